@@ -0,0 +1,323 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: news/v1/news.proto
+
+package newsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// NewsServiceClient is the client API for NewsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NewsServiceClient interface {
+	GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*Post, error)
+	// CreatePost creates a post in draft, scheduled or published status. Unspecified status means draft.
+	CreatePost(ctx context.Context, in *CreatePostRequest, opts ...grpc.CallOption) (*Post, error)
+	// UpsertPost creates or replaces the post with the id. Non zero version makes it a conditional update.
+	UpsertPost(ctx context.Context, in *UpsertPostRequest, opts ...grpc.CallOption) (*Post, error)
+	// DeletePost soft deletes the post. Non zero version makes it conditional.
+	DeletePost(ctx context.Context, in *DeletePostRequest, opts ...grpc.CallOption) (*DeletePostResponse, error)
+	FindPosts(ctx context.Context, in *FindPostsRequest, opts ...grpc.CallOption) (*FindPostsResponse, error)
+	// StreamPosts streams the posts FindPosts would return one by one, limits larger than the default one
+	// are fetched page by page
+	StreamPosts(ctx context.Context, in *FindPostsRequest, opts ...grpc.CallOption) (NewsService_StreamPostsClient, error)
+}
+
+type newsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNewsServiceClient(cc grpc.ClientConnInterface) NewsServiceClient {
+	return &newsServiceClient{cc}
+}
+
+func (c *newsServiceClient) GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, "/news.v1.NewsService/GetPost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) CreatePost(ctx context.Context, in *CreatePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, "/news.v1.NewsService/CreatePost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) UpsertPost(ctx context.Context, in *UpsertPostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, "/news.v1.NewsService/UpsertPost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) DeletePost(ctx context.Context, in *DeletePostRequest, opts ...grpc.CallOption) (*DeletePostResponse, error) {
+	out := new(DeletePostResponse)
+	err := c.cc.Invoke(ctx, "/news.v1.NewsService/DeletePost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) FindPosts(ctx context.Context, in *FindPostsRequest, opts ...grpc.CallOption) (*FindPostsResponse, error) {
+	out := new(FindPostsResponse)
+	err := c.cc.Invoke(ctx, "/news.v1.NewsService/FindPosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) StreamPosts(ctx context.Context, in *FindPostsRequest, opts ...grpc.CallOption) (NewsService_StreamPostsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &NewsService_ServiceDesc.Streams[0], "/news.v1.NewsService/StreamPosts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &newsServiceStreamPostsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NewsService_StreamPostsClient interface {
+	Recv() (*Post, error)
+	grpc.ClientStream
+}
+
+type newsServiceStreamPostsClient struct {
+	grpc.ClientStream
+}
+
+func (x *newsServiceStreamPostsClient) Recv() (*Post, error) {
+	m := new(Post)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewsServiceServer is the server API for NewsService service.
+// All implementations must embed UnimplementedNewsServiceServer
+// for forward compatibility
+type NewsServiceServer interface {
+	GetPost(context.Context, *GetPostRequest) (*Post, error)
+	// CreatePost creates a post in draft, scheduled or published status. Unspecified status means draft.
+	CreatePost(context.Context, *CreatePostRequest) (*Post, error)
+	// UpsertPost creates or replaces the post with the id. Non zero version makes it a conditional update.
+	UpsertPost(context.Context, *UpsertPostRequest) (*Post, error)
+	// DeletePost soft deletes the post. Non zero version makes it conditional.
+	DeletePost(context.Context, *DeletePostRequest) (*DeletePostResponse, error)
+	FindPosts(context.Context, *FindPostsRequest) (*FindPostsResponse, error)
+	// StreamPosts streams the posts FindPosts would return one by one, limits larger than the default one
+	// are fetched page by page
+	StreamPosts(*FindPostsRequest, NewsService_StreamPostsServer) error
+	mustEmbedUnimplementedNewsServiceServer()
+}
+
+// UnimplementedNewsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedNewsServiceServer struct {
+}
+
+func (UnimplementedNewsServiceServer) GetPost(context.Context, *GetPostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPost not implemented")
+}
+func (UnimplementedNewsServiceServer) CreatePost(context.Context, *CreatePostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePost not implemented")
+}
+func (UnimplementedNewsServiceServer) UpsertPost(context.Context, *UpsertPostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertPost not implemented")
+}
+func (UnimplementedNewsServiceServer) DeletePost(context.Context, *DeletePostRequest) (*DeletePostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePost not implemented")
+}
+func (UnimplementedNewsServiceServer) FindPosts(context.Context, *FindPostsRequest) (*FindPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindPosts not implemented")
+}
+func (UnimplementedNewsServiceServer) StreamPosts(*FindPostsRequest, NewsService_StreamPostsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPosts not implemented")
+}
+func (UnimplementedNewsServiceServer) mustEmbedUnimplementedNewsServiceServer() {}
+
+// UnsafeNewsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NewsServiceServer will
+// result in compilation errors.
+type UnsafeNewsServiceServer interface {
+	mustEmbedUnimplementedNewsServiceServer()
+}
+
+func RegisterNewsServiceServer(s grpc.ServiceRegistrar, srv NewsServiceServer) {
+	s.RegisterService(&NewsService_ServiceDesc, srv)
+}
+
+func _NewsService_GetPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).GetPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/news.v1.NewsService/GetPost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).GetPost(ctx, req.(*GetPostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_CreatePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).CreatePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/news.v1.NewsService/CreatePost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).CreatePost(ctx, req.(*CreatePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_UpsertPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertPostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).UpsertPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/news.v1.NewsService/UpsertPost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).UpsertPost(ctx, req.(*UpsertPostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_DeletePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).DeletePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/news.v1.NewsService/DeletePost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).DeletePost(ctx, req.(*DeletePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_FindPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).FindPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/news.v1.NewsService/FindPosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).FindPosts(ctx, req.(*FindPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_StreamPosts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindPostsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NewsServiceServer).StreamPosts(m, &newsServiceStreamPostsServer{stream})
+}
+
+type NewsService_StreamPostsServer interface {
+	Send(*Post) error
+	grpc.ServerStream
+}
+
+type newsServiceStreamPostsServer struct {
+	grpc.ServerStream
+}
+
+func (x *newsServiceStreamPostsServer) Send(m *Post) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// NewsService_ServiceDesc is the grpc.ServiceDesc for NewsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NewsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "news.v1.NewsService",
+	HandlerType: (*NewsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPost",
+			Handler:    _NewsService_GetPost_Handler,
+		},
+		{
+			MethodName: "CreatePost",
+			Handler:    _NewsService_CreatePost_Handler,
+		},
+		{
+			MethodName: "UpsertPost",
+			Handler:    _NewsService_UpsertPost_Handler,
+		},
+		{
+			MethodName: "DeletePost",
+			Handler:    _NewsService_DeletePost_Handler,
+		},
+		{
+			MethodName: "FindPosts",
+			Handler:    _NewsService_FindPosts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPosts",
+			Handler:       _NewsService_StreamPosts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "news/v1/news.proto",
+}
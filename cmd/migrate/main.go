@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/migrator"
+	"github.com/sladonia/news-svc/migration"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down        revert the last applied migration
+  status      list migrations and whether they are applied
+  to N        apply or revert migrations until the schema is at version N
+  baseline N  record migrations up to version N as applied without running them,
+              adopts the schema created before the migrator
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	config, err := LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("load config: %s", err))
+	}
+
+	log, err := logger.NewZapLogger(config.LogLevel)
+	if err != nil {
+		panic(fmt.Sprintf("init logger: %s", err))
+	}
+	defer log.Sync()
+
+	if len(os.Args) < 2 {
+		exitWithUsage()
+	}
+
+	db, err := sql.Open("postgres", config.PostgresDSN)
+	if err != nil {
+		log.Fatal("create database", zap.Error(err))
+	}
+	defer db.Close()
+
+	migrations, err := migrator.Load(migration.FS)
+	if err != nil {
+		log.Fatal("load migrations", zap.Error(err))
+	}
+
+	m := migrator.New(db, migrations, log)
+
+	switch os.Args[1] {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx)
+	case "status":
+		err = printStatus(ctx, m)
+	case "to":
+		err = migrateTo(ctx, m.To, os.Args[2:])
+	case "baseline":
+		err = migrateTo(ctx, m.Baseline, os.Args[2:])
+	default:
+		exitWithUsage()
+	}
+
+	if err != nil {
+		log.Fatal("migrate", zap.String("command", os.Args[1]), zap.Error(err))
+	}
+}
+
+// migrateTo calls fn with the version given in args
+func migrateTo(ctx context.Context, fn func(ctx context.Context, version uint) error, args []string) error {
+	if len(args) != 1 {
+		exitWithUsage()
+	}
+
+	version, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("version should be a non negative integer: %w", err)
+	}
+
+	return fn(ctx, uint(version))
+}
+
+func printStatus(ctx context.Context, m *migrator.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
+
+func exitWithUsage() {
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+}
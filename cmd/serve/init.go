@@ -1,20 +1,56 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/feed"
+	"github.com/sladonia/news-svc/internal/graphqlhandler"
+	"github.com/sladonia/news-svc/internal/grpchandler"
 	"github.com/sladonia/news-svc/internal/handler"
 	"github.com/sladonia/news-svc/internal/handler/middlewares"
+	"github.com/sladonia/news-svc/internal/health"
 	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/memstorage"
+	"github.com/sladonia/news-svc/internal/migrator"
+	"github.com/sladonia/news-svc/internal/policy"
 	"github.com/sladonia/news-svc/internal/post"
 	"github.com/sladonia/news-svc/internal/poststorage"
+	"github.com/sladonia/news-svc/internal/ratelimit"
+	"github.com/sladonia/news-svc/migration"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const (
+	storageTypePostgres = "postgres"
+	storageTypeMemory   = "memory"
+
+	tracingExporterNone   = "none"
+	tracingExporterOTLP   = "otlp"
+	tracingExporterStdout = "stdout"
+	tracingExporterFile   = "file"
 )
 
 func mustInitZapLogger(logLevel string) *zap.Logger {
@@ -35,21 +71,376 @@ func mustLoadConfig() Config {
 	return config
 }
 
-func mustCreateDatabaseConnection(config Config, log *zap.Logger) *goqu.Database {
+// mustCreateTracerProvider installs W3C trace context propagator and the global tracer provider exporting
+// spans with the configured exporter. Nil is returned if tracing is disabled.
+func mustCreateTracerProvider(ctx context.Context, config Config, log *zap.Logger) *sdktrace.TracerProvider {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter := mustCreateSpanExporter(ctx, config, log)
+	if exporter == nil {
+		return nil
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceNameKey.String(config.ServiceName)),
+	)
+	if err != nil {
+		log.Panic("create tracing resource", zap.Error(err))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.Tracing.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider
+}
+
+func mustCreateSpanExporter(ctx context.Context, config Config, log *zap.Logger) sdktrace.SpanExporter {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch config.Tracing.Exporter {
+	case tracingExporterNone:
+		return nil
+	case tracingExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Tracing.OTLPEndpoint)}
+
+		if config.Tracing.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case tracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case tracingExporterFile:
+		var file *os.File
+
+		file, err = os.OpenFile(config.Tracing.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Panic("open tracing file", zap.String("path", config.Tracing.File), zap.Error(err))
+		}
+
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		log.Panic("unknown tracing exporter", zap.String("exporter", config.Tracing.Exporter))
+	}
+
+	if err != nil {
+		log.Panic("create span exporter", zap.String("exporter", config.Tracing.Exporter), zap.Error(err))
+	}
+
+	return exporter
+}
+
+// shutdownTracerProvider flushes the spans left in the batcher
+func shutdownTracerProvider(config Config, log *zap.Logger, provider *sdktrace.TracerProvider) {
+	if provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		log.Error("tracer provider shutdown", zap.Error(err))
+	}
+}
+
+// newMetricsRegistry returns the registry of the service metrics along with the go runtime and process ones
+func newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return registry
+}
+
+func mustCreateDatabaseConnection(config Config, log *zap.Logger, registerer prometheus.Registerer) *sql.DB {
 	postgresClient, err := sql.Open("postgres", config.PostgresDSN)
 	if err != nil {
 		log.Panic("create database", zap.Error(err))
 	}
 
-	return goqu.New("postgres", postgresClient)
+	registerer.MustRegister(collectors.NewDBStatsCollector(postgresClient, "news"))
+
+	return postgresClient
+}
+
+// mustMigrate brings the schema up to date. Concurrently starting instances wait
+// for each other on the migrator advisory lock.
+func mustMigrate(ctx context.Context, config Config, log *zap.Logger, db *sql.DB) {
+	if !config.AutoMigrate || config.StorageType != storageTypePostgres {
+		return
+	}
+
+	migrations, err := migrator.Load(migration.FS)
+	if err != nil {
+		log.Panic("load migrations", zap.Error(err))
+	}
+
+	m := migrator.New(db, migrations, log)
+
+	// databases initialized before the migrator have the schema but no applied migrations,
+	// running the migrations on them would fail
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		log.Panic("get migration status", zap.Error(err))
+	}
+
+	var versioned, tableExists bool
+
+	for _, status := range statuses {
+		versioned = versioned || status.Applied
+	}
+
+	err = db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", config.PostTableName).Scan(&tableExists)
+	if err != nil {
+		log.Panic("check post table", zap.Error(err))
+	}
+
+	if !versioned && tableExists {
+		log.Panic("database schema is not versioned, record the applied migrations with `migrate baseline <version>`")
+	}
+
+	err = m.Up(ctx)
+	if err != nil {
+		log.Panic("migrate database", zap.Error(err))
+	}
+}
+
+// mustCreateStorages returns the instrumented post and author storages. Memory storages are linked
+// the way the tables of the database are.
+func mustCreateStorages(
+	config Config,
+	log *zap.Logger,
+	db *sql.DB,
+	metrics *poststorage.Metrics,
+) (post.Storage, author.Storage) {
+	var (
+		posts   post.Storage
+		authors author.Storage
+	)
+
+	switch config.StorageType {
+	case storageTypePostgres:
+		posts = poststorage.New(goqu.New("postgres", db), config.PostTableName)
+		authors = poststorage.NewAuthorStorage(goqu.New("postgres", db))
+	case storageTypeMemory:
+		posts = memstorage.New()
+		authors = memstorage.NewAuthorStorage(posts)
+	default:
+		log.Panic("unknown storage type", zap.String("storage_type", config.StorageType))
+	}
+
+	return poststorage.NewInstrumented(posts, metrics), poststorage.NewInstrumentedAuthorStorage(authors, metrics)
+}
+
+// mustCreateAuthenticator returns nil if auth is disabled
+func mustCreateAuthenticator(config Config, log *zap.Logger) *auth.Authenticator {
+	if config.Auth.Disabled {
+		log.Warn("auth is disabled, all the endpoints are public")
+		return nil
+	}
+
+	var (
+		jwtVerifier *auth.JWTVerifier
+		apiKeys     *auth.APIKeys
+		publicKeys  = mustLoadJWTPublicKeys(config, log)
+	)
+
+	if config.Auth.JWTSecret != "" || len(publicKeys) > 0 {
+		jwtVerifier = auth.NewJWTVerifier(auth.JWTConfig{
+			Secret:     []byte(config.Auth.JWTSecret),
+			PublicKeys: publicKeys,
+			Issuer:     config.Auth.JWTIssuer,
+			Audience:   config.Auth.JWTAudience,
+		})
+	}
+
+	if config.Auth.APIKeysFile != "" {
+		var err error
+
+		apiKeys, err = auth.LoadAPIKeys(config.Auth.APIKeysFile)
+		if err != nil {
+			log.Panic("load api keys", zap.String("path", config.Auth.APIKeysFile), zap.Error(err))
+		}
+	}
+
+	if jwtVerifier == nil && apiKeys == nil {
+		log.Panic("auth is enabled, but neither jwt keys nor api keys are configured")
+	}
+
+	return auth.NewAuthenticator(jwtVerifier, apiKeys)
+}
+
+// mustLoadJWTPublicKeys merges the RS256 keys of the public key and JWKS files
+func mustLoadJWTPublicKeys(config Config, log *zap.Logger) map[string]*rsa.PublicKey {
+	publicKeys := make(map[string]*rsa.PublicKey)
+
+	if config.Auth.JWTPublicKeyFile != "" {
+		keys, err := auth.LoadRSAPublicKey(config.Auth.JWTPublicKeyFile)
+		if err != nil {
+			log.Panic("load jwt public key", zap.Error(err))
+		}
+
+		for kid, key := range keys {
+			publicKeys[kid] = key
+		}
+	}
+
+	if config.Auth.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(config.Auth.JWKSFile)
+		if err != nil {
+			log.Panic("load jwks", zap.Error(err))
+		}
+
+		for kid, key := range keys {
+			publicKeys[kid] = key
+		}
+	}
+
+	return publicKeys
+}
+
+// mustCreatePolicyService authorizes post modifications by the roles of the callers unless auth is disabled
+func mustCreatePolicyService(config Config, log *zap.Logger, postService post.Service) post.Service {
+	if config.Auth.Disabled {
+		return postService
+	}
+
+	p := policy.DefaultPolicy()
+
+	if config.Auth.PolicyFile != "" {
+		var err error
+
+		p, err = policy.LoadPolicy(config.Auth.PolicyFile)
+		if err != nil {
+			log.Panic("load policy", zap.String("path", config.Auth.PolicyFile), zap.Error(err))
+		}
+	}
+
+	return policy.NewService(postService, p)
+}
+
+// newHealthChecker checks the database and reports the schema version of the postgres storage
+func newHealthChecker(config Config, log *zap.Logger, db *sql.DB) *health.Checker {
+	checker := health.NewChecker(config.Health.CheckTimeout)
+
+	if config.StorageType != storageTypePostgres {
+		return checker
+	}
+
+	migrations, err := migrator.Load(migration.FS)
+	if err != nil {
+		log.Panic("load migrations", zap.Error(err))
+	}
+
+	var latest uint
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	m := migrator.New(db, migrations, log)
+
+	return checker.
+		Add("postgres", func(ctx context.Context) (interface{}, error) {
+			return nil, db.PingContext(ctx)
+		}).
+		Add("migrations", func(ctx context.Context) (interface{}, error) {
+			version, err := m.Version(ctx)
+
+			return struct {
+				Version uint `json:"version"`
+				Latest  uint `json:"latest"`
+			}{Version: version, Latest: latest}, err
+		})
+}
+
+func newHandler(
+	config Config,
+	log *zap.Logger,
+	postService post.Service,
+	authorService author.Service,
+) *handler.Handler {
+	return handler.NewHandler(
+		log,
+		config.DefaultNewsLimit,
+		config.MaxNewsLimit,
+		postService,
+		authorService,
+		config.ServiceName,
+	)
+}
+
+func newFeedHandler(config Config, log *zap.Logger, postService post.Service) *feed.Handler {
+	meta := feed.Meta{
+		Title:       config.Feed.Title,
+		Link:        config.Feed.Link,
+		Description: config.Feed.Description,
+		ServiceName: config.ServiceName,
+	}
+
+	return feed.NewHandler(log, postService, meta, config.Feed.Limit, config.Feed.MaxLimit)
+}
+
+func newGraphQLHandler(
+	config Config,
+	log *zap.Logger,
+	postService post.Service,
+	authorService author.Service,
+) *graphqlhandler.Handler {
+	limits := graphqlhandler.Limits{
+		MaxDepth:      config.GraphQL.MaxDepth,
+		MaxComplexity: config.GraphQL.MaxComplexity,
+		MaxPage:       config.GraphQL.MaxPage,
+	}
+
+	h := graphqlhandler.NewHandler(log, postService, authorService, config.DefaultNewsLimit, limits)
+
+	if !config.Auth.Disabled {
+		h.RequireAuth()
+	}
+
+	if config.Debug {
+		h.ServeGraphiQL()
+	}
+
+	return h
 }
 
-func newPostStorage(config Config, db *goqu.Database) post.Storage {
-	return poststorage.New(db, config.PostTableName)
+// createGRPCServer returns nil if the gRPC API is disabled
+func createGRPCServer(
+	config Config,
+	log *zap.Logger,
+	postService post.Service,
+	authenticator *auth.Authenticator,
+) *grpc.Server {
+	if config.GRPC.Disabled {
+		return nil
+	}
+
+	srv := grpc.NewServer(grpchandler.ServerOptions(log, authenticator)...)
+	grpchandler.NewServer(log, postService, config.DefaultNewsLimit).Register(srv)
+
+	return srv
 }
 
-func newHandler(config Config, log *zap.Logger, postService post.Service) *handler.Handler {
-	return handler.NewHandler(log, config.DefaultNewsLimit, postService, config.ServiceName)
+func mustListenGRPC(config Config, log *zap.Logger) net.Listener {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", config.GRPC.Listen))
+	if err != nil {
+		log.Panic("listen grpc", zap.Error(err))
+	}
+
+	return lis
 }
 
 func createHTTPServer(config Config, router http.Handler) *http.Server {
@@ -61,9 +452,104 @@ func createHTTPServer(config Config, router http.Handler) *http.Server {
 	}
 }
 
-func registerHTTPHandlers(log *zap.Logger, r *mux.Router, handler *handler.Handler) {
+// mustCreateRateLimit returns nil if rate limiting is disabled. Probes and metrics are not limited
+// unless their limits are configured.
+func mustCreateRateLimit(config Config, log *zap.Logger, store ratelimit.Store) *middlewares.RateLimitMiddleware {
+	if config.RateLimit.Disabled {
+		return nil
+	}
+
+	defaultLimit, err := ratelimit.ParseLimit(config.RateLimit.Default)
+	if err != nil {
+		log.Panic("parse default rate limit", zap.Error(err))
+	}
+
+	limits, err := ratelimit.ParseLimits(config.RateLimit.Routes)
+	if err != nil {
+		log.Panic("parse route rate limits", zap.Error(err))
+	}
+
+	m := middlewares.NewRateLimit(log, store, defaultLimit).
+		Limit(ratelimit.Limit{}, "healthz", "readyz", "metrics")
+
+	for route, limit := range limits {
+		m.Limit(limit, route)
+	}
+
+	if config.RateLimit.TrustForwardedFor {
+		m.TrustForwardedFor()
+	}
+
+	return m
+}
+
+// mustCreateIPRateLimit returns nil if rate limiting is disabled or the IP limit is zero. It limits
+// the requests before auth, the ones failing it included.
+func mustCreateIPRateLimit(config Config, log *zap.Logger, store ratelimit.Store) *middlewares.RateLimitMiddleware {
+	if config.RateLimit.Disabled {
+		return nil
+	}
+
+	limit, err := ratelimit.ParseLimit(config.RateLimit.IP)
+	if err != nil {
+		log.Panic("parse ip rate limit", zap.Error(err))
+	}
+
+	if limit.IsZero() {
+		return nil
+	}
+
+	m := middlewares.NewRateLimit(log, store, limit).
+		ByIP().
+		Limit(ratelimit.Limit{}, "healthz", "readyz", "metrics")
+
+	if config.RateLimit.TrustForwardedFor {
+		m.TrustForwardedFor()
+	}
+
+	return m
+}
+
+func registerHTTPHandlers(
+	config Config,
+	log *zap.Logger,
+	r *mux.Router,
+	handler *handler.Handler,
+	healthHandler *health.Handler,
+	feedHandler *feed.Handler,
+	graphqlHandler *graphqlhandler.Handler,
+	authenticator *auth.Authenticator,
+	registry *prometheus.Registry,
+) {
+	r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Name("metrics").Methods("GET")
+
+	middlewares.NewTracing(config.ServiceName).Register(r)
 	middlewares.NewHandlerLogger(log).Register(r)
+	middlewares.NewMetrics(registry).Register(r)
+	middlewares.NewRecovery(log, registry).Register(r)
 	middlewares.NewJsonResponse().Register(r)
 
+	rateLimitStore := ratelimit.NewMemoryStore()
+
+	if ipRateLimit := mustCreateIPRateLimit(config, log, rateLimitStore); ipRateLimit != nil {
+		ipRateLimit.Register(r)
+	}
+
+	if authenticator != nil {
+		// graphql mutations are authorized by the handler, so that queries stay public
+		middlewares.NewAuth(log, authenticator).Require("", "graphql").Register(r)
+	}
+
+	if rateLimit := mustCreateRateLimit(config, log, rateLimitStore); rateLimit != nil {
+		rateLimit.Register(r)
+	}
+
+	if config.Debug {
+		middlewares.NewOpenAPIValidation(log, handler.OpenAPI()).Register(r)
+	}
+
 	handler.Register(r)
+	healthHandler.Register(r)
+	feedHandler.Register(r)
+	graphqlHandler.Register(r)
 }
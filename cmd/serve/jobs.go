@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+// runPeriodically calls fn every interval until ctx is done. Zero interval disables the job.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
+
+func runTrashPurger(ctx context.Context, config Config, log *zap.Logger, postService post.Service) {
+	runPeriodically(ctx, config.Trash.PurgeInterval, func(ctx context.Context) {
+		purged, err := postService.PurgeTrash(ctx, config.Trash.Retention)
+		if err != nil {
+			log.Error("failed to purge trash", zap.Error(err))
+			return
+		}
+
+		log.Info("trash purged", zap.Int64("posts", purged))
+	})
+}
+
+// runScheduler publishes the scheduled posts once their publish time comes
+func runScheduler(ctx context.Context, config Config, log *zap.Logger, postService post.Service) {
+	runPeriodically(ctx, config.Scheduler.Interval, func(ctx context.Context) {
+		published, err := postService.PublishScheduled(ctx)
+		if err != nil {
+			log.Error("failed to publish scheduled posts", zap.Error(err))
+			return
+		}
+
+		if published > 0 {
+			log.Info("scheduled posts published", zap.Int64("posts", published))
+		}
+	})
+}
@@ -5,11 +5,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/health"
 	"github.com/sladonia/news-svc/internal/post"
+	"github.com/sladonia/news-svc/internal/poststorage"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -24,36 +30,100 @@ func main() {
 
 	log.Info("config loaded", zap.Any("config", config))
 
+	tracerProvider := mustCreateTracerProvider(ctx, config, log)
+	defer shutdownTracerProvider(config, log, tracerProvider)
+
+	registry := newMetricsRegistry()
+
+	db := mustCreateDatabaseConnection(config, log, registry)
+	mustMigrate(ctx, config, log, db)
+
 	var (
-		db          = mustCreateDatabaseConnection(config, log)
-		postStorage = newPostStorage(config, db)
-		postService = post.NewService(postStorage)
-		handler     = newHandler(config, log, postService)
-		router      = mux.NewRouter()
-		server      = createHTTPServer(config, router)
+		storageMetrics             = poststorage.NewMetrics(registry)
+		postStorage, authorStorage = mustCreateStorages(config, log, db, storageMetrics)
+		postService                = post.NewTracedService(post.NewService(postStorage))
+		authorService              = author.NewService(authorStorage)
+		policyService              = mustCreatePolicyService(config, log, postService)
+		authenticator              = mustCreateAuthenticator(config, log)
+		handler                    = newHandler(config, log, policyService, authorService)
+		router                     = mux.NewRouter()
+		server                     = createHTTPServer(config, router)
+		grpcServer                 = createGRPCServer(config, log, policyService, authenticator)
+		checker                    = newHealthChecker(config, log, db)
 	)
 
-	registerHTTPHandlers(log, router, handler)
-	run(ctx, config, log, server, stop)
+	registerHTTPHandlers(
+		config,
+		log,
+		router,
+		handler,
+		health.NewHandler(log, checker),
+		newFeedHandler(config, log, postService),
+		newGraphQLHandler(config, log, policyService, authorService),
+		authenticator,
+		registry,
+	)
+
+	go runTrashPurger(ctx, config, log, postService)
+	go runScheduler(ctx, config, log, postService)
+
+	run(ctx, config, log, server, grpcServer, checker, stop)
 }
 
-func run(ctx context.Context, config Config, log *zap.Logger, srv *http.Server, stop func()) {
-	errCh := make(chan error)
+// run serves HTTP and gRPC, if it is enabled, until the context is done or one of the servers fails.
+// Both servers are shut down gracefully within HTTP_SHUTDOWN_TIMEOUT.
+func run(
+	ctx context.Context,
+	config Config,
+	log *zap.Logger,
+	srv *http.Server,
+	grpcSrv *grpc.Server,
+	checker *health.Checker,
+	stop func(),
+) {
+	errCh := make(chan error, 2)
 
 	go func() {
 		errCh <- srv.ListenAndServe()
 	}()
 
+	if grpcSrv != nil {
+		lis := mustListenGRPC(config, log)
+
+		go func() {
+			errCh <- grpcSrv.Serve(lis)
+		}()
+	}
+
 	shutdown := func(err error) {
 		stop()
+		checker.ShutDown()
+
+		if err == nil {
+			// keep serving while the load balancers notice the service is not ready
+			time.Sleep(config.HTTP.ShutdownDelay)
+		}
 
 		timeoutCtx, cancelTimeout := context.WithTimeout(context.Background(), config.HTTP.ShutdownTimeout)
 		defer cancelTimeout()
 
+		var wg sync.WaitGroup
+
+		if grpcSrv != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				stopGRPCServer(timeoutCtx, grpcSrv)
+			}()
+		}
+
 		if err := srv.Shutdown(timeoutCtx); err != nil {
 			log.Error("server shutdown", zap.Error(err))
 		}
 
+		wg.Wait()
+
 		if err != nil {
 			log.Error("shutdown caused by error", zap.Error(err))
 			os.Exit(1)
@@ -67,3 +137,19 @@ func run(ctx context.Context, config Config, log *zap.Logger, srv *http.Server,
 		shutdown(err)
 	}
 }
+
+// stopGRPCServer waits for the calls in flight to finish, the ones still running are canceled once ctx is done
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
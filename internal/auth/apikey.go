@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// APIKey is a static key issued to the service clients
+type APIKey struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	Role    Role     `json:"role"` // RoleReader unless set
+	Scopes  []string `json:"scopes"`
+}
+
+// APIKeys looks principals up by the key. Keys are kept hashed, so lookups don't leak them through timing.
+type APIKeys struct {
+	principals map[[sha256.Size]byte]Principal
+}
+
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	principals := make(map[[sha256.Size]byte]Principal, len(keys))
+
+	for _, key := range keys {
+		role, err := parseRole(string(key.Role))
+		if err != nil {
+			return nil, fmt.Errorf("api key of %q: %w", key.Subject, err)
+		}
+
+		principals[sha256.Sum256([]byte(key.Key))] = Principal{Subject: key.Subject, Role: role, Scopes: key.Scopes}
+	}
+
+	return &APIKeys{principals: principals}, nil
+}
+
+// LoadAPIKeys reads the JSON array of APIKey from the file
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []APIKey
+
+	err = jsoniter.ConfigFastest.Unmarshal(data, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("parse api keys: %w", err)
+	}
+
+	return NewAPIKeys(keys)
+}
+
+func (k *APIKeys) Principal(key string) (Principal, error) {
+	principal, ok := k.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+// Authenticator authenticates requests carrying either a bearer JWT or an X-API-Key header.
+// Nil verifier or keys disable the corresponding credentials.
+type Authenticator struct {
+	jwt     *JWTVerifier
+	apiKeys *APIKeys
+}
+
+func NewAuthenticator(jwt *JWTVerifier, apiKeys *APIKeys) *Authenticator {
+	return &Authenticator{jwt: jwt, apiKeys: apiKeys}
+}
+
+// Authenticate returns ErrNoCredentials for anonymous requests and ErrInvalidCredentials for the rejected ones
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	return a.AuthenticateCredentials(r.Header.Get(APIKeyHeader), r.Header.Get(authorizationHeader))
+}
+
+// AuthenticateCredentials authenticates the API key or the Authorization header value passed by other means
+// than HTTP headers, e.g. gRPC metadata. API key takes precedence.
+func (a *Authenticator) AuthenticateCredentials(apiKey, authorization string) (Principal, error) {
+	if apiKey != "" {
+		if a.apiKeys == nil {
+			return Principal{}, ErrInvalidCredentials
+		}
+
+		return a.apiKeys.Principal(apiKey)
+	}
+
+	if authorization == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	if !strings.HasPrefix(authorization, bearerPrefix) || a.jwt == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return a.jwt.Principal(strings.TrimPrefix(authorization, bearerPrefix))
+}
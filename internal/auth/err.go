@@ -0,0 +1,8 @@
+package auth
+
+import "errors"
+
+var (
+	ErrNoCredentials      = errors.New("no credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type JWTConfig struct {
+	Secret     []byte                    // verifies HS256 tokens
+	PublicKeys map[string]*rsa.PublicKey // verify RS256 tokens by kid, the key with empty kid matches any token
+	Issuer     string                    // required iss claim unless empty
+	Audience   string                    // required aud claim unless empty
+}
+
+// JWTVerifier verifies HS256 and RS256 signed tokens. Principal subject is taken from the sub claim,
+// role from the role claim and scopes from the space separated scope claim.
+type JWTVerifier struct {
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Role  string `json:"role"`
+	Scope string `json:"scope"`
+}
+
+func NewJWTVerifier(config JWTConfig) *JWTVerifier {
+	var methods []string
+
+	if len(config.Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if len(config.PublicKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	return &JWTVerifier{
+		config: config,
+		parser: jwt.NewParser(jwt.WithValidMethods(methods)),
+	}
+}
+
+func (v *JWTVerifier) Principal(token string) (Principal, error) {
+	var c claims
+
+	_, err := v.parser.ParseWithClaims(token, &c, v.key)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+
+	if v.config.Issuer != "" && !c.VerifyIssuer(v.config.Issuer, true) {
+		return Principal{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidCredentials)
+	}
+
+	if v.config.Audience != "" && !c.VerifyAudience(v.config.Audience, true) {
+		return Principal{}, fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+
+	if c.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: no subject", ErrInvalidCredentials)
+	}
+
+	role, err := parseRole(c.Role)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+
+	return Principal{Subject: c.Subject, Role: role, Scopes: strings.Fields(c.Scope)}, nil
+}
+
+func (v *JWTVerifier) key(token *jwt.Token) (interface{}, error) {
+	if token.Method == jwt.SigningMethodHS256 {
+		return v.config.Secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	if key, ok := v.config.PublicKeys[kid]; ok {
+		return key, nil
+	}
+
+	if key, ok := v.config.PublicKeys[""]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// LoadRSAPublicKey reads PEM encoded public key, which matches tokens with any kid
+func LoadRSAPublicKey(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]*rsa.PublicKey{"": key}, nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// LoadJWKS reads RSA signing keys of the local JSON Web Key Set file
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+
+	err = jsoniter.ConfigFastest.Unmarshal(data, &set)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwks key %q modulus: %w", k.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwks key %q exponent: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("secret")
+
+func TestJWTVerifier(t *testing.T) {
+	t.Run("hs256", func(t *testing.T) {
+		v := NewJWTVerifier(JWTConfig{Secret: secret})
+
+		principal, err := v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", newClaims("bob", "write edit")))
+		require.NoError(t, err)
+		assert.Equal(t, Principal{Subject: "bob", Role: RoleReader, Scopes: []string{ScopeWrite, ScopeEdit}}, principal)
+	})
+
+	t.Run("wrong_secret", func(t *testing.T) {
+		v := NewJWTVerifier(JWTConfig{Secret: secret})
+
+		_, err := v.Principal(sign(t, jwt.SigningMethodHS256, []byte("other"), "", newClaims("bob", "")))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		v := NewJWTVerifier(JWTConfig{Secret: secret})
+
+		c := newClaims("bob", "")
+		c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+		_, err := v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", c))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("no_subject", func(t *testing.T) {
+		v := NewJWTVerifier(JWTConfig{Secret: secret})
+
+		_, err := v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", newClaims("", "write")))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("issuer_and_audience", func(t *testing.T) {
+		v := NewJWTVerifier(JWTConfig{Secret: secret, Issuer: "issuer", Audience: "news"})
+
+		c := newClaims("bob", "")
+		c.Issuer = "issuer"
+		c.Audience = jwt.ClaimStrings{"news"}
+
+		_, err := v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", c))
+		assert.NoError(t, err)
+
+		c.Issuer = "other"
+
+		_, err = v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", c))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		c.Issuer = "issuer"
+		c.Audience = jwt.ClaimStrings{"other"}
+
+		_, err = v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", c))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("rs256_jwks", func(t *testing.T) {
+		key1, key2 := newRSAKey(t), newRSAKey(t)
+
+		keys, err := LoadJWKS(writeJWKS(t, map[string]*rsa.PublicKey{"1": &key1.PublicKey, "2": &key2.PublicKey}))
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+
+		v := NewJWTVerifier(JWTConfig{PublicKeys: keys})
+
+		principal, err := v.Principal(sign(t, jwt.SigningMethodRS256, key2, "2", newClaims("alice", "write")))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", principal.Subject)
+
+		_, err = v.Principal(sign(t, jwt.SigningMethodRS256, key2, "1", newClaims("alice", "write")))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = v.Principal(sign(t, jwt.SigningMethodRS256, key1, "3", newClaims("alice", "write")))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unexpected_method", func(t *testing.T) {
+		key := newRSAKey(t)
+		v := NewJWTVerifier(JWTConfig{PublicKeys: map[string]*rsa.PublicKey{"": &key.PublicKey}})
+
+		_, err := v.Principal(sign(t, jwt.SigningMethodHS256, secret, "", newClaims("bob", "")))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	err := os.WriteFile(path, []byte(`[{"key":"k1","subject":"bob","scopes":["write"]}]`), 0o600)
+	require.NoError(t, err)
+
+	keys, err := LoadAPIKeys(path)
+	require.NoError(t, err)
+
+	principal, err := keys.Principal("k1")
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "bob", Role: RoleReader, Scopes: []string{ScopeWrite}}, principal)
+
+	_, err = keys.Principal("k2")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func newClaims(subject, scope string) claims {
+	return claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Scope: scope,
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, c claims) string {
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key
+}
+
+func writeJWKS(t *testing.T, keys map[string]*rsa.PublicKey) string {
+	set := `{"keys":[`
+
+	i := 0
+	for kid, key := range keys {
+		if i > 0 {
+			set += ","
+		}
+
+		set += fmt.Sprintf(`{"kty":"RSA","use":"sig","kid":%q,"n":%q,"e":%q}`,
+			kid,
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		)
+		i++
+	}
+
+	set += `]}`
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	err := os.WriteFile(path, []byte(set), 0o600)
+	require.NoError(t, err)
+
+	return path
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ScopeWrite = "write" // create, update and delete posts
+	ScopeEdit  = "edit"  // modify posts of other authors
+)
+
+// Role is the set of permissions of the principal, see policy.Policy for what each role may do
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleWriter Role = "writer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// roleScopes are granted to the principal along with the scopes of its own
+var roleScopes = map[Role][]string{
+	RoleReader: nil,
+	RoleWriter: {ScopeWrite},
+	RoleEditor: {ScopeWrite, ScopeEdit},
+	RoleAdmin:  {ScopeWrite, ScopeEdit},
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleScopes[r]
+
+	return ok
+}
+
+// parseRole defaults empty role to RoleReader
+func parseRole(role string) (Role, error) {
+	if role == "" {
+		return RoleReader, nil
+	}
+
+	if !Role(role).IsValid() {
+		return "", fmt.Errorf("unknown role %q", role)
+	}
+
+	return Role(role), nil
+}
+
+type principalKey struct{}
+
+// Principal is the authenticated caller
+type Principal struct {
+	Subject string
+	Role    Role
+	Scopes  []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return hasScope(p.Scopes, scope) || hasScope(roleScopes[p.Role], scope)
+}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext reports false for anonymous callers
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+
+	return principal, ok
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package author
+
+import (
+	"context"
+	"time"
+)
+
+// Author writes posts, post.Post.AuthorID refers to Author.ID. Author.ID is the subject of the auth.Principal
+// writing the posts, so that the posts are attributed to the author profile of their writer.
+type Author struct {
+	ID        string
+	Name      string
+	Email     string `json:",omitempty"` // visible to the author and editors only
+	Bio       string `json:",omitempty"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewAuthor(id, name, email, bio string) Author {
+	return Author{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Bio:       bio,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		UpdatedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+}
+
+type Storage interface {
+	ByID(ctx context.Context, id string) (Author, error)
+	// List returns authors ordered by name, zero limit means no limit
+	List(ctx context.Context, limit, offset uint) ([]Author, error)
+	Count(ctx context.Context) (int64, error)
+	Insert(ctx context.Context, a Author) error
+	// Update replaces name, email and bio of the author with a.ID
+	Update(ctx context.Context, a Author) error
+	Remove(ctx context.Context, id string) error
+}
@@ -0,0 +1,9 @@
+package author
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("author not found")
+	ErrAlreadyExists = errors.New("author already exists")
+	ErrForbidden     = errors.New("only the author or an editor can modify the author")
+)
@@ -0,0 +1,104 @@
+package author
+
+import (
+	"context"
+
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// Service manages the author profiles of the post.ActorFromContext. Only the author or an editor can modify
+// the profile, ErrForbidden is returned to the others. Emails are hidden from the others as well.
+type Service interface {
+	GetAuthor(ctx context.Context, id string) (Author, error)
+	ListAuthors(ctx context.Context, limit, offset uint) ([]Author, error)
+	CountAuthors(ctx context.Context) (int64, error)
+	// CreateAuthor creates the profile of the actor
+	CreateAuthor(ctx context.Context, name, email, bio string) (Author, error)
+	// UpdateAuthor replaces name, email and bio of the author with a.ID and returns its new state
+	UpdateAuthor(ctx context.Context, a Author) (Author, error)
+	// DeleteAuthor deletes the author, posts of the author become anonymous
+	DeleteAuthor(ctx context.Context, id string) error
+}
+
+func NewService(storage Storage) Service {
+	return &service{storage: storage}
+}
+
+type service struct {
+	storage Storage
+}
+
+func (s *service) GetAuthor(ctx context.Context, id string) (Author, error) {
+	a, err := s.storage.ByID(ctx, id)
+	if err != nil {
+		return Author{}, err
+	}
+
+	return visible(ctx, a), nil
+}
+
+func (s *service) ListAuthors(ctx context.Context, limit, offset uint) ([]Author, error) {
+	authors, err := s.storage.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, a := range authors {
+		authors[i] = visible(ctx, a)
+	}
+
+	return authors, nil
+}
+
+func (s *service) CountAuthors(ctx context.Context) (int64, error) {
+	return s.storage.Count(ctx)
+}
+
+func (s *service) CreateAuthor(ctx context.Context, name, email, bio string) (Author, error) {
+	actor := post.ActorFromContext(ctx)
+	if actor.ID == "" {
+		return Author{}, ErrForbidden
+	}
+
+	a := NewAuthor(actor.ID, name, email, bio)
+
+	err := s.storage.Insert(ctx, a)
+
+	return a, err
+}
+
+func (s *service) UpdateAuthor(ctx context.Context, a Author) (Author, error) {
+	if !canModify(ctx, a.ID) {
+		return Author{}, ErrForbidden
+	}
+
+	err := s.storage.Update(ctx, a)
+	if err != nil {
+		return Author{}, err
+	}
+
+	return s.storage.ByID(ctx, a.ID)
+}
+
+func (s *service) DeleteAuthor(ctx context.Context, id string) error {
+	if !canModify(ctx, id) {
+		return ErrForbidden
+	}
+
+	return s.storage.Remove(ctx, id)
+}
+
+func canModify(ctx context.Context, id string) bool {
+	actor := post.ActorFromContext(ctx)
+
+	return actor.Editor || (actor.ID != "" && actor.ID == id)
+}
+
+// visible hides the email of the author from the others
+func visible(ctx context.Context, a Author) Author {
+	if !canModify(ctx, a.ID) {
+		a.Email = ""
+	}
+
+	return a
+}
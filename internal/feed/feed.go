@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// Meta describes the feed. Link is the base URL the post and feed URLs are built from.
+type Meta struct {
+	Title       string
+	Link        string
+	Description string
+	ServiceName string // reported as the feed generator
+}
+
+// Feed is the format independent feed of posts, which is encoded by the RSS, Atom and JSON Feed encoders
+type Feed struct {
+	Meta
+	URL     string // URL of the feed itself
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	ID        string
+	URL       string
+	Title     string
+	Content   string
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// New builds the feed of the posts. Feed is updated when the most recently updated post is.
+func New(meta Meta, url string, posts []post.Post) Feed {
+	f := Feed{Meta: meta, URL: url, Items: make([]Item, 0, len(posts))}
+
+	for _, p := range posts {
+		item := Item{
+			ID:        p.ID,
+			URL:       meta.postURL(p.ID),
+			Title:     p.Title,
+			Content:   p.Content,
+			Author:    p.AuthorID,
+			Tags:      p.Tags,
+			Published: p.CreatedAt,
+			Updated:   p.UpdatedAt,
+		}
+
+		if p.PublishAt != nil {
+			item.Published = *p.PublishAt
+		}
+
+		if p.Category != "" {
+			item.Tags = append([]string{p.Category}, p.Tags...)
+		}
+
+		if p.UpdatedAt.After(f.Updated) {
+			f.Updated = p.UpdatedAt
+		}
+
+		f.Items = append(f.Items, item)
+	}
+
+	return f
+}
+
+func (m Meta) postURL(id string) string {
+	return strings.TrimRight(m.Link, "/") + "/posts/" + id
+}
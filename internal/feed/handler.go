@@ -0,0 +1,197 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+type encoder struct {
+	contentType string
+	encode      func(w io.Writer, f Feed) error
+}
+
+var (
+	rssEncoder  = encoder{contentType: rssContentType, encode: EncodeRSS}
+	atomEncoder = encoder{contentType: atomContentType, encode: EncodeAtom}
+	jsonEncoder = encoder{contentType: jsonContentType, encode: EncodeJSON}
+)
+
+func NewHandler(log *zap.Logger, postService post.Service, meta Meta, defaultLimit, maxLimit uint) *Handler {
+	return &Handler{log: log, postService: postService, meta: meta, defaultLimit: defaultLimit, maxLimit: maxLimit}
+}
+
+// Handler serves the published posts as RSS at /feed.rss, Atom at /feed.atom and JSON Feed at /feed.json.
+// Feeds take the from, to and limit query parameters of the post list and are validated
+// by the newest post update time. The limit is clamped to maxLimit unless it's zero.
+type Handler struct {
+	log          *zap.Logger
+	postService  post.Service
+	meta         Meta
+	defaultLimit uint
+	maxLimit     uint
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/feed.rss", h.serve(rssEncoder)).Name("feedRSS").Methods("GET")
+	r.HandleFunc("/feed.atom", h.serve(atomEncoder)).Name("feedAtom").Methods("GET")
+	r.HandleFunc("/feed.json", h.serve(jsonEncoder)).Name("feedJSON").Methods("GET")
+}
+
+func (h *Handler) serve(e encoder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, ok := h.filter(w, r)
+		if !ok {
+			return
+		}
+
+		posts, err := h.postService.FindPosts(r.Context(), f)
+		if err != nil {
+			h.logFor(r).Error("failed to find posts", zap.Error(err))
+			h.writeApiError(w, http.StatusInternalServerError, handler.LevelSystem, "failed to find posts")
+
+			return
+		}
+
+		feed := New(h.meta, strings.TrimRight(h.meta.Link, "/")+r.URL.RequestURI(), posts)
+
+		w.Header().Set("ETag", etag(r, feed))
+
+		if !feed.Updated.IsZero() {
+			w.Header().Set("Last-Modified", feed.Updated.UTC().Format(http.TimeFormat))
+		}
+
+		if notModified(r, w.Header().Get("ETag"), feed.Updated) {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		var buf bytes.Buffer
+
+		err = e.encode(&buf, feed)
+		if err != nil {
+			h.logFor(r).Error("failed to encode feed", zap.Error(err))
+			h.writeApiError(w, http.StatusInternalServerError, handler.LevelSystem, "failed to encode feed")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", e.contentType)
+		w.WriteHeader(http.StatusOK)
+
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			h.logFor(r).Error("failed to write response", zap.Error(err))
+		}
+	}
+}
+
+func (h *Handler) logFor(r *http.Request) *zap.Logger {
+	if log, ok := logger.FromContext(r.Context()); ok {
+		return log
+	}
+
+	return logger.WithTrace(r.Context(), h.log)
+}
+
+// filter parses the query parameters into the filter of published posts, writing the error if they are invalid
+func (h *Handler) filter(w http.ResponseWriter, r *http.Request) (post.Filter, bool) {
+	f := post.Filter{Statuses: []post.Status{post.StatusPublished}, Limit: h.defaultLimit}
+
+	if limitStr := r.FormValue("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 32)
+		if err != nil {
+			h.writeApiError(w, http.StatusBadRequest, handler.LevelUser, "limit query parameter should be integer")
+
+			return f, false
+		}
+
+		if limit > 0 {
+			f.Limit = uint(limit)
+		}
+	}
+
+	if h.maxLimit > 0 && f.Limit > h.maxLimit {
+		f.Limit = h.maxLimit
+	}
+
+	for _, t := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		timeStr := r.FormValue(t.name)
+		if timeStr == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, timeStr)
+		if err != nil {
+			h.writeApiError(w, http.StatusBadRequest, handler.LevelUser, t.name+" query parameter should be RFC3339 formatted")
+
+			return f, false
+		}
+
+		*t.value = parsed
+	}
+
+	return f, true
+}
+
+func (h *Handler) writeApiError(w http.ResponseWriter, status int, level handler.Level, msg string) {
+	encoded, _ := jsoniter.ConfigFastest.Marshal(handler.NewApiError(msg, level))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err := w.Write(encoded)
+	if err != nil {
+		h.log.Error("failed to write response", zap.Error(err))
+	}
+}
+
+// etag is the weak entity tag of the feed. Besides the newest update time it covers the posts and the query,
+// so removed posts and different filters change the tag as well.
+func etag(r *http.Request, f Feed) string {
+	hash := sha256.New()
+
+	_, _ = io.WriteString(hash, r.URL.Path+"?"+r.URL.RawQuery+"\n"+f.Updated.UTC().Format(time.RFC3339Nano))
+	for _, item := range f.Items {
+		_, _ = io.WriteString(hash, "\n"+item.ID)
+	}
+
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the former is absent
+func notModified(r *http.Request, etag string, updated time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || updated.IsZero() {
+		return false
+	}
+
+	return !updated.Truncate(time.Second).After(since)
+}
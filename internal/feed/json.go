@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"io"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	jsonContentType = "application/feed+json; charset=utf-8"
+	jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+)
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url,omitempty"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentText   string       `json:"content_text"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// EncodeJSON writes the feed as JSON Feed 1.1
+func EncodeJSON(w io.Writer, f Feed) error {
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.URL,
+		Description: f.Description,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+
+	for _, item := range f.Items {
+		i := jsonItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentText:   item.Content,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Tags:          item.Tags,
+		}
+
+		if item.Author != "" {
+			i.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+
+		feed.Items = append(feed.Items, i)
+	}
+
+	return jsoniter.ConfigFastest.NewEncoder(w).Encode(feed)
+}
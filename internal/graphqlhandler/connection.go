@@ -0,0 +1,66 @@
+package graphqlhandler
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+const offsetCursorPrefix = "offset|"
+
+var errInvalidOffsetCursor = errors.New("invalid offset cursor")
+
+// connection is the page of the Relay-style post connection. Filter.Limit is the size of the page.
+type connection struct {
+	filter  post.Filter
+	posts   []post.Post
+	hasNext bool
+}
+
+type edge struct {
+	cursor string
+	node   post.Post
+}
+
+func (c connection) edges() []edge {
+	edges := make([]edge, len(c.posts))
+
+	for i, p := range c.posts {
+		edges[i] = edge{cursor: c.cursor(i), node: p}
+	}
+
+	return edges
+}
+
+// cursor of the i-th post of the page. Search results are ordered by relevance and can't be paginated
+// by post.Cursor, so their cursors are the offsets of the next pages.
+func (c connection) cursor(i int) string {
+	if c.filter.Query != "" {
+		return encodeOffsetCursor(c.filter.Offset + uint(i) + 1)
+	}
+
+	return post.NewCursor(c.posts[i]).Encode()
+}
+
+func encodeOffsetCursor(offset uint) string {
+	raw := offsetCursorPrefix + strconv.FormatUint(uint64(offset), 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOffsetCursor(encoded string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !strings.HasPrefix(string(raw), offsetCursorPrefix) {
+		return 0, errInvalidOffsetCursor
+	}
+
+	offset, err := strconv.ParseUint(strings.TrimPrefix(string(raw), offsetCursorPrefix), 10, 0)
+	if err != nil {
+		return 0, errInvalidOffsetCursor
+	}
+
+	return uint(offset), nil
+}
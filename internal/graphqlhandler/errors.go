@@ -0,0 +1,87 @@
+package graphqlhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/policy"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// Error codes put into the extensions of the GraphQL errors
+const (
+	CodeBadUserInput        = "BAD_USER_INPUT"
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeNotFound            = "NOT_FOUND"
+	CodeAlreadyExists       = "ALREADY_EXISTS"
+	CodeVersionMismatch     = "VERSION_MISMATCH"
+	CodeInvalidTransition   = "INVALID_TRANSITION"
+	CodeTimeout             = "TIMEOUT"
+	CodeQueryTooComplex     = "QUERY_TOO_COMPLEX"
+	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is the resolver error carrying the code reported in the extensions of the GraphQL error
+type Error struct {
+	Code    string
+	Message string
+}
+
+func newError(code, msg string) *Error {
+	return &Error{Code: code, Message: msg}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+// serviceError maps the service error to the code the same way the REST handler maps it to HTTP status
+func serviceError(err error) *Error {
+	return newError(errorCode(err), err.Error())
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, post.ErrNotFound), errors.Is(err, author.ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, post.ErrorAlreadyExists), errors.Is(err, author.ErrAlreadyExists):
+		return CodeAlreadyExists
+	case errors.Is(err, post.ErrVersionMismatch):
+		return CodeVersionMismatch
+	case errors.Is(err, post.ErrForbidden), errors.Is(err, author.ErrForbidden), errors.Is(err, policy.ErrDenied):
+		return CodeForbidden
+	case errors.Is(err, post.ErrInvalidTransition):
+		return CodeInvalidTransition
+	case errors.Is(err, post.ErrInvalidPublishAt):
+		return CodeBadUserInput
+	default:
+		return CodeInternalServerError
+	}
+}
+
+// validationError formats the validator errors the way the REST handler does
+func validationError(err error) *Error {
+	var vErr validator.ValidationErrors
+	if !errors.As(err, &vErr) {
+		return newError(CodeBadUserInput, err.Error())
+	}
+
+	msg := "validation error:"
+
+	for _, err := range vErr {
+		msg += fmt.Sprintf(" %s %s", strings.ToLower(err.StructField()), err.ActualTag())
+	}
+
+	return newError(CodeBadUserInput, msg)
+}
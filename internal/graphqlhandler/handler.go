@@ -0,0 +1,248 @@
+package graphqlhandler
+
+import (
+	_ "embed"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+//go:embed graphiql.html
+var graphiQLPage []byte
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewHandler(
+	log *zap.Logger,
+	postService post.Service,
+	authorService author.Service,
+	defaultLimit uint,
+	limits Limits,
+) *Handler {
+	r := &resolver{
+		log:           log,
+		postService:   postService,
+		authorService: authorService,
+		validator:     validator.New(),
+		defaultLimit:  defaultLimit,
+		maxPage:       limits.MaxPage,
+	}
+
+	schema, err := newSchema(r)
+	if err != nil {
+		// the schema is static, so it's either always valid or never
+		panic(err)
+	}
+
+	return &Handler{log: log, schema: schema, resolver: r, limits: limits}
+}
+
+// Handler serves GraphQL queries and mutations of the posts at /graphql, both GET and POST requests
+// are accepted, mutations are allowed over POST only. Queries exceeding the Limits are rejected
+// before they are executed.
+type Handler struct {
+	log      *zap.Logger
+	schema   graphql.Schema
+	resolver *resolver
+	limits   Limits
+	graphiQL bool
+}
+
+// RequireAuth makes mutations require auth.ScopeWrite. The /graphql route itself should be made public
+// in the auth middleware, so that queries don't need credentials.
+func (h *Handler) RequireAuth() *Handler {
+	h.resolver.requireAuth = true
+
+	return h
+}
+
+// ServeGraphiQL serves the GraphiQL IDE at /graphiql
+func (h *Handler) ServeGraphiQL() *Handler {
+	h.graphiQL = true
+
+	return h
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/graphql", h.serve).Name("graphql").Methods("GET", "POST")
+
+	if h.graphiQL {
+		r.HandleFunc("/graphiql", h.serveGraphiQL).Name("graphiql").Methods("GET")
+	}
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		h.logFor(r).Info("failed to parse query", zap.Error(err))
+		h.writeResult(w, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		h.logFor(r).Info("invalid query", zap.Any("errors", validation.Errors))
+		h.writeResult(w, &graphql.Result{Errors: validation.Errors})
+
+		return
+	}
+
+	operation, _ := splitDocument(doc, req.OperationName)
+	if r.Method == http.MethodGet && operation != nil && operation.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", http.MethodPost)
+		h.writeApiError(w, http.StatusMethodNotAllowed, "mutations are allowed over POST only")
+
+		return
+	}
+
+	limitErr := h.limits.check(doc, req.OperationName, req.Variables, h.resolver.defaultLimit)
+	if limitErr != nil {
+		h.logFor(r).Info("query rejected", zap.Error(limitErr))
+		h.writeResult(w, &graphql.Result{Errors: []gqlerrors.FormattedError{formatError(limitErr)}})
+
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withAuthors(r.Context()),
+	})
+
+	h.writeResult(w, result)
+}
+
+// parseRequest reads the query from the JSON or application/graphql body of POST requests
+// and from the query string of GET requests
+func (h *Handler) parseRequest(w http.ResponseWriter, r *http.Request) (request, bool) {
+	var req request
+
+	if r.Method == http.MethodGet {
+		req.Query = r.FormValue("query")
+		req.OperationName = r.FormValue("operationName")
+
+		if variables := r.FormValue("variables"); variables != "" {
+			err := jsoniter.ConfigFastest.UnmarshalFromString(variables, &req.Variables)
+			if err != nil {
+				h.logFor(r).Info("failed to unmarshal variables", zap.Error(err))
+				h.writeApiError(w, http.StatusBadRequest, "variables query parameter should be JSON object")
+
+				return request{}, false
+			}
+		}
+	} else {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+		switch mediaType {
+		case "application/graphql":
+			query, err := io.ReadAll(r.Body)
+			if err != nil {
+				h.logFor(r).Info("failed to read request", zap.Error(err))
+				h.writeApiError(w, http.StatusBadRequest, "failed to read request")
+
+				return request{}, false
+			}
+
+			req.Query = string(query)
+		default:
+			err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&req)
+			if err != nil {
+				h.logFor(r).Info("failed to unmarshal request", zap.Error(err))
+				h.writeApiError(w, http.StatusBadRequest, "failed to unmarshal json")
+
+				return request{}, false
+			}
+		}
+	}
+
+	if req.Query == "" {
+		h.writeApiError(w, http.StatusBadRequest, "query is required")
+
+		return request{}, false
+	}
+
+	return req, true
+}
+
+func (h *Handler) serveGraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(graphiQLPage)
+	if err != nil {
+		h.log.Error("failed to write response", zap.Error(err))
+	}
+}
+
+// writeResult writes the result with 200 OK even if it has errors, as GraphQL over HTTP does
+// for the application/json responses
+func (h *Handler) writeResult(w http.ResponseWriter, result *graphql.Result) {
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) writeApiError(w http.ResponseWriter, status int, msg string) {
+	h.writeJSON(w, status, handler.NewApiError(msg, handler.LevelUser))
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	encoded, err := jsoniter.ConfigFastest.Marshal(data)
+	if err != nil {
+		h.log.Error("failed to marshal response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(encoded)
+	if err != nil {
+		h.log.Error("failed to write response", zap.Error(err))
+	}
+}
+
+// logFor returns the request scoped logger put into the context by the middlewares.HandlerLogger.
+// The handler logger annotated with the trace is used if there is none.
+func (h *Handler) logFor(r *http.Request) *zap.Logger {
+	if log, ok := logger.FromContext(r.Context()); ok {
+		return log
+	}
+
+	return logger.WithTrace(r.Context(), h.log)
+}
+
+// formatError formats the error raised outside of the execution, so it has no locations
+func formatError(err *Error) gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    err.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: err.Extensions(),
+	}
+}
@@ -0,0 +1,221 @@
+package graphqlhandler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// connectionFields are multiplied by the page size when the complexity is computed
+var connectionFields = map[string]bool{"posts": true}
+
+// Limits reject the queries before they are executed. Zero value disables the limit.
+type Limits struct {
+	// MaxDepth limits the nesting of the fields, top level fields are at depth 1
+	MaxDepth int
+	// MaxComplexity limits the number of the fields resolved. Fields selected inside a post connection
+	// count once per post of the page, which is DEFAULT_NEWS_LIMIT unless set by the first argument.
+	MaxComplexity int
+	// MaxPage limits the page size of the connections, larger first argument is clamped to it
+	MaxPage int
+}
+
+// check returns the error describing the exceeded limit. Introspection fields aren't limited.
+func (l Limits) check(
+	doc *ast.Document,
+	operationName string,
+	variables map[string]interface{},
+	defaultLimit uint,
+) *Error {
+	operation, fragments := splitDocument(doc, operationName)
+	if operation == nil {
+		return nil
+	}
+
+	m := measurer{
+		fragments:    fragments,
+		variables:    withDefaults(operation, variables),
+		defaultLimit: int(defaultLimit),
+		maxPage:      l.MaxPage,
+	}
+	depth, complexity := m.measure(operation.SelectionSet, 1)
+
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return newError(CodeQueryTooComplex, fmt.Sprintf("query depth %d exceeds the limit of %d", depth, l.MaxDepth))
+	}
+
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return newError(
+			CodeQueryTooComplex,
+			fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity),
+		)
+	}
+
+	return nil
+}
+
+// splitDocument returns the operation to execute and the fragments by name. Operation is nil
+// if it can't be chosen, which is reported by the execution.
+func splitDocument(
+	doc *ast.Document,
+	operationName string,
+) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition) {
+	var (
+		operation  *ast.OperationDefinition
+		operations int
+		fragments  = make(map[string]*ast.FragmentDefinition)
+	)
+
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			operations++
+
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+
+	if operationName == "" && operations > 1 {
+		return nil, fragments
+	}
+
+	return operation, fragments
+}
+
+// withDefaults adds the default values of the variables missing in the request, the way the execution does.
+// Only the integer defaults are added, the others don't affect the limits.
+func withDefaults(operation *ast.OperationDefinition, variables map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(variables))
+
+	for name, value := range variables {
+		values[name] = value
+	}
+
+	for _, def := range operation.VariableDefinitions {
+		name := def.Variable.Name.Value
+		if _, ok := values[name]; ok {
+			continue
+		}
+
+		if value, ok := def.DefaultValue.(*ast.IntValue); ok {
+			if n, err := strconv.ParseFloat(value.Value, 64); err == nil {
+				values[name] = n
+			}
+		}
+	}
+
+	return values
+}
+
+type measurer struct {
+	fragments    map[string]*ast.FragmentDefinition
+	variables    map[string]interface{}
+	defaultLimit int
+	maxPage      int
+}
+
+// measure returns the depth of the deepest field and the complexity of the selection set at the depth.
+// The document is validated before, so fragments exist and don't form cycles.
+func (m measurer) measure(set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	var maxDepth, complexity int
+
+	for _, selection := range set.Selections {
+		var d, c int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+
+			d, c = m.measure(selection.SelectionSet, depth+1)
+			c = saturatedAdd(1, saturatedMul(m.multiplier(selection), c))
+
+			if d < depth {
+				d = depth
+			}
+		case *ast.InlineFragment:
+			d, c = m.measure(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[selection.Name.Value]; ok {
+				d, c = m.measure(fragment.SelectionSet, depth)
+			}
+		}
+
+		if d > maxDepth {
+			maxDepth = d
+		}
+
+		complexity = saturatedAdd(complexity, c)
+	}
+
+	return maxDepth, complexity
+}
+
+// multiplier is the page size of the connection field and 1 for the others
+func (m measurer) multiplier(field *ast.Field) int {
+	if !connectionFields[field.Name.Value] {
+		return 1
+	}
+
+	first := m.first(field)
+	if first <= 0 {
+		return m.defaultLimit
+	}
+
+	if m.maxPage > 0 && first > m.maxPage {
+		return m.maxPage
+	}
+
+	return first
+}
+
+// first returns the first argument of the field within the int32 range, zero if it's missing
+func (m measurer) first(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+
+		var first float64
+
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			first, _ = strconv.ParseFloat(value.Value, 64)
+		case *ast.Variable:
+			first, _ = m.variables[value.Name.Value].(float64)
+		}
+
+		return int(math.Max(math.Min(first, math.MaxInt32), math.MinInt32))
+	}
+
+	return 0
+}
+
+// saturatedAdd and saturatedMul stop at math.MaxInt32, so that huge pages don't overflow the complexity
+func saturatedAdd(a, b int) int {
+	if a > math.MaxInt32-b {
+		return math.MaxInt32
+	}
+
+	return a + b
+}
+
+func saturatedMul(a, b int) int {
+	if a != 0 && b > math.MaxInt32/a {
+		return math.MaxInt32
+	}
+
+	return a * b
+}
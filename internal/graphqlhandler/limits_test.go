@@ -0,0 +1,78 @@
+package graphqlhandler
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		variables  map[string]interface{}
+		depth      int
+		complexity int
+	}{
+		{"field", `{ post(id: "1") { id title } }`, nil, 2, 3},
+		{"default_page", `{ posts { edges { node { id } } } }`, nil, 4, 31},
+		{"first", `{ posts(first: 2) { totalCount edges { node { id } } } }`, nil, 4, 9},
+		{"variable", `query($n: Int) { posts(first: $n) { edges { cursor } } }`, map[string]interface{}{"n": 5.0}, 3, 11},
+		{"variable_default", `query($n: Int = 5) { posts(first: $n) { edges { cursor } } }`, nil, 3, 11},
+		{
+			"variable_overrides_default",
+			`query($n: Int = 5) { posts(first: $n) { edges { cursor } } }`,
+			map[string]interface{}{"n": 2.0}, 3, 5,
+		},
+		{"max_page", `{ posts(first: 100000) { edges { cursor } } }`, nil, 3, 41},
+		{"variable_default_max_page", `query($n: Int = 100000) { posts(first: $n) { edges { cursor } } }`, nil, 3, 41},
+		{
+			"nested_pages",
+			`{ posts(first: 2) { edges { node { author { posts(first: 3) { totalCount } } } } } }`,
+			nil, 6, 15,
+		},
+		{
+			"fragments",
+			`{ post(id: "1") { ...fields ... on Post { title } } } fragment fields on Post { id author { name } }`,
+			nil, 3, 5,
+		},
+		{"introspection", `{ __schema { types { name } } post(id: "1") { __typename id } }`, nil, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			require.NoError(t, err)
+
+			operation, fragments := splitDocument(doc, "")
+			require.NotNil(t, operation)
+
+			m := measurer{
+				fragments:    fragments,
+				variables:    withDefaults(operation, tt.variables),
+				defaultLimit: 10,
+				maxPage:      20,
+			}
+			depth, complexity := m.measure(operation.SelectionSet, 1)
+
+			assert.Equal(t, tt.depth, depth, "depth")
+			assert.Equal(t, tt.complexity, complexity, "complexity")
+
+			limits := Limits{MaxDepth: tt.depth, MaxComplexity: tt.complexity, MaxPage: 20}
+			assert.Nil(t, limits.check(doc, "", tt.variables, 10))
+			assert.NotNil(t, Limits{MaxDepth: tt.depth - 1, MaxPage: 20}.check(doc, "", tt.variables, 10))
+			assert.NotNil(t, Limits{MaxComplexity: tt.complexity - 1, MaxPage: 20}.check(doc, "", tt.variables, 10))
+		})
+	}
+}
+
+func TestLimitsSaturate(t *testing.T) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: `{ posts(first: 2000000000) { edges { node { author { posts(first: 2000000000) { totalCount } } } } } }`,
+	})
+	require.NoError(t, err)
+
+	assert.NotNil(t, Limits{MaxComplexity: 1000}.check(doc, "", nil, 10))
+}
@@ -0,0 +1,495 @@
+package graphqlhandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+type postInput struct {
+	Title    string   `validate:"required"`
+	Content  string   `validate:"required"`
+	Tags     []string `validate:"max=20,dive,required,max=50"`
+	Category string   `validate:"max=50"`
+}
+
+type createPostInput struct {
+	postInput
+	Status    post.Status `validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time
+}
+
+type resolver struct {
+	log           *zap.Logger
+	postService   post.Service
+	authorService author.Service
+	validator     *validator.Validate
+	defaultLimit  uint
+	maxPage       int
+	requireAuth   bool
+}
+
+func (r *resolver) post(p graphql.ResolveParams) (interface{}, error) {
+	found, err := r.postService.GetPost(p.Context, stringArg(p.Args, "id"))
+	if errors.Is(err, post.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		r.logFor(p.Context).Error("failed to get post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return found, nil
+}
+
+func (r *resolver) posts(p graphql.ResolveParams) (interface{}, error) {
+	return r.findPosts(p.Context, p.Args, stringArg(p.Args, "authorId"))
+}
+
+func (r *resolver) author(p graphql.ResolveParams) (interface{}, error) {
+	a, err := authorsFromContext(p.Context).get(p.Context, r.authorService, stringArg(p.Args, "id"))
+	if errors.Is(err, author.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		r.logFor(p.Context).Error("failed to get author", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return a, nil
+}
+
+func (r *resolver) postAuthor(p graphql.ResolveParams) (interface{}, error) {
+	authorID := p.Source.(post.Post).AuthorID
+	if authorID == "" {
+		return nil, nil
+	}
+
+	return r.author(graphql.ResolveParams{Context: p.Context, Args: map[string]interface{}{"id": authorID}})
+}
+
+func (r *resolver) authorPosts(p graphql.ResolveParams) (interface{}, error) {
+	return r.findPosts(p.Context, p.Args, p.Source.(author.Author).ID)
+}
+
+func (r *resolver) totalCount(p graphql.ResolveParams) (interface{}, error) {
+	count, err := r.postService.CountPosts(p.Context, p.Source.(connection).filter)
+	if err != nil {
+		r.logFor(p.Context).Error("failed to count posts", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return count, nil
+}
+
+// findPosts fetches one post more than requested to tell whether there is the next page
+func (r *resolver) findPosts(ctx context.Context, args map[string]interface{}, authorID string) (connection, error) {
+	f, err := r.filter(args)
+	if err != nil {
+		return connection{}, err
+	}
+
+	f.AuthorID = authorID
+
+	page := f.Limit
+	f.Limit++
+
+	posts, err := r.postService.FindPosts(ctx, f)
+	if err != nil {
+		r.logFor(ctx).Error("failed to find posts", zap.Error(err))
+
+		return connection{}, serviceError(err)
+	}
+
+	f.Limit = page
+	c := connection{filter: f, posts: posts}
+
+	if uint(len(posts)) > page {
+		c.posts = posts[:page]
+		c.hasNext = true
+	}
+
+	return c, nil
+}
+
+// filter converts the connection arguments into the filter the way REST query parameters are converted
+func (r *resolver) filter(args map[string]interface{}) (post.Filter, error) {
+	f := post.Filter{
+		From:     timeArg(args, "from"),
+		To:       timeArg(args, "to"),
+		Query:    stringArg(args, "query"),
+		Tags:     stringsArg(args, "tags"),
+		Category: stringArg(args, "category"),
+		Limit:    r.defaultLimit,
+	}
+
+	f.AllTags, _ = args["allTags"].(bool)
+
+	if first, ok := args["first"].(int); ok {
+		if first < 1 {
+			return post.Filter{}, newError(CodeBadUserInput, "first should be positive")
+		}
+
+		if r.maxPage > 0 && first > r.maxPage {
+			first = r.maxPage
+		}
+
+		f.Limit = uint(first)
+	}
+
+	if statuses, ok := args["statuses"].([]interface{}); ok {
+		for _, st := range statuses {
+			f.Statuses = append(f.Statuses, st.(post.Status))
+		}
+	}
+
+	// readers see only published posts unless asked otherwise
+	if len(f.Statuses) == 0 {
+		f.Statuses = []post.Status{post.StatusPublished}
+	}
+
+	if after := stringArg(args, "after"); after != "" {
+		if f.Query != "" {
+			offset, err := decodeOffsetCursor(after)
+			if err != nil {
+				return post.Filter{}, newError(CodeBadUserInput, "after cursor is invalid")
+			}
+
+			f.Offset = offset
+		} else {
+			cursor, err := post.DecodeCursor(after)
+			if err != nil {
+				return post.Filter{}, newError(CodeBadUserInput, "after cursor is invalid")
+			}
+
+			f.After = &cursor
+		}
+	}
+
+	return f, nil
+}
+
+func (r *resolver) createPost(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	args, _ := p.Args["input"].(map[string]interface{})
+
+	input := createPostInput{postInput: newPostInput(args)}
+	input.Status, _ = args["status"].(post.Status)
+
+	if publishAt := timeArg(args, "publishAt"); !publishAt.IsZero() {
+		input.PublishAt = &publishAt
+	}
+
+	err = r.validator.StructCtx(p.Context, input)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	created, err := r.postService.CreatePost(p.Context, post.Post{
+		Title:     input.Title,
+		Content:   input.Content,
+		Tags:      input.Tags,
+		Category:  input.Category,
+		Status:    input.Status,
+		PublishAt: input.PublishAt,
+	})
+	if err != nil {
+		r.logFor(p.Context).Error("failed to create post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return created, nil
+}
+
+func (r *resolver) upsertPost(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	args, _ := p.Args["input"].(map[string]interface{})
+	input := newPostInput(args)
+
+	err = r.validator.StructCtx(p.Context, input)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	id := stringArg(p.Args, "id")
+
+	err = r.postService.UpsertPost(p.Context, post.Post{
+		ID:       id,
+		Title:    input.Title,
+		Content:  input.Content,
+		Tags:     input.Tags,
+		Category: input.Category,
+		Version:  versionArg(p.Args),
+	})
+	if err != nil {
+		r.logFor(p.Context).Error("failed to upsert post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return r.getPost(p.Context, id)
+}
+
+func (r *resolver) patchPost(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	args, _ := p.Args["input"].(map[string]interface{})
+
+	var (
+		patch  post.Patch
+		fields []string
+	)
+
+	if _, ok := args["title"]; ok {
+		title := stringArg(args, "title")
+		patch.Title = &title
+		fields = append(fields, "Title")
+	}
+
+	if _, ok := args["content"]; ok {
+		content := stringArg(args, "content")
+		patch.Content = &content
+		fields = append(fields, "Content")
+	}
+
+	if _, ok := args["tags"]; ok {
+		tags := stringsArg(args, "tags")
+		patch.Tags = &tags
+		fields = append(fields, "Tags")
+	}
+
+	if _, ok := args["category"]; ok {
+		category := stringArg(args, "category")
+		patch.Category = &category
+		fields = append(fields, "Category")
+	}
+
+	if patch.IsEmpty() {
+		return nil, newError(CodeBadUserInput, "patch should change at least one field")
+	}
+
+	// only the patched fields are validated
+	err = r.validator.StructPartialCtx(p.Context, newPostInput(args), fields...)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	patched, err := r.postService.PatchPost(p.Context, stringArg(p.Args, "id"), patch, versionArg(p.Args))
+	if err != nil {
+		r.logFor(p.Context).Error("failed to patch post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return patched, nil
+}
+
+func (r *resolver) deletePost(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.postService.DeletePost(p.Context, stringArg(p.Args, "id"), versionArg(p.Args))
+	if err != nil {
+		r.logFor(p.Context).Error("failed to delete post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return true, nil
+}
+
+func (r *resolver) restorePost(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := r.postService.RestorePost(p.Context, stringArg(p.Args, "id"))
+	if err != nil {
+		r.logFor(p.Context).Error("failed to restore post", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return restored, nil
+}
+
+func (r *resolver) setPostStatus(p graphql.ResolveParams) (interface{}, error) {
+	err := r.authorizeWrite(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	var publishAt *time.Time
+
+	if t := timeArg(p.Args, "publishAt"); !t.IsZero() {
+		publishAt = &t
+	}
+
+	status, _ := p.Args["status"].(post.Status)
+
+	updated, err := r.postService.SetPostStatus(
+		p.Context,
+		stringArg(p.Args, "id"),
+		status,
+		publishAt,
+		versionArg(p.Args),
+	)
+	if err != nil {
+		r.logFor(p.Context).Error("failed to set post status", zap.Error(err))
+
+		return nil, serviceError(err)
+	}
+
+	return updated, nil
+}
+
+func (r *resolver) getPost(ctx context.Context, id string) (interface{}, error) {
+	p, err := r.postService.GetPost(ctx, id)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return p, nil
+}
+
+// authorizeWrite requires auth.ScopeWrite for the mutations the way the auth middleware requires it
+// for the REST writes. The /graphql route itself is public, so that anyone can query.
+func (r *resolver) authorizeWrite(ctx context.Context) error {
+	if !r.requireAuth {
+		return nil
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return newError(CodeUnauthenticated, "authentication required")
+	}
+
+	if !principal.HasScope(auth.ScopeWrite) {
+		return newError(CodeForbidden, "scope "+auth.ScopeWrite+" is required")
+	}
+
+	return nil
+}
+
+// logFor returns the request scoped logger put into the context by the middlewares.HandlerLogger.
+// The resolver logger annotated with the trace is used if there is none.
+func (r *resolver) logFor(ctx context.Context) *zap.Logger {
+	if log, ok := logger.FromContext(ctx); ok {
+		return log
+	}
+
+	return logger.WithTrace(ctx, r.log)
+}
+
+// authors caches the authors of the posts for the duration of the request, so that the author
+// of many posts is fetched once
+type authors struct {
+	mu   sync.Mutex
+	byID map[string]author.Author
+}
+
+type authorsKey struct{}
+
+func withAuthors(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authorsKey{}, &authors{byID: make(map[string]author.Author)})
+}
+
+// authorsFromContext returns the request cache or the empty one if there is none
+func authorsFromContext(ctx context.Context) *authors {
+	if a, ok := ctx.Value(authorsKey{}).(*authors); ok {
+		return a
+	}
+
+	return &authors{byID: make(map[string]author.Author)}
+}
+
+func (a *authors) get(ctx context.Context, service author.Service, id string) (author.Author, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if found, ok := a.byID[id]; ok {
+		return found, nil
+	}
+
+	found, err := service.GetAuthor(ctx, id)
+	if err != nil {
+		return author.Author{}, err
+	}
+
+	a.byID[id] = found
+
+	return found, nil
+}
+
+func newPostInput(args map[string]interface{}) postInput {
+	return postInput{
+		Title:    stringArg(args, "title"),
+		Content:  stringArg(args, "content"),
+		Tags:     stringsArg(args, "tags"),
+		Category: stringArg(args, "category"),
+	}
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+
+	return s
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	values, ok := args[name].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	strs := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs
+}
+
+func timeArg(args map[string]interface{}, name string) time.Time {
+	t, _ := args[name].(time.Time)
+
+	return t
+}
+
+func versionArg(args map[string]interface{}) int64 {
+	version, _ := args["version"].(int)
+
+	return int64(version)
+}
@@ -0,0 +1,30 @@
+package graphqlhandler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterClampsFirst(t *testing.T) {
+	r := &resolver{defaultLimit: 10, maxPage: 20}
+
+	tests := []struct {
+		name  string
+		args  map[string]interface{}
+		limit uint
+	}{
+		{"default", map[string]interface{}{}, 10},
+		{"first", map[string]interface{}{"first": 5}, 5},
+		{"max_page", map[string]interface{}{"first": 100000}, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := r.filter(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.limit, f.Limit)
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package graphqlhandler
+
+import (
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+var postStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "PostStatus",
+	Values: graphql.EnumValueConfigMap{
+		"DRAFT":     {Value: post.StatusDraft},
+		"SCHEDULED": {Value: post.StatusScheduled},
+		"PUBLISHED": {Value: post.StatusPublished},
+		"ARCHIVED":  {Value: post.StatusArchived},
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": connectionField(graphql.NewNonNull(graphql.Boolean), func(c connection) interface{} {
+			return c.hasNext
+		}),
+		"hasPreviousPage": connectionField(graphql.NewNonNull(graphql.Boolean), func(c connection) interface{} {
+			return c.filter.After != nil || c.filter.Offset > 0
+		}),
+		"startCursor": connectionField(graphql.String, func(c connection) interface{} {
+			if len(c.posts) == 0 {
+				return nil
+			}
+
+			return c.cursor(0)
+		}),
+		"endCursor": connectionField(graphql.String, func(c connection) interface{} {
+			if len(c.posts) == 0 {
+				return nil
+			}
+
+			return c.cursor(len(c.posts) - 1)
+		}),
+	},
+})
+
+var postInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PostInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":    {Type: graphql.NewNonNull(graphql.String)},
+		"content":  {Type: graphql.NewNonNull(graphql.String)},
+		"tags":     {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"category": {Type: graphql.String},
+	},
+})
+
+var createPostInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreatePostInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":     {Type: graphql.NewNonNull(graphql.String)},
+		"content":   {Type: graphql.NewNonNull(graphql.String)},
+		"tags":      {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"category":  {Type: graphql.String},
+		"status":    {Type: postStatusEnum, Description: "DRAFT unless set"},
+		"publishAt": {Type: graphql.DateTime},
+	},
+})
+
+var postPatchInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "PostPatchInput",
+	Description: "Fields left out are not changed",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":    {Type: graphql.String},
+		"content":  {Type: graphql.String},
+		"tags":     {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"category": {Type: graphql.String},
+	},
+})
+
+// newSchema builds the schema resolved by r. Post and Author refer to each other, so their fields are thunks.
+func newSchema(r *resolver) (graphql.Schema, error) {
+	var (
+		postType           *graphql.Object
+		authorType         *graphql.Object
+		postConnectionType *graphql.Object
+	)
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":      postField(graphql.NewNonNull(graphql.ID), func(p post.Post) interface{} { return p.ID }),
+				"title":   postField(graphql.NewNonNull(graphql.String), func(p post.Post) interface{} { return p.Title }),
+				"content": postField(graphql.NewNonNull(graphql.String), func(p post.Post) interface{} { return p.Content }),
+				"tags": postField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					func(p post.Post) interface{} {
+						if p.Tags == nil {
+							return []string{}
+						}
+
+						return p.Tags
+					}),
+				"category": postField(graphql.String, func(p post.Post) interface{} { return p.Category }),
+				"authorId": postField(graphql.ID, func(p post.Post) interface{} { return p.AuthorID }),
+				"author": &graphql.Field{
+					Type:        authorType,
+					Description: "null for anonymous posts and deleted authors",
+					Resolve:     r.postAuthor,
+				},
+				"status":    postField(graphql.NewNonNull(postStatusEnum), func(p post.Post) interface{} { return p.Status }),
+				"publishAt": postField(graphql.DateTime, func(p post.Post) interface{} { return timeValue(p.PublishAt) }),
+				"createdAt": postField(graphql.NewNonNull(graphql.DateTime), func(p post.Post) interface{} { return p.CreatedAt }),
+				"updatedAt": postField(graphql.NewNonNull(graphql.DateTime), func(p post.Post) interface{} { return p.UpdatedAt }),
+				"version":   postField(graphql.NewNonNull(graphql.Int), func(p post.Post) interface{} { return p.Version }),
+				"snippet": &graphql.Field{
+					Type:        graphql.String,
+					Description: "set for the posts found by the query",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(post.Post).Snippet, nil
+					},
+				},
+			}
+		}),
+	})
+
+	postEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(edge).cursor, nil
+				},
+			},
+			"node": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(edge).node, nil
+				},
+			},
+		},
+	})
+
+	postConnectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "PostConnection",
+		Fields: graphql.Fields{
+			"edges": connectionField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postEdgeType))),
+				func(c connection) interface{} {
+					return c.edges()
+				}),
+			"pageInfo": connectionField(graphql.NewNonNull(pageInfoType), func(c connection) interface{} {
+				return c
+			}),
+			"totalCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "number of the posts on all the pages",
+				Resolve:     r.totalCount,
+			},
+		},
+	})
+
+	authorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":   authorField(graphql.NewNonNull(graphql.ID), func(a author.Author) interface{} { return a.ID }),
+				"name": authorField(graphql.NewNonNull(graphql.String), func(a author.Author) interface{} { return a.Name }),
+				// emails are hidden from everyone but the author and editors by the author.Service
+				"email": authorField(graphql.String, func(a author.Author) interface{} {
+					if a.Email == "" {
+						return nil
+					}
+
+					return a.Email
+				}),
+				"bio": authorField(graphql.String, func(a author.Author) interface{} { return a.Bio }),
+				"createdAt": authorField(graphql.NewNonNull(graphql.DateTime), func(a author.Author) interface{} {
+					return a.CreatedAt
+				}),
+				"updatedAt": authorField(graphql.NewNonNull(graphql.DateTime), func(a author.Author) interface{} {
+					return a.UpdatedAt
+				}),
+				"posts": &graphql.Field{
+					Type:        graphql.NewNonNull(postConnectionType),
+					Description: "posts of the author",
+					Args:        postsArgs(false),
+					Resolve:     r.authorPosts,
+				},
+			}
+		}),
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "null if there is no such post",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     r.post,
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(postConnectionType),
+				Description: "posts ordered by creation time, newest first, or by relevance if the query is set",
+				Args:        postsArgs(true),
+				Resolve:     r.posts,
+			},
+			"author": &graphql.Field{
+				Type:        authorType,
+				Description: "null if there is no such author",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     r.author,
+			},
+		},
+	})
+
+	version := &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "makes the mutation conditional on the current version of the post",
+	}
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type:    graphql.NewNonNull(postType),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(createPostInputType)}},
+				Resolve: r.createPost,
+			},
+			"upsertPost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "creates the post with the id or replaces it",
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"input":   {Type: graphql.NewNonNull(postInputType)},
+					"version": version,
+				},
+				Resolve: r.upsertPost,
+			},
+			"patchPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"input":   {Type: graphql.NewNonNull(postPatchInputType)},
+					"version": version,
+				},
+				Resolve: r.patchPost,
+			},
+			"deletePost": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "moves the post to the trash",
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"version": version,
+				},
+				Resolve: r.deletePost,
+			},
+			"restorePost": &graphql.Field{
+				Type:        graphql.NewNonNull(postType),
+				Description: "brings the post back from the trash",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     r.restorePost,
+			},
+			"setPostStatus": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":        {Type: graphql.NewNonNull(graphql.ID)},
+					"status":    {Type: graphql.NewNonNull(postStatusEnum)},
+					"publishAt": {Type: graphql.DateTime},
+					"version":   version,
+				},
+				Resolve: r.setPostStatus,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// postsArgs are the arguments of the post connections, author posts are filtered by the author already
+func postsArgs(withAuthor bool) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"first": {Type: graphql.Int, Description: "page size, DEFAULT_NEWS_LIMIT unless set"},
+		"after": {Type: graphql.String, Description: "endCursor of the previous page"},
+		"query": {Type: graphql.String, Description: "full text search over title and content"},
+		"statuses": {
+			Type:        graphql.NewList(graphql.NewNonNull(postStatusEnum)),
+			Description: "PUBLISHED unless set",
+		},
+		"tags":     {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"allTags":  {Type: graphql.Boolean, Description: "match posts having all the tags instead of any"},
+		"category": {Type: graphql.String},
+		"from":     {Type: graphql.DateTime},
+		"to":       {Type: graphql.DateTime},
+	}
+
+	if withAuthor {
+		args["authorId"] = &graphql.ArgumentConfig{Type: graphql.ID}
+	}
+
+	return args
+}
+
+func postField(typ graphql.Output, value func(p post.Post) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return value(p.Source.(post.Post)), nil
+		},
+	}
+}
+
+func authorField(typ graphql.Output, value func(a author.Author) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return value(p.Source.(author.Author)), nil
+		},
+	}
+}
+
+func connectionField(typ graphql.Output, value func(c connection) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return value(p.Source.(connection)), nil
+		},
+	}
+}
+
+// timeValue returns nil for nil t, so that it's resolved as null
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return *t
+}
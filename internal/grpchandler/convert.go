@@ -0,0 +1,67 @@
+package grpchandler
+
+import (
+	"time"
+
+	newsv1 "github.com/sladonia/news-svc/api/news/v1"
+	"github.com/sladonia/news-svc/internal/post"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statusToProto = map[post.Status]newsv1.PostStatus{
+	post.StatusDraft:     newsv1.PostStatus_POST_STATUS_DRAFT,
+	post.StatusScheduled: newsv1.PostStatus_POST_STATUS_SCHEDULED,
+	post.StatusPublished: newsv1.PostStatus_POST_STATUS_PUBLISHED,
+	post.StatusArchived:  newsv1.PostStatus_POST_STATUS_ARCHIVED,
+}
+
+var statusFromProto = map[newsv1.PostStatus]post.Status{
+	newsv1.PostStatus_POST_STATUS_UNSPECIFIED: "",
+	newsv1.PostStatus_POST_STATUS_DRAFT:       post.StatusDraft,
+	newsv1.PostStatus_POST_STATUS_SCHEDULED:   post.StatusScheduled,
+	newsv1.PostStatus_POST_STATUS_PUBLISHED:   post.StatusPublished,
+	newsv1.PostStatus_POST_STATUS_ARCHIVED:    post.StatusArchived,
+}
+
+func postToProto(p post.Post) *newsv1.Post {
+	return &newsv1.Post{
+		Id:        p.ID,
+		Title:     p.Title,
+		Content:   p.Content,
+		AuthorId:  p.AuthorID,
+		Tags:      p.Tags,
+		Category:  p.Category,
+		CreatedAt: timestamppb.New(p.CreatedAt),
+		UpdatedAt: timestamppb.New(p.UpdatedAt),
+		Version:   p.Version,
+		Status:    statusToProto[p.Status],
+		PublishAt: timeToProto(p.PublishAt),
+	}
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
+
+// timeValue converts the optional timestamp, zero time is returned if it is absent
+func timeValue(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return t.AsTime()
+}
+
+func timeFromProto(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	converted := t.AsTime()
+
+	return &converted
+}
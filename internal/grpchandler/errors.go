@@ -0,0 +1,37 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sladonia/news-svc/internal/policy"
+	"github.com/sladonia/news-svc/internal/post"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError maps the service error to the gRPC status the same way the REST handler maps it to HTTP status
+func statusError(err error) error {
+	return status.Error(errorCode(err), err.Error())
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, post.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, post.ErrorAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, post.ErrVersionMismatch), errors.Is(err, post.ErrInvalidTransition):
+		return codes.FailedPrecondition
+	case errors.Is(err, post.ErrForbidden), errors.Is(err, policy.ErrDenied):
+		return codes.PermissionDenied
+	case errors.Is(err, post.ErrInvalidPublishAt):
+		return codes.InvalidArgument
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,191 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyMetadata        = "x-api-key"
+	authorizationMetadata = "authorization"
+	requestIDMetadata     = "x-request-id"
+)
+
+// writeMethods modify posts and require auth.ScopeWrite, the others are public like the REST reads
+var writeMethods = map[string]bool{
+	"/news.v1.NewsService/CreatePost": true,
+	"/news.v1.NewsService/UpsertPost": true,
+	"/news.v1.NewsService/DeletePost": true,
+}
+
+// ServerOptions chain the interceptors logging, recovering the panics and authenticating the calls
+// with the authenticator. Nil authenticator disables authentication.
+func ServerOptions(log *zap.Logger, authenticator *auth.Authenticator) []grpc.ServerOption {
+	i := &interceptors{log: log, authenticator: authenticator}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	}
+}
+
+type interceptors struct {
+	log           *zap.Logger
+	authenticator *auth.Authenticator
+}
+
+func (i *interceptors) unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	ctx, done := i.start(ctx, info.FullMethod)
+	defer func() { done(err) }()
+	defer i.recover(ctx, info.FullMethod, &err)
+
+	ctx, err = i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (i *interceptors) stream(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	ctx, done := i.start(ss.Context(), info.FullMethod)
+	defer func() { done(err) }()
+	defer i.recover(ctx, info.FullMethod, &err)
+
+	ctx, err = i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// start puts the call scoped logger into the context. Returned function logs the handled call.
+func (i *interceptors) start(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	requestID := xid.New().String()
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if id := first(md, requestIDMetadata); logger.IsValidRequestID(id) {
+			requestID = id
+		}
+	}
+
+	log := logger.WithTrace(ctx, i.log).With(zap.String("request_id", requestID))
+	ctx = logger.WithContext(ctx, log)
+
+	return ctx, func(err error) {
+		log.Info(
+			"call handled",
+			zap.String("method", method),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+}
+
+func (i *interceptors) recover(ctx context.Context, method string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	logFor(ctx, i.log).Error(
+		"handler panicked",
+		zap.String("method", method),
+		zap.String("panic", fmt.Sprint(recovered)),
+		zap.String("stack", string(debug.Stack())),
+	)
+
+	*err = status.Error(codes.Internal, "internal server error")
+}
+
+// authenticate puts the principal and the post.Actor into the context like the REST auth middleware
+func (i *interceptors) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if i.authenticator == nil {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	principal, err := i.authenticator.AuthenticateCredentials(first(md, apiKeyMetadata), first(md, authorizationMetadata))
+	authenticated := err == nil
+
+	if err != nil && !errors.Is(err, auth.ErrNoCredentials) {
+		logFor(ctx, i.log).Info("authentication failed", zap.Error(err))
+
+		return ctx, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if writeMethods[method] {
+		if !authenticated {
+			return ctx, status.Error(codes.Unauthenticated, "authentication required")
+		}
+
+		if !principal.HasScope(auth.ScopeWrite) {
+			return ctx, status.Error(codes.PermissionDenied, "scope "+auth.ScopeWrite+" is required")
+		}
+	}
+
+	if !authenticated {
+		return ctx, nil
+	}
+
+	ctx = auth.WithPrincipal(ctx, principal)
+	ctx = post.WithActor(ctx, post.Actor{
+		ID:     principal.Subject,
+		Editor: principal.HasScope(auth.ScopeEdit),
+	})
+
+	return ctx, nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// serverStream overrides the context of the stream with the authenticated one
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// logFor returns the call scoped logger put into the context by the interceptors
+func logFor(ctx context.Context, log *zap.Logger) *zap.Logger {
+	if l, ok := logger.FromContext(ctx); ok {
+		return l
+	}
+
+	return logger.WithTrace(ctx, log)
+}
@@ -0,0 +1,259 @@
+package grpchandler
+
+import (
+	"context"
+	"fmt"
+
+	newsv1 "github.com/sladonia/news-svc/api/news/v1"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxTags        = 20
+	maxTagLength   = 50
+	maxCategoryLen = 50
+)
+
+func NewServer(log *zap.Logger, postService post.Service, defaultLimit uint) *Server {
+	return &Server{log: log, postService: postService, defaultLimit: defaultLimit}
+}
+
+// Server implements newsv1.NewsServiceServer on top of the post.Service. Requests are validated like
+// the REST ones and service errors are mapped to the gRPC status codes by statusError.
+type Server struct {
+	newsv1.UnimplementedNewsServiceServer
+
+	log          *zap.Logger
+	postService  post.Service
+	defaultLimit uint
+}
+
+func (s *Server) Register(srv *grpc.Server) {
+	newsv1.RegisterNewsServiceServer(srv, s)
+}
+
+func (s *Server) GetPost(ctx context.Context, req *newsv1.GetPostRequest) (*newsv1.Post, error) {
+	p, err := s.postService.GetPost(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return postToProto(p), nil
+}
+
+func (s *Server) CreatePost(ctx context.Context, req *newsv1.CreatePostRequest) (*newsv1.Post, error) {
+	err := validatePost(req.GetTitle(), req.GetContent(), req.GetTags(), req.GetCategory())
+	if err != nil {
+		return nil, err
+	}
+
+	st, ok := statusFromProto[req.GetStatus()]
+	if !ok || st == post.StatusArchived {
+		return nil, status.Error(codes.InvalidArgument, "status should be draft, scheduled or published")
+	}
+
+	p, err := s.postService.CreatePost(ctx, post.Post{
+		Title:     req.GetTitle(),
+		Content:   req.GetContent(),
+		Tags:      req.GetTags(),
+		Category:  req.GetCategory(),
+		Status:    st,
+		PublishAt: timeFromProto(req.GetPublishAt()),
+	})
+	if err != nil {
+		logFor(ctx, s.log).Error("failed to create post", zap.Error(err))
+
+		return nil, statusError(err)
+	}
+
+	return postToProto(p), nil
+}
+
+func (s *Server) UpsertPost(ctx context.Context, req *newsv1.UpsertPostRequest) (*newsv1.Post, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	err := validatePost(req.GetTitle(), req.GetContent(), req.GetTags(), req.GetCategory())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.postService.UpsertPost(ctx, post.Post{
+		ID:       req.GetId(),
+		Title:    req.GetTitle(),
+		Content:  req.GetContent(),
+		Tags:     req.GetTags(),
+		Category: req.GetCategory(),
+		Version:  req.GetVersion(),
+	})
+	if err != nil {
+		logFor(ctx, s.log).Error("failed to upsert post", zap.Error(err))
+
+		return nil, statusError(err)
+	}
+
+	p, err := s.postService.GetPost(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return postToProto(p), nil
+}
+
+func (s *Server) DeletePost(ctx context.Context, req *newsv1.DeletePostRequest) (*newsv1.DeletePostResponse, error) {
+	err := s.postService.DeletePost(ctx, req.GetId(), req.GetVersion())
+	if err != nil {
+		logFor(ctx, s.log).Error("failed to delete post", zap.Error(err))
+
+		return nil, statusError(err)
+	}
+
+	return &newsv1.DeletePostResponse{}, nil
+}
+
+func (s *Server) FindPosts(ctx context.Context, req *newsv1.FindPostsRequest) (*newsv1.FindPostsResponse, error) {
+	f, err := s.filter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.postService.FindPosts(ctx, f)
+	if err != nil {
+		logFor(ctx, s.log).Error("failed to find posts", zap.Error(err))
+
+		return nil, statusError(err)
+	}
+
+	response := &newsv1.FindPostsResponse{Items: make([]*newsv1.Post, len(posts))}
+
+	for i, p := range posts {
+		response.Items[i] = postToProto(p)
+	}
+
+	// search results are ordered by relevance, so they can't be paginated by cursor
+	if f.Query == "" && uint(len(posts)) == f.Limit {
+		response.NextCursor = post.NewCursor(posts[len(posts)-1]).Encode()
+	}
+
+	return response, nil
+}
+
+// StreamPosts sends the posts FindPosts would return, the default limit included. Limits larger
+// than the default one are fetched page by page.
+func (s *Server) StreamPosts(req *newsv1.FindPostsRequest, stream newsv1.NewsService_StreamPostsServer) error {
+	f, err := s.filter(req)
+	if err != nil {
+		return err
+	}
+
+	remaining := f.Limit
+	f.Limit = s.defaultLimit
+
+	for {
+		if remaining < f.Limit {
+			f.Limit = remaining
+		}
+
+		posts, err := s.postService.FindPosts(stream.Context(), f)
+		if err != nil {
+			logFor(stream.Context(), s.log).Error("failed to find posts", zap.Error(err))
+
+			return statusError(err)
+		}
+
+		for _, p := range posts {
+			err = stream.Send(postToProto(p))
+			if err != nil {
+				return err
+			}
+		}
+
+		remaining -= uint(len(posts))
+
+		if remaining == 0 || uint(len(posts)) < f.Limit {
+			return nil
+		}
+
+		if f.Query != "" {
+			f.Offset += f.Limit
+		} else {
+			cursor := post.NewCursor(posts[len(posts)-1])
+			f.After = &cursor
+			f.Offset = 0
+		}
+	}
+}
+
+// filter converts the request into the filter the way REST query parameters are converted
+func (s *Server) filter(req *newsv1.FindPostsRequest) (post.Filter, error) {
+	f := post.Filter{
+		From:     timeValue(req.GetFrom()),
+		To:       timeValue(req.GetTo()),
+		Query:    req.GetQuery(),
+		Tags:     req.GetTags(),
+		AllTags:  req.GetAllTags(),
+		Category: req.GetCategory(),
+		AuthorID: req.GetAuthorId(),
+		Limit:    uint(req.GetLimit()),
+		Offset:   uint(req.GetOffset()),
+	}
+
+	for _, st := range req.GetStatuses() {
+		converted, ok := statusFromProto[st]
+		if !ok || converted == "" {
+			return post.Filter{}, status.Error(codes.InvalidArgument, fmt.Sprintf("unknown post status %s", st))
+		}
+
+		f.Statuses = append(f.Statuses, converted)
+	}
+
+	// readers see only published posts unless asked otherwise
+	if len(f.Statuses) == 0 {
+		f.Statuses = []post.Status{post.StatusPublished}
+	}
+
+	if cursor := req.GetCursor(); cursor != "" {
+		if f.Query != "" {
+			return post.Filter{}, status.Error(codes.InvalidArgument, "cursor can't be combined with query, use offset instead")
+		}
+
+		after, err := post.DecodeCursor(cursor)
+		if err != nil {
+			return post.Filter{}, status.Error(codes.InvalidArgument, "cursor is invalid")
+		}
+
+		f.After = &after
+	}
+
+	if f.Limit == 0 {
+		f.Limit = s.defaultLimit
+	}
+
+	return f, nil
+}
+
+func validatePost(title, content string, tags []string, category string) error {
+	switch {
+	case title == "":
+		return status.Error(codes.InvalidArgument, "title is required")
+	case content == "":
+		return status.Error(codes.InvalidArgument, "content is required")
+	case len(tags) > maxTags:
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("at most %d tags are allowed", maxTags))
+	case len(category) > maxCategoryLen:
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("category should be at most %d characters", maxCategoryLen))
+	}
+
+	for _, tag := range tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("tags should be 1 to %d characters", maxTagLength))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+	"go.uber.org/zap"
+)
+
+type authorRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Bio   string `json:"bio" validate:"max=2000"`
+}
+
+func (h *Handler) createAuthor(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.decodeAuthorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := h.authorService.CreateAuthor(r.Context(), request.Name, request.Email, request.Bio)
+	if err != nil {
+		h.logFor(r).Error("failed to create author", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusCreated, a)
+}
+
+func (h *Handler) listAuthors(w http.ResponseWriter, r *http.Request) {
+	format, ok := h.negotiateList(w, r)
+	if !ok {
+		return
+	}
+
+	count, ok := h.parseCount(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := h.parseUint(r.FormValue("limit"))
+	if err != nil {
+		h.logFor(r).Info("atoi error. limit", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "limit query parameter should be non-negative integer")
+
+		return
+	}
+
+	offset, err := h.parseUint(r.FormValue("offset"))
+	if err != nil {
+		h.logFor(r).Info("atoi error. offset", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "offset query parameter should be non-negative integer")
+
+		return
+	}
+
+	limit = h.pageLimit(limit)
+
+	authors, err := h.authorService.ListAuthors(r.Context(), limit, offset)
+	if err != nil {
+		h.logFor(r).Error("failed to list authors", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	response := listResponse{Items: authorTable(authors), Limit: limit, Offset: offset}
+
+	if count {
+		total, err := h.authorService.CountAuthors(r.Context())
+		if err != nil {
+			h.logFor(r).Error("failed to count authors", zap.Error(err))
+			h.writeError(w, err, err.Error())
+
+			return
+		}
+
+		response.Total = &total
+	}
+
+	response.Links = newListLinks(r, response.Items, limit, offset, "")
+
+	h.writeList(w, r, format, response)
+}
+
+func (h *Handler) authorByID(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	a, err := h.authorService.GetAuthor(r.Context(), id)
+	if err != nil {
+		h.logFor(r).Info("failed to get author", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, a)
+}
+
+func (h *Handler) updateAuthor(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	request, ok := h.decodeAuthorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := h.authorService.UpdateAuthor(r.Context(), author.Author{
+		ID:    id,
+		Name:  request.Name,
+		Email: request.Email,
+		Bio:   request.Bio,
+	})
+	if err != nil {
+		h.logFor(r).Error("failed to update author", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, a)
+}
+
+func (h *Handler) deleteAuthor(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	err := h.authorService.DeleteAuthor(r.Context(), id)
+	if err != nil {
+		h.logFor(r).Error("failed to delete author", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusNoContent, nil)
+}
+
+// decodeAuthorRequest writes the error response itself and reports whether the request is valid
+func (h *Handler) decodeAuthorRequest(w http.ResponseWriter, r *http.Request) (authorRequest, bool) {
+	var request authorRequest
+
+	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		h.logFor(r).Error("failed to unmarshal request", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "failed to unmarshal json")
+
+		return request, false
+	}
+
+	err = h.validator.StructCtx(r.Context(), request)
+	if err != nil {
+		h.logFor(r).Info("validation error", zap.String("error", err.Error()))
+		h.writeValidationErr(w, err)
+
+		return request, false
+	}
+
+	return request, true
+}
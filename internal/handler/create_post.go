@@ -2,22 +2,42 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/post"
 	"go.uber.org/zap"
 )
 
 type createPostRequest struct {
-	Title   string `json:"title" validate:"required"`
-	Content string `json:"content" validate:"required"`
+	Title    string   `json:"title" validate:"required"`
+	Content  string   `json:"content" validate:"required"`
+	Tags     []string `json:"tags" validate:"max=20,dive,required,max=50"`
+	Category string   `json:"category" validate:"max=50"`
+}
+
+func (r createPostRequest) post() post.Post {
+	return post.Post{
+		Title:    r.Title,
+		Content:  r.Content,
+		Tags:     r.Tags,
+		Category: r.Category,
+	}
+}
+
+// newPostRequest is createPostRequest with the optional initial status of the post
+type newPostRequest struct {
+	createPostRequest
+	Status    post.Status `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time  `json:"publish_at"`
 }
 
 func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
-	var request createPostRequest
+	var request newPostRequest
 
 	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		h.log.Error("failed to unmarshal request", zap.Error(err))
+		h.logFor(r).Error("failed to unmarshal request", zap.Error(err))
 		h.writeApiError(w, http.StatusBadRequest, LevelUser, "failed to unmarshal json")
 
 		return
@@ -25,19 +45,24 @@ func (h *Handler) createPost(w http.ResponseWriter, r *http.Request) {
 
 	err = h.validator.StructCtx(r.Context(), request)
 	if err != nil {
-		h.log.Info("validation error", zap.String("error", err.Error()))
+		h.logFor(r).Info("validation error", zap.String("error", err.Error()))
 		h.writeValidationErr(w, err)
 
 		return
 	}
 
-	p, err := h.postService.CreatePost(request.Title, request.Content)
+	p := request.post()
+	p.Status = request.Status
+	p.PublishAt = request.PublishAt
+
+	p, err = h.postService.CreatePost(r.Context(), p)
 	if err != nil {
-		h.log.Error("failed to create post", zap.Error(err))
+		h.logFor(r).Error("failed to create post", zap.Error(err))
 		h.writeError(w, err, err.Error())
 
 		return
 	}
 
+	w.Header().Set("ETag", formatETag(p.Version))
 	h.writeResponse(w, http.StatusCreated, p)
 }
@@ -11,9 +11,22 @@ func (h *Handler) deletePost(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	err := h.postService.DeletePost(id)
+	version, err := h.expectedVersion(r, id)
 	if err != nil {
-		h.log.Error("failed to delete post", zap.Error(err))
+		h.logFor(r).Info("precondition failed", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	if r.FormValue("permanent") == "true" {
+		err = h.postService.PurgePost(r.Context(), id, version)
+	} else {
+		err = h.postService.DeletePost(r.Context(), id, version)
+	}
+
+	if err != nil {
+		h.logFor(r).Error("failed to delete post", zap.Error(err))
 		h.writeError(w, err, err.Error())
 
 		return
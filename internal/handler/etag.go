@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+const anyETag = "*"
+
+var errInvalidETag = errors.New("invalid entity tag")
+
+func formatETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// expectedVersion resolves If-Match header into the version the modification is conditional on.
+// Zero version means unconditional modification. "*" requires the post to exist, so its current version is expected.
+func (h *Handler) expectedVersion(r *http.Request, id string) (int64, error) {
+	version, any, err := parseIfMatch(r)
+	if err != nil {
+		return 0, post.ErrVersionMismatch
+	}
+
+	if !any {
+		return version, nil
+	}
+
+	p, err := h.postService.GetPost(r.Context(), id)
+	if errors.Is(err, post.ErrNotFound) {
+		return 0, post.ErrVersionMismatch
+	}
+
+	return p.Version, err
+}
+
+// parseIfMatch returns the version expected by If-Match header. Zero version means the header is absent,
+// any is set for "*". Only strong entity tags can match, so weak ones are rejected.
+func parseIfMatch(r *http.Request) (version int64, any bool, err error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	switch {
+	case header == "":
+		return 0, false, nil
+	case header == anyETag:
+		return 0, true, nil
+	case strings.HasPrefix(header, "W/"):
+		return 0, false, errInvalidETag
+	}
+
+	version, err = parseETag(header)
+
+	return version, false, err
+}
+
+// ifNoneMatch reports whether If-None-Match header matches the current version using weak comparison
+func ifNoneMatch(r *http.Request, version int64) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+		if tag == anyETag {
+			return true
+		}
+
+		tagVersion, err := parseETag(tag)
+		if err == nil && tagVersion == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseETag(tag string) (int64, error) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, errInvalidETag
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errInvalidETag
+	}
+
+	return version, nil
+}
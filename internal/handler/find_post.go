@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sladonia/news-svc/internal/post"
@@ -10,25 +12,44 @@ import (
 )
 
 func (h *Handler) findPosts(w http.ResponseWriter, r *http.Request) {
+	h.listPosts(w, r, false)
+}
+
+func (h *Handler) trashPosts(w http.ResponseWriter, r *http.Request) {
+	h.listPosts(w, r, true)
+}
+
+// listPosts lists either live or soft deleted posts using the same filters
+func (h *Handler) listPosts(w http.ResponseWriter, r *http.Request, deleted bool) {
+	format, ok := h.negotiateList(w, r)
+	if !ok {
+		return
+	}
+
+	count, ok := h.parseCount(w, r)
+	if !ok {
+		return
+	}
+
 	limit, err := h.parseUint(r.FormValue("limit"))
 	if err != nil {
-		h.log.Info("atoi error. limit", zap.Error(err))
-		h.writeApiError(w, http.StatusBadRequest, LevelUser, "limit query parameter should be integer")
+		h.logFor(r).Info("atoi error. limit", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "limit query parameter should be non-negative integer")
 
 		return
 	}
 
 	offset, err := h.parseUint(r.FormValue("offset"))
 	if err != nil {
-		h.log.Info("atoi error. offset", zap.Error(err))
-		h.writeApiError(w, http.StatusBadRequest, LevelUser, "offset query parameter should be integer")
+		h.logFor(r).Info("atoi error. offset", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "offset query parameter should be non-negative integer")
 
 		return
 	}
 
 	from, err := h.parseTime(r.FormValue("from"))
 	if err != nil {
-		h.log.Info("time parse error. offset", zap.Error(err))
+		h.logFor(r).Info("time parse error. offset", zap.Error(err))
 		h.writeApiError(w, http.StatusBadRequest, LevelUser, "from query parameter should be RFC3339 formatted")
 
 		return
@@ -36,32 +57,96 @@ func (h *Handler) findPosts(w http.ResponseWriter, r *http.Request) {
 
 	to, err := h.parseTime(r.FormValue("to"))
 	if err != nil {
-		h.log.Info("time parse error. offset", zap.Error(err))
+		h.logFor(r).Info("time parse error. offset", zap.Error(err))
 		h.writeApiError(w, http.StatusBadRequest, LevelUser, "to query parameter should be RFC3339 formatted")
 
 		return
 	}
 
-	if limit == 0 {
-		limit = h.defaultNewsLimit
+	after, err := h.parseCursor(r.FormValue("cursor"))
+	if err != nil {
+		h.logFor(r).Info("cursor decode error", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "cursor query parameter is invalid")
+
+		return
+	}
+
+	statuses, err := h.parseStatuses(r.FormValue("status"))
+	if err != nil {
+		h.logFor(r).Info("status parse error", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "status query parameter should be comma separated post statuses")
+
+		return
+	}
+
+	// readers see only published posts unless asked otherwise, the trash lists posts in any status
+	if len(statuses) == 0 && !deleted {
+		statuses = []post.Status{post.StatusPublished}
+	}
+
+	allTags, err := h.parseTagsMatch(r.FormValue("tags_match"))
+	if err != nil {
+		h.logFor(r).Info("tags_match parse error", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "tags_match query parameter should be any or all")
+
+		return
 	}
 
+	query := r.FormValue("q")
+
+	if query != "" && after != nil {
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "cursor can't be combined with q, use offset instead")
+
+		return
+	}
+
+	limit = h.pageLimit(limit)
+
 	f := post.Filter{
-		From:   from,
-		To:     to,
-		Limit:  limit,
-		Offset: offset,
+		From:     from,
+		To:       to,
+		Query:    query,
+		Deleted:  deleted,
+		Statuses: statuses,
+		Tags:     h.parseList(r.FormValue("tags")),
+		AllTags:  allTags,
+		Category: r.FormValue("category"),
+		AuthorID: r.FormValue("author"),
+		After:    after,
+		Limit:    limit,
+		Offset:   offset,
 	}
 
-	posts, err := h.postService.FindPosts(f)
+	posts, err := h.postService.FindPosts(r.Context(), f)
 	if err != nil {
-		h.log.Error("failed to delete post", zap.Error(err))
+		h.logFor(r).Error("failed to find posts", zap.Error(err))
 		h.writeError(w, err, err.Error())
 
 		return
 	}
 
-	h.writeResponse(w, http.StatusOK, posts)
+	response := listResponse{Items: postTable(posts), Limit: limit, Offset: offset}
+
+	// search results are ordered by relevance, so they can't be paginated by cursor
+	if query == "" && uint(len(posts)) == limit {
+		response.NextCursor = post.NewCursor(posts[len(posts)-1]).Encode()
+	}
+
+	if count {
+		total, err := h.postService.CountPosts(r.Context(), f)
+		if err != nil {
+			h.logFor(r).Error("failed to count posts", zap.Error(err))
+			h.writeError(w, err, err.Error())
+
+			return
+		}
+
+		response.Total = &total
+	}
+
+	response.Links = newListLinks(r, response.Items, limit, offset, response.NextCursor)
+
+	h.writeList(w, r, format, response)
 }
 
 func (h *Handler) parseTime(timeStr string) (time.Time, error) {
@@ -72,15 +157,82 @@ func (h *Handler) parseTime(timeStr string) (time.Time, error) {
 	return time.Parse(time.RFC3339, timeStr)
 }
 
+func (h *Handler) parseStatuses(statusesStr string) ([]post.Status, error) {
+	if statusesStr == "" {
+		return nil, nil
+	}
+
+	var statuses []post.Status
+
+	for _, s := range h.parseList(statusesStr) {
+		status := post.Status(strings.TrimSpace(s))
+		if !status.IsValid() {
+			return nil, fmt.Errorf("unknown post status %q", status)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (h *Handler) parseList(listStr string) []string {
+	if listStr == "" {
+		return nil
+	}
+
+	return strings.Split(listStr, ",")
+}
+
+// parseTagsMatch reports whether posts should have all the tags rather than any of them
+func (h *Handler) parseTagsMatch(matchStr string) (bool, error) {
+	switch matchStr {
+	case "", "any":
+		return false, nil
+	case "all":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown tags match %q", matchStr)
+	}
+}
+
+func (h *Handler) parseCursor(cursorStr string) (*post.Cursor, error) {
+	if cursorStr == "" {
+		return nil, nil
+	}
+
+	cursor, err := post.DecodeCursor(cursorStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// parseUint rejects negative values, so that they don't wrap around to the huge ones
 func (h *Handler) parseUint(intStr string) (uint, error) {
 	if intStr == "" {
 		return 0, nil
 	}
 
-	val, err := strconv.Atoi(intStr)
+	val, err := strconv.ParseUint(intStr, 10, 0)
 	if err != nil {
 		return 0, err
 	}
 
 	return uint(val), nil
 }
+
+// pageLimit replaces the zero limit with the default one and clamps it to the maximum, zero maximum
+// leaves it unbounded
+func (h *Handler) pageLimit(limit uint) uint {
+	if limit == 0 {
+		limit = h.defaultNewsLimit
+	}
+
+	if h.maxNewsLimit > 0 && limit > h.maxNewsLimit {
+		limit = h.maxNewsLimit
+	}
+
+	return limit
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -10,6 +11,10 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/openapi"
+	"github.com/sladonia/news-svc/internal/policy"
 	"github.com/sladonia/news-svc/internal/post"
 	"go.uber.org/zap"
 )
@@ -17,15 +22,29 @@ import (
 func NewHandler(
 	log *zap.Logger,
 	defaultNewsLimit uint,
+	maxNewsLimit uint,
 	postService post.Service,
+	authorService author.Service,
 	serviceName string,
 ) *Handler {
+	openAPI := newOpenAPI(serviceName)
+
+	// the document is static, so it's either always encoded or never
+	openAPIDocument, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(openAPI)
+	if err != nil {
+		panic(err)
+	}
+
 	return &Handler{
 		log:              log,
 		validator:        validator.New(),
 		postService:      postService,
+		authorService:    authorService,
 		defaultNewsLimit: defaultNewsLimit,
+		maxNewsLimit:     maxNewsLimit,
 		serviceName:      serviceName,
+		openAPI:          openAPI,
+		openAPIDocument:  openAPIDocument,
 	}
 }
 
@@ -33,28 +52,60 @@ type Handler struct {
 	serviceName      string
 	log              *zap.Logger
 	postService      post.Service
+	authorService    author.Service
 	validator        *validator.Validate
 	defaultNewsLimit uint
+	maxNewsLimit     uint
+	openAPI          *openapi.Document
+	openAPIDocument  []byte
+}
+
+type identityResponse struct {
+	ServiceName string `json:"service_name"`
 }
 
 func (h *Handler) Register(r *mux.Router) {
-	r.HandleFunc("/", h.identity)
+	r.HandleFunc("/", h.identity).Name("identity")
 	r.HandleFunc("/posts", h.createPost).Name("createPost").Methods("POST")
 	r.HandleFunc("/posts", h.findPosts).Name("findPosts").Methods("GET")
+	r.HandleFunc("/posts/trash", h.trashPosts).Name("trashPosts").Methods("GET")
 	r.HandleFunc("/posts/{id}", h.postByID).Name("postByID").Methods("GET")
 	r.HandleFunc("/posts/{id}", h.replacePost).Name("replacePost").Methods("PUT")
+	r.HandleFunc("/posts/{id}", h.patchPost).Name("patchPost").Methods("PATCH")
 	r.HandleFunc("/posts/{id}", h.deletePost).Name("deletePost").Methods("DELETE")
+	r.HandleFunc("/posts/{id}/restore", h.restorePost).Name("restorePost").Methods("POST")
+	r.HandleFunc("/posts/{id}/status", h.setPostStatus).Name("setPostStatus").Methods("PUT")
+	r.HandleFunc("/tags", h.tags).Name("tags").Methods("GET")
+	r.HandleFunc("/categories", h.categories).Name("categories").Methods("GET")
+	r.HandleFunc("/authors", h.createAuthor).Name("createAuthor").Methods("POST")
+	r.HandleFunc("/authors", h.listAuthors).Name("listAuthors").Methods("GET")
+	r.HandleFunc("/authors/{id}", h.authorByID).Name("authorByID").Methods("GET")
+	r.HandleFunc("/authors/{id}", h.updateAuthor).Name("updateAuthor").Methods("PUT")
+	r.HandleFunc("/authors/{id}", h.deleteAuthor).Name("deleteAuthor").Methods("DELETE")
+	r.HandleFunc("/posts/{id}/revisions", h.postRevisions).Name("postRevisions").Methods("GET")
+	r.HandleFunc("/posts/{id}/revisions/diff", h.diffRevisions).Name("diffRevisions").Methods("GET")
+	r.HandleFunc("/posts/{id}/revisions/{n:[0-9]+}", h.postRevision).Name("postRevision").Methods("GET")
+	r.HandleFunc("/posts/{id}/revisions/{n:[0-9]+}/revert", h.revertPost).Name("revertPost").Methods("POST")
+	r.HandleFunc("/openapi.json", h.serveOpenAPI).Name("openapi").Methods("GET")
 }
 
 func (h *Handler) identity(w http.ResponseWriter, r *http.Request) {
-	encoded, _ := jsoniter.ConfigFastest.Marshal(struct {
-		ServiceName string `json:"service_name"`
-	}{ServiceName: h.serviceName})
+	encoded, _ := jsoniter.ConfigFastest.Marshal(identityResponse{ServiceName: h.serviceName})
 
 	w.WriteHeader(200)
 	w.Write(encoded)
 }
 
+// logFor returns the request scoped logger put into the context by the middlewares.HandlerLogger.
+// The handler logger annotated with the trace is used if there is none.
+func (h *Handler) logFor(r *http.Request) *zap.Logger {
+	if log, ok := logger.FromContext(r.Context()); ok {
+		return log
+	}
+
+	return logger.WithTrace(r.Context(), h.log)
+}
+
 func (h *Handler) writeApiError(w http.ResponseWriter, status int, level Level, msg string) {
 	apiErr := NewApiError(msg, level)
 
@@ -112,10 +163,20 @@ func (h *Handler) classifyError(err error) (int, Level) {
 	switch {
 	case errors.As(err, &operationError):
 		return http.StatusInternalServerError, LevelSystem
-	case errors.Is(err, post.ErrNotFound):
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, LevelSystem
+	case errors.Is(err, post.ErrNotFound), errors.Is(err, author.ErrNotFound):
 		return http.StatusNotFound, LevelUser
-	case errors.Is(err, post.ErrorAlreadyExists):
+	case errors.Is(err, post.ErrorAlreadyExists), errors.Is(err, author.ErrAlreadyExists):
+		return http.StatusConflict, LevelUser
+	case errors.Is(err, post.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, LevelUser
+	case errors.Is(err, post.ErrForbidden), errors.Is(err, author.ErrForbidden), errors.Is(err, policy.ErrDenied):
+		return http.StatusForbidden, LevelUser
+	case errors.Is(err, post.ErrInvalidTransition):
 		return http.StatusConflict, LevelUser
+	case errors.Is(err, post.ErrInvalidPublishAt):
+		return http.StatusBadRequest, LevelUser
 	default:
 		return http.StatusInternalServerError, LevelSystem
 	}
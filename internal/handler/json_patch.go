@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var errPatchTestFailed = errors.New("json patch test operation failed")
+
+// jsonPatchOperation is a single operation of RFC 6902 JSON Patch
+type jsonPatchOperation struct {
+	Op    string     `json:"op"`
+	Path  string     `json:"path"`
+	From  string     `json:"from"`
+	Value patchValue `json:"value"`
+}
+
+// patchValue keeps the raw value of the operation, unlike jsoniter.RawMessage it keeps explicit null
+type patchValue struct {
+	raw []byte
+}
+
+func (v *patchValue) UnmarshalJSON(b []byte) error {
+	v.raw = append([]byte(nil), b...)
+
+	return nil
+}
+
+// applyJSONPatch applies RFC 6902 operations to the json document. Members of the nested objects and items
+// of the arrays are addressed by RFC 6901 JSON Pointers, "-" addresses the end of the array.
+func applyJSONPatch(doc map[string]interface{}, operations []jsonPatchOperation) error {
+	for _, operation := range operations {
+		path, err := parsePointer(operation.Path)
+		if err != nil {
+			return err
+		}
+
+		switch operation.Op {
+		case "add", "replace", "test":
+			value, err := operation.value()
+			if err != nil {
+				return err
+			}
+
+			switch operation.Op {
+			case "add":
+				err = updateContainer(doc, path, addValue(value))
+			case "replace":
+				err = updateContainer(doc, path, replaceValue(value))
+			default:
+				err = testValue(doc, path, value)
+			}
+
+			if err != nil {
+				return err
+			}
+		case "remove":
+			err = updateContainer(doc, path, removeValue)
+			if err != nil {
+				return err
+			}
+		case "move", "copy":
+			from, err := parsePointer(operation.From)
+			if err != nil {
+				return err
+			}
+
+			value, err := valueAt(doc, from)
+			if err != nil {
+				return err
+			}
+
+			if operation.Op == "move" {
+				err = updateContainer(doc, from, removeValue)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = updateContainer(doc, path, addValue(value))
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported operation %q", operation.Op)
+		}
+	}
+
+	return nil
+}
+
+func (o jsonPatchOperation) value() (interface{}, error) {
+	if len(o.Value.raw) == 0 {
+		return nil, fmt.Errorf("%s operation requires value", o.Op)
+	}
+
+	var value interface{}
+
+	err := jsoniter.ConfigFastest.Unmarshal(o.Value.raw, &value)
+
+	return value, err
+}
+
+// pointer is the parsed RFC 6901 JSON Pointer, the document itself can't be addressed
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("path %q is not supported", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func (p pointer) String() string {
+	return "/" + strings.Join(p, "/")
+}
+
+// containerUpdate changes the member of the object or the item of the array addressed by the token.
+// It returns the container, which is a new slice if the array is resized.
+type containerUpdate func(container interface{}, token string, path pointer) (interface{}, error)
+
+// updateContainer applies the update to the container of the last token of the path. Arrays on the way
+// are replaced in their parents, as updates may resize them.
+func updateContainer(doc map[string]interface{}, path pointer, update containerUpdate) error {
+	_, err := updateAt(doc, path, 0, update)
+
+	return err
+}
+
+func updateAt(container interface{}, path pointer, i int, update containerUpdate) (interface{}, error) {
+	if i == len(path)-1 {
+		return update(container, path[i], path)
+	}
+
+	child, err := member(container, path[i], path[:i+1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = updateAt(child, path, i+1, update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := container.(type) {
+	case map[string]interface{}:
+		container[path[i]] = child
+	case []interface{}:
+		index, _ := arrayIndex(container, path[i], false)
+		container[index] = child
+	}
+
+	return container, nil
+}
+
+func valueAt(doc map[string]interface{}, path pointer) (interface{}, error) {
+	var value interface{} = doc
+
+	for i, token := range path {
+		var err error
+
+		value, err = member(value, token, path[:i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// member returns the existing member of the object or item of the array
+func member(container interface{}, token string, path pointer) (interface{}, error) {
+	switch container := container.(type) {
+	case map[string]interface{}:
+		value, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("path %s does not exist", path)
+		}
+
+		return value, nil
+	case []interface{}:
+		index, err := arrayIndex(container, token, false)
+		if err != nil {
+			return nil, fmt.Errorf("path %s does not exist", path)
+		}
+
+		return container[index], nil
+	default:
+		return nil, fmt.Errorf("path %s does not exist", path)
+	}
+}
+
+// arrayIndex parses the index of the existing item, or the one to insert at if end is allowed
+func arrayIndex(array []interface{}, token string, end bool) (int, error) {
+	size := len(array)
+	if end {
+		size++
+	}
+
+	if end && token == "-" {
+		return len(array), nil
+	}
+
+	// leading zeros aren't allowed by RFC 6901
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index >= size {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	return index, nil
+}
+
+func addValue(value interface{}) containerUpdate {
+	return func(container interface{}, token string, path pointer) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(container, token, true)
+			if err != nil {
+				return nil, fmt.Errorf("path %s: %w", path, err)
+			}
+
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+
+			return container, nil
+		default:
+			return nil, fmt.Errorf("path %s does not exist", path[:len(path)-1])
+		}
+	}
+}
+
+func replaceValue(value interface{}) containerUpdate {
+	return func(container interface{}, token string, path pointer) (interface{}, error) {
+		if _, err := member(container, token, path); err != nil {
+			return nil, err
+		}
+
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+		case []interface{}:
+			index, _ := arrayIndex(container, token, false)
+			container[index] = value
+		}
+
+		return container, nil
+	}
+}
+
+func removeValue(container interface{}, token string, path pointer) (interface{}, error) {
+	if _, err := member(container, token, path); err != nil {
+		return nil, err
+	}
+
+	switch container := container.(type) {
+	case map[string]interface{}:
+		delete(container, token)
+
+		return container, nil
+	case []interface{}:
+		index, _ := arrayIndex(container, token, false)
+
+		return append(container[:index], container[index+1:]...), nil
+	default:
+		return container, nil
+	}
+}
+
+// testValue compares the values decoded from JSON, so numbers are float64 on both sides
+func testValue(doc map[string]interface{}, path pointer, value interface{}) error {
+	current, err := valueAt(doc, path)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(current, value) {
+		return fmt.Errorf("%w: %s", errPatchTestFailed, path)
+	}
+
+	return nil
+}
+
+// applyMergePatch applies RFC 7396 JSON Merge Patch to the document
+func applyMergePatch(doc map[string]interface{}, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(doc, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			doc[key] = value
+			continue
+		}
+
+		docObject, ok := doc[key].(map[string]interface{})
+		if !ok {
+			docObject = make(map[string]interface{})
+		}
+
+		applyMergePatch(docObject, patchObject)
+		doc[key] = docObject
+	}
+}
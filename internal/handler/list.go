@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+)
+
+// listResponse is the envelope of the JSON and MessagePack lists. NDJSON and CSV lists carry
+// the total in X-Total-Count header, links of every format are also sent in Link header.
+type listResponse struct {
+	Items      table     `json:"items"`
+	Total      *int64    `json:"total,omitempty"`
+	Limit      uint      `json:"limit"`
+	Offset     uint      `json:"offset"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Links      listLinks `json:"links"`
+}
+
+type listLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// table is the page of items, which can be written as CSV records
+type table interface {
+	Len() int
+	Item(i int) interface{}
+	Header() []string
+	Record(i int) []string
+}
+
+type postTable []post.Post
+
+func (t postTable) Len() int               { return len(t) }
+func (t postTable) Item(i int) interface{} { return t[i] }
+
+func (t postTable) Header() []string {
+	return []string{"ID", "Title", "Content", "AuthorID", "Tags", "Category", "Status",
+		"CreatedAt", "UpdatedAt", "PublishAt", "DeletedAt", "Version"}
+}
+
+func (t postTable) Record(i int) []string {
+	p := t[i]
+
+	return []string{p.ID, p.Title, p.Content, p.AuthorID, strings.Join(p.Tags, ","), p.Category, string(p.Status),
+		formatCSVTime(&p.CreatedAt), formatCSVTime(&p.UpdatedAt), formatCSVTime(p.PublishAt),
+		formatCSVTime(p.DeletedAt), strconv.FormatInt(p.Version, 10)}
+}
+
+type authorTable []author.Author
+
+func (t authorTable) Len() int               { return len(t) }
+func (t authorTable) Item(i int) interface{} { return t[i] }
+
+func (t authorTable) Header() []string {
+	return []string{"ID", "Name", "Email", "Bio", "CreatedAt", "UpdatedAt"}
+}
+
+func (t authorTable) Record(i int) []string {
+	a := t[i]
+
+	return []string{a.ID, a.Name, a.Email, a.Bio, formatCSVTime(&a.CreatedAt), formatCSVTime(&a.UpdatedAt)}
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+// negotiateList picks the list format by the Accept header, writing 406 if none is acceptable
+func (h *Handler) negotiateList(w http.ResponseWriter, r *http.Request) (format, bool) {
+	f, ok := negotiateFormat(r.Header.Get("Accept"))
+	if !ok {
+		h.writeApiError(w, http.StatusNotAcceptable, LevelUser,
+			"acceptable list media types are application/json, application/x-ndjson, text/csv and application/msgpack")
+	}
+
+	return f, ok
+}
+
+// parseCount reports whether the total count is requested by the count query parameter
+func (h *Handler) parseCount(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	countStr := r.FormValue("count")
+	if countStr == "" {
+		return false, true
+	}
+
+	count, err := strconv.ParseBool(countStr)
+	if err != nil {
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "count query parameter should be boolean")
+
+		return false, false
+	}
+
+	return count, true
+}
+
+// newListLinks links the current, first, previous and next pages. The next page is linked by the cursor
+// if there is one, by offset otherwise, and only when the page is full.
+func newListLinks(r *http.Request, items table, limit, offset uint, nextCursor string) listLinks {
+	page := func(set map[string]string) string {
+		query := r.URL.Query()
+		query.Del("cursor")
+		query.Del("offset")
+
+		for key, value := range set {
+			query.Set(key, value)
+		}
+
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+
+		return u.String()
+	}
+
+	links := listLinks{
+		Self:  r.URL.RequestURI(),
+		First: page(nil),
+	}
+
+	if offset > 0 && r.FormValue("cursor") == "" {
+		prev := uint(0)
+		if offset > limit {
+			prev = offset - limit
+		}
+
+		links.Prev = page(map[string]string{"offset": strconv.FormatUint(uint64(prev), 10)})
+	}
+
+	switch {
+	case nextCursor != "":
+		links.Next = page(map[string]string{"cursor": nextCursor})
+	case uint(items.Len()) == limit && r.FormValue("cursor") == "":
+		links.Next = page(map[string]string{"offset": strconv.FormatUint(uint64(offset+limit), 10)})
+	}
+
+	return links
+}
+
+// formatLinkHeader formats the links as RFC 8288 Link header
+func formatLinkHeader(links listLinks) string {
+	var values []string
+
+	for _, link := range []struct{ rel, target string }{
+		{"self", links.Self},
+		{"first", links.First},
+		{"prev", links.Prev},
+		{"next", links.Next},
+	} {
+		if link.target != "" {
+			values = append(values, "<"+link.target+`>; rel="`+link.rel+`"`)
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// writeList writes the list in the negotiated format
+func (h *Handler) writeList(w http.ResponseWriter, r *http.Request, f format, response listResponse) {
+	w.Header().Set("Link", formatLinkHeader(response.Links))
+	w.Header().Set("Vary", "Accept")
+
+	if response.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*response.Total, 10))
+	}
+
+	var err error
+
+	switch f {
+	case formatNDJSON:
+		err = h.writeNDJSON(w, response.Items)
+	case formatCSV:
+		err = h.writeCSV(w, response.Items)
+	case formatMsgPack:
+		err = h.writeMsgPack(w, response)
+	default:
+		w.Header().Set("Content-Type", string(formatJSON))
+		h.writeResponse(w, http.StatusOK, response)
+	}
+
+	if err != nil {
+		h.logFor(r).Error("failed to write list", zap.Error(err), zap.String("format", string(f)))
+	}
+}
+
+// writeNDJSON streams the items as JSON lines, flushing every line
+func (h *Handler) writeNDJSON(w http.ResponseWriter, items table) error {
+	w.Header().Set("Content-Type", string(formatNDJSON))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	bw := bufio.NewWriter(w)
+	encoder := jsoniter.ConfigFastest.NewEncoder(bw)
+
+	for i := 0; i < items.Len(); i++ {
+		err := encoder.Encode(items.Item(i))
+		if err != nil {
+			return err
+		}
+
+		err = bw.Flush()
+		if err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) writeCSV(w http.ResponseWriter, items table) error {
+	w.Header().Set("Content-Type", string(formatCSV)+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(items.Header())
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < items.Len(); i++ {
+		err = cw.Write(items.Record(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// writeMsgPack encodes the envelope with the field names of JSON
+func (h *Handler) writeMsgPack(w http.ResponseWriter, response listResponse) error {
+	encoder := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(encoder)
+
+	var buf bytes.Buffer
+
+	encoder.Reset(&buf)
+	encoder.SetCustomStructTag("json")
+
+	err := encoder.Encode(response)
+	if err != nil {
+		h.writeApiError(w, http.StatusInternalServerError, LevelSystem, "failed to encode response")
+
+		return err
+	}
+
+	w.Header().Set("Content-Type", string(formatMsgPack))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(buf.Bytes())
+
+	return err
+}
@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+func NewAuth(log *zap.Logger, authenticator *auth.Authenticator) *AuthMiddleware {
+	return &AuthMiddleware{
+		log:           log,
+		authenticator: authenticator,
+		scopes:        make(map[string]string),
+	}
+}
+
+// AuthMiddleware puts the authenticated auth.Principal into the request context along with the post.Actor
+// it acts as. By default GET, HEAD and OPTIONS routes are public and the others require auth.ScopeWrite.
+type AuthMiddleware struct {
+	log           *zap.Logger
+	authenticator *auth.Authenticator
+	scopes        map[string]string
+}
+
+// Require makes the named routes require the scope instead of the default one. Empty scope makes them public.
+func (m *AuthMiddleware) Require(scope string, routeNames ...string) *AuthMiddleware {
+	for _, name := range routeNames {
+		m.scopes[name] = scope
+	}
+
+	return m
+}
+
+func (m *AuthMiddleware) Register(r *mux.Router) {
+	r.Use(m.authenticate)
+}
+
+func (m *AuthMiddleware) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := m.authenticator.Authenticate(r)
+		authenticated := err == nil
+
+		if err != nil && !errors.Is(err, auth.ErrNoCredentials) {
+			requestLogger(r, m.log).Info("authentication failed", zap.Error(err))
+			m.writeUnauthorized(w, "invalid credentials")
+
+			return
+		}
+
+		scope := m.requiredScope(r)
+
+		if scope != "" && !authenticated {
+			m.writeUnauthorized(w, "authentication required")
+
+			return
+		}
+
+		if scope != "" && !principal.HasScope(scope) {
+			m.writeError(w, http.StatusForbidden, "scope "+scope+" is required")
+
+			return
+		}
+
+		if authenticated {
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			ctx = post.WithActor(ctx, post.Actor{
+				ID:     principal.Subject,
+				Editor: principal.HasScope(auth.ScopeEdit),
+			})
+
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *AuthMiddleware) requiredScope(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if scope, ok := m.scopes[route.GetName()]; ok {
+			return scope
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ""
+	default:
+		return auth.ScopeWrite
+	}
+}
+
+func (m *AuthMiddleware) writeUnauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	m.writeError(w, http.StatusUnauthorized, msg)
+}
+
+func (m *AuthMiddleware) writeError(w http.ResponseWriter, status int, msg string) {
+	encoded, _ := jsoniter.ConfigFastest.Marshal(handler.NewApiError(msg, handler.LevelUser))
+
+	w.WriteHeader(status)
+
+	_, err := w.Write(encoded)
+	if err != nil {
+		m.log.Error("failed to write response", zap.Error(err))
+	}
+}
@@ -1,16 +1,34 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/rs/xid"
+	"github.com/sladonia/news-svc/internal/logger"
 	"go.uber.org/zap"
 )
 
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID returns the id assigned to the request by the HandlerLogger
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
 func NewHandlerLogger(logger *zap.Logger) *HandlerLogger {
 	return &HandlerLogger{logger: logger}
 }
 
+// HandlerLogger keeps the X-Request-ID of the request or assigns a new one and returns it in the response.
+// Request scoped logger annotated with the request id and trace is put into the request context
+// and the access line is logged once the request is handled.
 type HandlerLogger struct {
 	logger *zap.Logger
 }
@@ -21,12 +39,42 @@ func (mw *HandlerLogger) Register(r *mux.Router) {
 
 func (mw *HandlerLogger) log(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		mw.logger.Debug(
-			"incoming request",
-			zap.String("url", r.URL.String()),
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !logger.IsValidRequestID(requestID) {
+			requestID = xid.New().String()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		log := logger.WithTrace(r.Context(), mw.logger).With(zap.String("request_id", requestID))
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx = logger.WithContext(ctx, log)
+
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		log.Info(
+			"request handled",
 			zap.String("method", r.Method),
+			zap.String("url", r.URL.String()),
+			zap.String("route", routeName(r)),
+			zap.Int("status", recorder.status),
+			zap.Int("bytes", recorder.bytes),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("remote_addr", r.RemoteAddr),
 		)
-
-		next.ServeHTTP(w, r)
 	})
 }
+
+// requestLogger returns the request scoped logger or the fallback one if HandlerLogger isn't registered
+func requestLogger(r *http.Request, fallback *zap.Logger) *zap.Logger {
+	if log, ok := logger.FromContext(r.Context()); ok {
+		return log
+	}
+
+	return fallback
+}
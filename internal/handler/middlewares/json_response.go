@@ -10,6 +10,8 @@ func NewJsonResponse() *JsonResponseMiddleware {
 	return &JsonResponseMiddleware{}
 }
 
+// JsonResponseMiddleware defaults Content-Type of the responses to application/json. Handlers writing other
+// formats set their own Content-Type, responses without the body like 204 and 304 get none.
 type JsonResponseMiddleware struct{}
 
 func (m *JsonResponseMiddleware) Register(r *mux.Router) {
@@ -18,8 +20,42 @@ func (m *JsonResponseMiddleware) Register(r *mux.Router) {
 
 func (m *JsonResponseMiddleware) jsonResponse(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Content-Type", "application/json")
-
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(&jsonResponseWriter{ResponseWriter: w}, r)
 	})
 }
+
+type jsonResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *jsonResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		if hasBody(status) && w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *jsonResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the writer
+func (w *jsonResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func hasBody(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func NewMetrics(registerer prometheus.Registerer) *MetricsMiddleware {
+	m := &MetricsMiddleware{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "news",
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled requests by route name, method and status code.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "news",
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of handled requests by route name and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "news",
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of requests being handled by route name.",
+		}, []string{"route"}),
+	}
+
+	registerer.MustRegister(m.requests, m.duration, m.inFlight)
+
+	return m
+}
+
+// MetricsMiddleware records prometheus metrics of the requests labeled with the name of the matched route
+type MetricsMiddleware struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func (m *MetricsMiddleware) Register(r *mux.Router) {
+	r.Use(m.instrument)
+}
+
+func (m *MetricsMiddleware) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeName(r)
+		inFlight := m.inFlight.WithLabelValues(route)
+
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r)
+
+		m.duration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+	})
+}
+
+// routeName returns the name of the matched route or its path template if the route is unnamed
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	if name := route.GetName(); name != "" {
+		return name
+	}
+
+	template, _ := route.GetPathTemplate()
+
+	return template
+}
@@ -0,0 +1,287 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/openapi"
+	"go.uber.org/zap"
+)
+
+var (
+	errInvalidJSON = errors.New("failed to unmarshal json")
+	errReadRequest = errors.New("failed to read request")
+)
+
+func NewOpenAPIValidation(log *zap.Logger, doc *openapi.Document) *OpenAPIValidationMiddleware {
+	return &OpenAPIValidationMiddleware{log: log, doc: doc}
+}
+
+// OpenAPIValidationMiddleware validates the requests of the routes described by the openapi.Document, operations
+// are matched by the route names. Invalid requests are rejected with 400 ApiError like the handlers reject them.
+// JSON responses are buffered and validated, the ones not matching the document are logged and replaced
+// with 500 ApiError. It's meant for development and tests, streamed responses aren't validated.
+type OpenAPIValidationMiddleware struct {
+	log *zap.Logger
+	doc *openapi.Document
+}
+
+func (m *OpenAPIValidationMiddleware) Register(r *mux.Router) {
+	r.Use(m.validate)
+}
+
+func (m *OpenAPIValidationMiddleware) validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, operation, ok := m.doc.Operation(routeName(r))
+		if !ok {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		err := m.validateRequest(r, operation)
+		if err != nil {
+			requestLogger(r, m.log).Info("invalid request", zap.Error(err))
+			m.writeError(w, http.StatusBadRequest, handler.NewApiError(err.Error(), handler.LevelUser))
+
+			return
+		}
+
+		recorder := &validationRecorder{ResponseWriter: w, status: http.StatusOK, buffered: true}
+
+		next.ServeHTTP(recorder, r)
+
+		err = m.validateResponse(recorder, operation)
+		if err == nil {
+			recorder.flush()
+
+			return
+		}
+
+		requestLogger(r, m.log).Error(
+			"response does not match openapi document",
+			zap.String("route", routeName(r)),
+			zap.Int("status", recorder.status),
+			zap.Error(err),
+		)
+
+		if !recorder.buffered {
+			return
+		}
+
+		m.writeError(w, http.StatusInternalServerError,
+			handler.NewApiError("response validation error: "+err.Error(), handler.LevelSystem))
+	})
+}
+
+func (m *OpenAPIValidationMiddleware) validateRequest(r *http.Request, operation *openapi.Operation) error {
+	var errs openapi.ValidationError
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	for _, p := range operation.Parameters {
+		var raw string
+
+		switch p.In {
+		case "path":
+			raw = vars[p.Name]
+		case "query":
+			raw = query.Get(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+		}
+
+		// handlers treat empty parameters as absent
+		if raw == "" {
+			if p.Required {
+				errs = append(errs, openapi.FieldError{Field: p.Name, Rule: "required"})
+			}
+
+			continue
+		}
+
+		errs = appendValidationError(errs, m.doc.ValidateParameter(p, raw))
+	}
+
+	if operation.RequestBody != nil {
+		err := m.validateBody(r, operation.RequestBody)
+		if errors.Is(err, errInvalidJSON) || errors.Is(err, errReadRequest) {
+			return err
+		}
+
+		errs = appendValidationError(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateBody validates the body of the documented media type and puts it back for the handler.
+// Bodies of the other media types are left to the handler to reject.
+func (m *OpenAPIValidationMiddleware) validateBody(r *http.Request, body *openapi.RequestBody) error {
+	mediaType := parseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = openapi.MediaTypeJSON
+	}
+
+	content, ok := body.Content[mediaType]
+	if !ok || content.Schema == nil {
+		return nil
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errReadRequest
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+
+	var value interface{}
+
+	err = jsoniter.ConfigFastest.Unmarshal(raw, &value)
+	if err != nil {
+		return errInvalidJSON
+	}
+
+	return m.doc.Validate(content.Schema, value)
+}
+
+func (m *OpenAPIValidationMiddleware) validateResponse(
+	recorder *validationRecorder,
+	operation *openapi.Operation,
+) error {
+	response, ok := operation.Response(recorder.status)
+	if !ok {
+		return fmt.Errorf("status %d is not documented", recorder.status)
+	}
+
+	mediaType := parseMediaType(recorder.Header().Get("Content-Type"))
+
+	if !recorder.buffered {
+		if _, ok := response.Content[mediaType]; !ok {
+			return fmt.Errorf("media type %s is not documented", mediaType)
+		}
+
+		return nil
+	}
+
+	content, ok := response.Content[openapi.MediaTypeJSON]
+
+	switch {
+	case recorder.body.Len() == 0 && !ok:
+		return nil
+	case recorder.body.Len() == 0:
+		return errors.New("body is missing")
+	case !ok:
+		return errors.New("body is not documented")
+	case content.Schema == nil:
+		return nil
+	}
+
+	var value interface{}
+
+	err := jsoniter.ConfigFastest.Unmarshal(recorder.body.Bytes(), &value)
+	if err != nil {
+		return fmt.Errorf("body is not json: %w", err)
+	}
+
+	return m.doc.Validate(content.Schema, value)
+}
+
+func (m *OpenAPIValidationMiddleware) writeError(w http.ResponseWriter, status int, apiErr handler.ApiError) {
+	encoded, _ := jsoniter.ConfigFastest.Marshal(apiErr)
+
+	w.WriteHeader(status)
+
+	_, err := w.Write(encoded)
+	if err != nil {
+		m.log.Error("failed to write response", zap.Error(err))
+	}
+}
+
+func appendValidationError(errs openapi.ValidationError, err error) openapi.ValidationError {
+	var vErr openapi.ValidationError
+	if errors.As(err, &vErr) {
+		return append(errs, vErr...)
+	}
+
+	return errs
+}
+
+func parseMediaType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return mediaType
+}
+
+// validationRecorder buffers JSON responses, so that they can be replaced if they are invalid. JSON is
+// the default Content-Type of the responses with the body, see JsonResponseMiddleware. Responses are
+// buffered until the header is written, as the handler may write none.
+type validationRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffered    bool
+	body        bytes.Buffer
+}
+
+func (w *validationRecorder) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+
+	mediaType := parseMediaType(w.Header().Get("Content-Type"))
+	w.buffered = mediaType == "" || mediaType == openapi.MediaTypeJSON
+
+	if !w.buffered {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *validationRecorder) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.buffered {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the recorder, buffered responses are written once validated
+func (w *validationRecorder) Flush() {
+	if w.buffered {
+		return
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// flush writes the buffered response
+func (w *validationRecorder) flush() {
+	if !w.buffered {
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+func NewRateLimit(log *zap.Logger, store ratelimit.Store, defaultLimit ratelimit.Limit) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		log:          log,
+		store:        store,
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]ratelimit.Limit),
+	}
+}
+
+// RateLimitMiddleware limits the requests of each client with the token bucket. Clients are told apart by
+// the API key, JWT subject or IP address of the anonymous ones, so it should be registered after the
+// AuthMiddleware. Routes with their own limit have the buckets of their own, the others share the bucket
+// of the default limit. Store failures let the requests through.
+type RateLimitMiddleware struct {
+	log               *zap.Logger
+	store             ratelimit.Store
+	defaultLimit      ratelimit.Limit
+	limits            map[string]ratelimit.Limit
+	trustForwardedFor bool
+	byIP              bool
+}
+
+// Limit sets the limit of the named routes, zero limit makes them unlimited
+func (m *RateLimitMiddleware) Limit(limit ratelimit.Limit, routeNames ...string) *RateLimitMiddleware {
+	for _, name := range routeNames {
+		m.limits[name] = limit
+	}
+
+	return m
+}
+
+// TrustForwardedFor makes the anonymous clients identified by the last X-Forwarded-For address, the one
+// appended by the proxy. The earlier ones are set by the client and can't be trusted.
+func (m *RateLimitMiddleware) TrustForwardedFor() *RateLimitMiddleware {
+	m.trustForwardedFor = true
+
+	return m
+}
+
+// ByIP makes the clients told apart by the IP address only. Such middleware is registered before
+// the AuthMiddleware, so that the requests with invalid credentials are limited as well.
+func (m *RateLimitMiddleware) ByIP() *RateLimitMiddleware {
+	m.byIP = true
+
+	return m
+}
+
+func (m *RateLimitMiddleware) Register(r *mux.Router) {
+	r.Use(m.limit)
+}
+
+func (m *RateLimitMiddleware) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket, limit := m.bucket(r)
+		if limit.IsZero() {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		result, err := m.store.Take(r.Context(), m.clientKey(r)+"|"+bucket, limit, time.Now())
+		if err != nil {
+			requestLogger(r, m.log).Error("failed to take rate limit token", zap.Error(err))
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+			m.writeError(w)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bucket returns the name of the bucket of the request and its limit
+func (m *RateLimitMiddleware) bucket(r *http.Request) (string, ratelimit.Limit) {
+	route := routeName(r)
+
+	if limit, ok := m.limits[route]; ok {
+		return route, limit
+	}
+
+	return "*", m.defaultLimit
+}
+
+// clientKey of the IP limit differs from the one of the anonymous clients, so that the buckets
+// of the middlewares don't mix in the shared store
+func (m *RateLimitMiddleware) clientKey(r *http.Request) string {
+	if m.byIP {
+		return "addr:" + m.clientIP(r)
+	}
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+
+	switch {
+	case ok && r.Header.Get(auth.APIKeyHeader) != "":
+		sum := sha256.Sum256([]byte(r.Header.Get(auth.APIKeyHeader)))
+
+		return "key:" + hex.EncodeToString(sum[:16])
+	case ok:
+		return "sub:" + principal.Subject
+	default:
+		return "ip:" + m.clientIP(r)
+	}
+}
+
+func (m *RateLimitMiddleware) clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); m.trustForwardedFor && forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+
+		return strings.TrimSpace(hops[len(hops)-1])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func (m *RateLimitMiddleware) writeError(w http.ResponseWriter) {
+	encoded, _ := jsoniter.ConfigFastest.Marshal(handler.NewApiError("rate limit exceeded", handler.LevelUser))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	_, err := w.Write(encoded)
+	if err != nil {
+		m.log.Error("failed to write response", zap.Error(err))
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sladonia/news-svc/internal/handler"
+	"go.uber.org/zap"
+)
+
+func NewRecovery(log *zap.Logger, registerer prometheus.Registerer) *RecoveryMiddleware {
+	m := &RecoveryMiddleware{
+		log: log,
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "news",
+			Subsystem: "http",
+			Name:      "panics_total",
+			Help:      "Number of panics recovered in the handlers by route name.",
+		}, []string{"route"}),
+	}
+
+	registerer.MustRegister(m.panics)
+
+	return m
+}
+
+// RecoveryMiddleware recovers the panics of the handlers, logs them with the stack trace and responds
+// with 500 ApiError unless the handler already started the response
+type RecoveryMiddleware struct {
+	log    *zap.Logger
+	panics *prometheus.CounterVec
+}
+
+func (m *RecoveryMiddleware) Register(r *mux.Router) {
+	r.Use(m.recover)
+}
+
+func (m *RecoveryMiddleware) recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := newResponseRecorder(w)
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// the server aborts the response silently on this one
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			route := routeName(r)
+			m.panics.WithLabelValues(route).Inc()
+
+			requestLogger(r, m.log).Error(
+				"handler panicked",
+				zap.String("route", route),
+				zap.String("panic", fmt.Sprint(recovered)),
+				zap.String("stack", string(debug.Stack())),
+			)
+
+			if recorder.wroteHeader {
+				return
+			}
+
+			m.writeError(w)
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+func (m *RecoveryMiddleware) writeError(w http.ResponseWriter) {
+	encoded, _ := jsoniter.ConfigFastest.Marshal(handler.NewApiError("internal server error", handler.LevelSystem))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	_, err := w.Write(encoded)
+	if err != nil {
+		m.log.Error("failed to write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/sladonia/news-svc/internal/handler/middlewares"
+
+func NewTracing(serviceName string) *TracingMiddleware {
+	return &TracingMiddleware{serviceName: serviceName}
+}
+
+// TracingMiddleware starts the server span named after the matched route. The span continues the trace
+// passed in the traceparent header. Global tracer provider and propagator are used.
+type TracingMiddleware struct {
+	serviceName string
+}
+
+func (m *TracingMiddleware) Register(r *mux.Router) {
+	r.Use(m.trace)
+}
+
+func (m *TracingMiddleware) trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		var route string
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(
+			ctx,
+			routeName(r),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(m.serviceName, route, r)...),
+		)
+		defer span.End()
+
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(recorder.status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(recorder.status, trace.SpanKindServer))
+	})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// format is the representation of the list negotiated by the Accept header
+type format string
+
+const (
+	formatJSON    format = "application/json"
+	formatNDJSON  format = "application/x-ndjson"
+	formatCSV     format = "text/csv"
+	formatMsgPack format = "application/msgpack"
+)
+
+// formats are listed in the order of preference, which breaks ties between equally acceptable ones
+var formats = []format{formatJSON, formatNDJSON, formatCSV, formatMsgPack}
+
+// mediaTypes maps the accepted media types and their aliases to the formats
+var mediaTypes = map[string]format{
+	"application/json":        formatJSON,
+	"application/x-ndjson":    formatNDJSON,
+	"application/ndjson":      formatNDJSON,
+	"application/jsonl":       formatNDJSON,
+	"text/csv":                formatCSV,
+	"application/msgpack":     formatMsgPack,
+	"application/x-msgpack":   formatMsgPack,
+	"application/vnd.msgpack": formatMsgPack,
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// negotiateFormat picks the most acceptable format for the Accept header, JSON is picked if the header is absent.
+// False is returned if none of the formats is acceptable.
+func negotiateFormat(accept string) (format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+
+	ranges := parseAccept(accept)
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, mr := range ranges {
+		if mr.q == 0 {
+			break
+		}
+
+		if f, ok := mediaTypes[mr.mediaType]; ok && acceptable(ranges, f) {
+			return f, true
+		}
+
+		for _, f := range formats {
+			if matches(mr.mediaType, f) && acceptable(ranges, f) {
+				return f, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// acceptable reports whether the format isn't excluded with zero quality by the more specific range
+func acceptable(ranges []mediaRange, f format) bool {
+	for _, mr := range ranges {
+		if mr.q == 0 && mediaTypes[mr.mediaType] == f {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches reports whether the wildcard media range matches the format
+func matches(mediaType string, f format) bool {
+	if mediaType == "*/*" {
+		return true
+	}
+
+	return strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(string(f), strings.TrimSuffix(mediaType, "*"))
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, item := range strings.Split(accept, ",") {
+		params := strings.Split(item, ";")
+
+		mr := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if mr.mediaType == "" {
+			continue
+		}
+
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(kv[1], 64)
+			if err == nil && q >= 0 && q <= 1 {
+				mr.q = q
+			}
+		}
+
+		ranges = append(ranges, mr)
+	}
+
+	return ranges
+}
@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/openapi"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+const apiVersion = "1.0.0"
+
+// writeSecurity is required by the routes modifying the resources, the others accept credentials optionally
+var writeSecurity = []openapi.SecurityRequirement{{"apiKey": {}}, {"bearerAuth": {}}}
+
+// responses are the successful responses of the operation by status
+type responses map[int]openapi.Response
+
+// OpenAPI returns the document describing the routes of the Register. Operation IDs are the route names,
+// so that the document can be matched with the routes.
+func (h *Handler) OpenAPI() *openapi.Document {
+	return h.openAPI
+}
+
+// newOpenAPI describes the routes as OpenAPI 3.1 document
+func newOpenAPI(serviceName string) *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       serviceName,
+		Description: "News posts and their authors",
+		Version:     apiVersion,
+	})
+
+	doc.Security = append([]openapi.SecurityRequirement{{}}, writeSecurity...)
+	doc.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+		"apiKey": {Type: "apiKey", In: "header", Name: auth.APIKeyHeader},
+		"bearerAuth": {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		},
+	}
+
+	g := openapi.NewGenerator(doc).
+		Enum(post.StatusDraft, string(post.StatusDraft), string(post.StatusScheduled),
+			string(post.StatusPublished), string(post.StatusArchived)).
+		Enum(LevelUser, string(LevelUser), string(LevelSystem))
+
+	s := &spec{doc: doc, apiError: g.Response(ApiError{})}
+
+	s.identity(g)
+	s.posts(g)
+	s.revisions(g)
+	s.authors(g)
+
+	return doc
+}
+
+type spec struct {
+	doc      *openapi.Document
+	apiError *openapi.Schema
+}
+
+func (s *spec) identity(g *openapi.Generator) {
+	s.add("GET", "/", &openapi.Operation{ID: "identity", Summary: "Service identity"},
+		responses{http.StatusOK: jsonResponse("Service identity", g.Response(identityResponse{}))})
+
+	s.add("GET", "/openapi.json", &openapi.Operation{ID: "openapi", Summary: "This document"},
+		responses{http.StatusOK: jsonResponse("OpenAPI document", &openapi.Schema{Type: openapi.Types{"object"}})})
+}
+
+func (s *spec) posts(g *openapi.Generator) {
+	postSchema := g.Response(post.Post{})
+	created := jsonResponse("Post", postSchema)
+	created.Headers = etagHeader
+
+	s.add("POST", "/posts", &openapi.Operation{
+		ID:          "createPost",
+		Summary:     "Create a post, a draft unless the status is set",
+		Tags:        []string{"posts"},
+		RequestBody: jsonBody(g.Request(newPostRequest{})),
+		Security:    writeSecurity,
+	}, responses{http.StatusCreated: created},
+		http.StatusBadRequest, http.StatusConflict)
+
+	postList := g.NamedResponse("PostList", listResponse{Items: postTable{}})
+
+	s.add("GET", "/posts", &openapi.Operation{
+		ID:         "findPosts",
+		Summary:    "Find posts, only published ones unless the status is set",
+		Tags:       []string{"posts"},
+		Parameters: postFilterParams(),
+	}, responses{http.StatusOK: listOK(postList, postSchema)},
+		http.StatusBadRequest, http.StatusForbidden, http.StatusNotAcceptable)
+
+	s.add("GET", "/posts/trash", &openapi.Operation{
+		ID:         "trashPosts",
+		Summary:    "Find soft deleted posts, editors only",
+		Tags:       []string{"posts"},
+		Parameters: postFilterParams(),
+	}, responses{http.StatusOK: listOK(postList, postSchema)},
+		http.StatusBadRequest, http.StatusForbidden, http.StatusNotAcceptable)
+
+	s.add("GET", "/posts/{id}", &openapi.Operation{
+		ID:      "postByID",
+		Summary: "Get a post",
+		Tags:    []string{"posts"},
+		Parameters: []openapi.Parameter{
+			idParam,
+			header("If-None-Match", "Entity tags of the cached versions"),
+		},
+	}, responses{
+		http.StatusOK:          created,
+		http.StatusNotModified: {Description: "Cached version is current", Headers: etagHeader},
+	}, http.StatusNotFound)
+
+	s.add("PUT", "/posts/{id}", &openapi.Operation{
+		ID:          "replacePost",
+		Summary:     "Create or replace a post",
+		Tags:        []string{"posts"},
+		Parameters:  []openapi.Parameter{idParam, ifMatchParam},
+		RequestBody: jsonBody(g.Request(createPostRequest{})),
+		Security:    writeSecurity,
+	}, responses{http.StatusOK: {Description: "Post is saved"}},
+		http.StatusBadRequest, http.StatusConflict, http.StatusPreconditionFailed)
+
+	s.add("PATCH", "/posts/{id}", &openapi.Operation{
+		ID:         "patchPost",
+		Summary:    "Patch a post with JSON Merge Patch or JSON Patch",
+		Tags:       []string{"posts"},
+		Parameters: []openapi.Parameter{idParam, ifMatchParam},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				mediaTypeMergePatch: {Schema: &openapi.Schema{Type: openapi.Types{"object"}}},
+				mediaTypeJSONPatch:  {Schema: openapi.Array(g.Request(jsonPatchOperation{}))},
+			},
+		},
+		Security: writeSecurity,
+	}, responses{http.StatusOK: created},
+		http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+		http.StatusUnsupportedMediaType)
+
+	s.add("DELETE", "/posts/{id}", &openapi.Operation{
+		ID:      "deletePost",
+		Summary: "Move a post to the trash or delete it permanently",
+		Tags:    []string{"posts"},
+		Parameters: []openapi.Parameter{
+			idParam,
+			ifMatchParam,
+			query("permanent", "Delete the post permanently", openapi.Boolean()),
+		},
+		Security: writeSecurity,
+	}, responses{http.StatusNoContent: {Description: "Post is deleted"}},
+		http.StatusNotFound, http.StatusPreconditionFailed)
+
+	s.add("POST", "/posts/{id}/restore", &openapi.Operation{
+		ID:         "restorePost",
+		Summary:    "Restore a post from the trash",
+		Tags:       []string{"posts"},
+		Parameters: []openapi.Parameter{idParam},
+		Security:   writeSecurity,
+	}, responses{http.StatusOK: created},
+		http.StatusNotFound)
+
+	s.add("PUT", "/posts/{id}/status", &openapi.Operation{
+		ID:          "setPostStatus",
+		Summary:     "Publish, schedule, unpublish or archive a post",
+		Tags:        []string{"posts"},
+		Parameters:  []openapi.Parameter{idParam, ifMatchParam},
+		RequestBody: jsonBody(g.Request(setPostStatusRequest{})),
+		Security:    writeSecurity,
+	}, responses{http.StatusOK: created},
+		http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed)
+
+	counts := jsonResponse("Names with the number of published posts", g.Response(countsResponse{}))
+
+	s.add("GET", "/tags", &openapi.Operation{ID: "tags", Summary: "Count posts by tag", Tags: []string{"posts"}},
+		responses{http.StatusOK: counts})
+
+	s.add("GET", "/categories", &openapi.Operation{
+		ID:      "categories",
+		Summary: "Count posts by category",
+		Tags:    []string{"posts"},
+	}, responses{http.StatusOK: counts})
+}
+
+func (s *spec) revisions(g *openapi.Generator) {
+	revisionNumber := openapi.Parameter{
+		Name:     "n",
+		In:       "path",
+		Required: true,
+		Schema:   openapi.Integer().WithMinimum(0),
+	}
+
+	s.add("GET", "/posts/{id}/revisions", &openapi.Operation{
+		ID:         "postRevisions",
+		Summary:    "List revisions of a post, readable by its author and editors",
+		Tags:       []string{"revisions"},
+		Parameters: []openapi.Parameter{idParam},
+	}, responses{http.StatusOK: jsonResponse("Revisions", g.Response(postRevisionsResponse{}))},
+		http.StatusForbidden, http.StatusNotFound)
+
+	from := query("from", "Revision number", openapi.Integer())
+	from.Required = true
+	to := query("to", "Revision number", openapi.Integer())
+	to.Required = true
+
+	s.add("GET", "/posts/{id}/revisions/diff", &openapi.Operation{
+		ID:         "diffRevisions",
+		Summary:    "Diff two revisions of a post",
+		Tags:       []string{"revisions"},
+		Parameters: []openapi.Parameter{idParam, from, to},
+	}, responses{http.StatusOK: jsonResponse("Changed fields", g.Response(diffRevisionsResponse{}))},
+		http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound)
+
+	s.add("GET", "/posts/{id}/revisions/{n}", &openapi.Operation{
+		ID:         "postRevision",
+		Summary:    "Get a revision of a post",
+		Tags:       []string{"revisions"},
+		Parameters: []openapi.Parameter{idParam, revisionNumber},
+	}, responses{http.StatusOK: jsonResponse("Revision", g.Response(post.Revision{}))},
+		http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound)
+
+	reverted := jsonResponse("Post", g.Response(post.Post{}))
+	reverted.Headers = etagHeader
+
+	s.add("POST", "/posts/{id}/revisions/{n}/revert", &openapi.Operation{
+		ID:         "revertPost",
+		Summary:    "Revert a post to the revision",
+		Tags:       []string{"revisions"},
+		Parameters: []openapi.Parameter{idParam, revisionNumber, ifMatchParam},
+		Security:   writeSecurity,
+	}, responses{http.StatusOK: reverted},
+		http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed)
+}
+
+func (s *spec) authors(g *openapi.Generator) {
+	authorSchema := g.Response(author.Author{})
+
+	s.add("POST", "/authors", &openapi.Operation{
+		ID:          "createAuthor",
+		Summary:     "Create the author profile of the caller",
+		Tags:        []string{"authors"},
+		RequestBody: jsonBody(g.Request(authorRequest{})),
+		Security:    writeSecurity,
+	}, responses{http.StatusCreated: jsonResponse("Author", authorSchema)},
+		http.StatusBadRequest, http.StatusConflict)
+
+	s.add("GET", "/authors", &openapi.Operation{
+		ID:         "listAuthors",
+		Summary:    "List authors, emails are visible to the author and editors",
+		Tags:       []string{"authors"},
+		Parameters: pageParams(),
+	}, responses{http.StatusOK: listOK(g.NamedResponse("AuthorList", listResponse{Items: authorTable{}}), authorSchema)},
+		http.StatusBadRequest, http.StatusNotAcceptable)
+
+	s.add("GET", "/authors/{id}", &openapi.Operation{
+		ID:         "authorByID",
+		Summary:    "Get an author",
+		Tags:       []string{"authors"},
+		Parameters: []openapi.Parameter{idParam},
+	}, responses{http.StatusOK: jsonResponse("Author", authorSchema)},
+		http.StatusNotFound)
+
+	s.add("PUT", "/authors/{id}", &openapi.Operation{
+		ID:          "updateAuthor",
+		Summary:     "Update an author, allowed to the author and editors",
+		Tags:        []string{"authors"},
+		Parameters:  []openapi.Parameter{idParam},
+		RequestBody: jsonBody(g.Request(authorRequest{})),
+		Security:    writeSecurity,
+	}, responses{http.StatusOK: jsonResponse("Author", authorSchema)},
+		http.StatusBadRequest, http.StatusNotFound, http.StatusConflict)
+
+	s.add("DELETE", "/authors/{id}", &openapi.Operation{
+		ID:         "deleteAuthor",
+		Summary:    "Delete an author, allowed to the author and editors",
+		Tags:       []string{"authors"},
+		Parameters: []openapi.Parameter{idParam},
+		Security:   writeSecurity,
+	}, responses{http.StatusNoContent: {Description: "Author is deleted"}},
+		http.StatusNotFound)
+}
+
+// add adds the operation with the successful responses and the errors. Errors of the middlewares
+// and the server errors are added to every operation, forbidden is added to the ones requiring the scope
+// and has to be listed for the reads restricted by the post service.
+func (s *spec) add(method, path string, operation *openapi.Operation, ok responses, errors ...int) {
+	operation.Responses = make(map[string]openapi.Response)
+
+	for status, response := range ok {
+		operation.Responses[strconv.Itoa(status)] = response
+	}
+
+	errors = append(errors, http.StatusUnauthorized, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusGatewayTimeout)
+
+	if operation.Security != nil {
+		errors = append(errors, http.StatusForbidden)
+	}
+
+	for _, status := range errors {
+		operation.Responses[strconv.Itoa(status)] = jsonResponse(http.StatusText(status), s.apiError)
+	}
+
+	s.doc.Add(method, path, operation)
+}
+
+var (
+	idParam = openapi.Parameter{Name: "id", In: "path", Required: true, Schema: openapi.String()}
+
+	ifMatchParam = header("If-Match", "Entity tag of the expected version, * requires the post to exist")
+
+	etagHeader = map[string]openapi.Header{
+		"ETag": {Description: "Version of the post", Schema: openapi.String()},
+	}
+)
+
+func pageParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		query("limit", "Page size, DEFAULT_NEWS_LIMIT if zero, at most MAX_NEWS_LIMIT", openapi.Integer().WithMinimum(0)),
+		query("offset", "Number of items to skip", openapi.Integer().WithMinimum(0)),
+		query("count", "Count the total", openapi.Boolean()),
+	}
+}
+
+func postFilterParams() []openapi.Parameter {
+	statuses := openapi.Array(openapi.Ref("Status"))
+	tagsMatch := openapi.String().WithEnum("any", "all")
+
+	return append(pageParams(),
+		query("cursor", "Next page cursor, can't be combined with q", openapi.String()),
+		query("from", "Created at or after", openapi.String().WithFormat("date-time")),
+		query("to", "Created before", openapi.String().WithFormat("date-time")),
+		arrayQuery("status", "Statuses, published by default", statuses),
+		arrayQuery("tags", "Tags", openapi.Array(openapi.String())),
+		query("tags_match", "Whether posts have any or all the tags", tagsMatch),
+		query("category", "Category", openapi.String()),
+		query("author", "Author ID", openapi.String()),
+		query("q", "Full text search query, results are ordered by relevance", openapi.String()),
+	)
+}
+
+func query(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// arrayQuery is the query parameter with comma separated items
+func arrayQuery(name, description string, schema *openapi.Schema) openapi.Parameter {
+	p := query(name, description, schema)
+	p.Style = "form"
+	p.Explode = new(bool)
+
+	return p
+}
+
+func header(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "header", Description: description, Schema: openapi.String()}
+}
+
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: openapi.JSON(schema)}
+}
+
+func jsonResponse(description string, schema *openapi.Schema) openapi.Response {
+	return openapi.Response{Description: description, Content: openapi.JSON(schema)}
+}
+
+// listOK is the list negotiated by the Accept header. NDJSON lines are the items, CSV and MessagePack
+// are described by their media types only.
+func listOK(list, item *openapi.Schema) openapi.Response {
+	return openapi.Response{
+		Description: "Page of the list",
+		Headers: map[string]openapi.Header{
+			"Link":          {Description: "RFC 8288 links of the pages", Schema: openapi.String()},
+			"X-Total-Count": {Description: "Total, if counted", Schema: openapi.Integer()},
+		},
+		Content: map[string]openapi.MediaType{
+			string(formatJSON):    {Schema: list},
+			string(formatNDJSON):  {Schema: item},
+			string(formatCSV):     {Schema: openapi.String()},
+			string(formatMsgPack): {},
+		},
+	}
+}
+
+func (h *Handler) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(h.openAPIDocument)
+	if err != nil {
+		h.logFor(r).Error("failed to write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+var strictJSON = jsoniter.Config{DisallowUnknownFields: true}.Froze()
+
+func (h *Handler) patchPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mediaTypeMergePatch && mediaType != mediaTypeJSONPatch {
+		w.Header().Set("Accept-Patch", mediaTypeMergePatch+", "+mediaTypeJSONPatch)
+		h.writeApiError(w, http.StatusUnsupportedMediaType, LevelUser, "unsupported patch content type")
+
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		h.logFor(r).Info("precondition failed", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	current, err := h.postService.GetPost(r.Context(), id)
+	if err != nil {
+		h.logFor(r).Info("failed to get post", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	// the document is decoded from JSON, so that its values compare equal with the ones of the patch
+	doc, err := h.patchDocument(current)
+	if err != nil {
+		h.logFor(r).Error("failed to build patch document", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	if mediaType == mediaTypeMergePatch {
+		err = h.mergePatch(r, doc)
+	} else {
+		err = h.jsonPatch(r, doc)
+	}
+
+	if errors.Is(err, errPatchTestFailed) {
+		h.logFor(r).Info("patch test failed", zap.Error(err))
+		h.writeApiError(w, http.StatusConflict, LevelUser, err.Error())
+
+		return
+	}
+
+	if err != nil {
+		h.logFor(r).Info("failed to apply patch", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "failed to apply patch: "+err.Error())
+
+		return
+	}
+
+	request, err := h.patchedRequest(doc)
+	if err != nil {
+		h.logFor(r).Info("failed to unmarshal patched post", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "patched post is invalid")
+
+		return
+	}
+
+	err = h.validator.StructCtx(r.Context(), request)
+	if err != nil {
+		h.logFor(r).Info("validation error", zap.String("error", err.Error()))
+		h.writeValidationErr(w, err)
+
+		return
+	}
+
+	// the patch is applied to the current state, so it must not overwrite a concurrent update
+	if version == 0 {
+		version = current.Version
+	}
+
+	var patch post.Patch
+
+	if request.Title != current.Title {
+		patch.Title = &request.Title
+	}
+
+	if request.Content != current.Content {
+		patch.Content = &request.Content
+	}
+
+	if !reflect.DeepEqual(post.NormalizeTags(request.Tags), current.Tags) {
+		patch.Tags = &request.Tags
+	}
+
+	if post.NormalizeCategory(request.Category) != current.Category {
+		patch.Category = &request.Category
+	}
+
+	p, err := h.postService.PatchPost(r.Context(), id, patch, version)
+	if err != nil {
+		h.logFor(r).Error("failed to patch post", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(p.Version))
+	h.writeResponse(w, http.StatusOK, p)
+}
+
+func (h *Handler) mergePatch(r *http.Request, doc map[string]interface{}) error {
+	var patch map[string]interface{}
+
+	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		return err
+	}
+
+	applyMergePatch(doc, patch)
+
+	return nil
+}
+
+func (h *Handler) jsonPatch(r *http.Request, doc map[string]interface{}) error {
+	var operations []jsonPatchOperation
+
+	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&operations)
+	if err != nil {
+		return err
+	}
+
+	return applyJSONPatch(doc, operations)
+}
+
+// patchDocument represents the editable fields of the post as the patched json document. Tags of an untagged
+// post are an empty array, so that they can be appended to.
+func (h *Handler) patchDocument(p post.Post) (map[string]interface{}, error) {
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	encoded, err := jsoniter.ConfigFastest.Marshal(createPostRequest{
+		Title:    p.Title,
+		Content:  p.Content,
+		Tags:     tags,
+		Category: p.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+
+	err = jsoniter.ConfigFastest.Unmarshal(encoded, &doc)
+
+	return doc, err
+}
+
+// patchedRequest converts the patched document back to the request, members unknown to it are rejected
+func (h *Handler) patchedRequest(doc map[string]interface{}) (createPostRequest, error) {
+	var request createPostRequest
+
+	encoded, err := jsoniter.ConfigFastest.Marshal(doc)
+	if err != nil {
+		return request, err
+	}
+
+	err = strictJSON.Unmarshal(encoded, &request)
+
+	return request, err
+}
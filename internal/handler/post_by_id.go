@@ -11,13 +11,21 @@ func (h *Handler) postByID(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	p, err := h.postService.GetPost(id)
+	p, err := h.postService.GetPost(r.Context(), id)
 	if err != nil {
-		h.log.Info("failed to get post", zap.Error(err))
+		h.logFor(r).Info("failed to get post", zap.Error(err))
 		h.writeError(w, err, err.Error())
 
 		return
 	}
 
+	w.Header().Set("ETag", formatETag(p.Version))
+
+	if ifNoneMatch(r, p.Version) {
+		h.writeResponse(w, http.StatusNotModified, nil)
+
+		return
+	}
+
 	h.writeResponse(w, http.StatusOK, p)
 }
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+type setPostStatusRequest struct {
+	Status    post.Status `json:"status" validate:"required,oneof=draft scheduled published archived"`
+	PublishAt *time.Time  `json:"publish_at"`
+}
+
+func (h *Handler) setPostStatus(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var request setPostStatusRequest
+
+	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		h.logFor(r).Error("failed to unmarshal request", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "failed to unmarshal json")
+
+		return
+	}
+
+	err = h.validator.StructCtx(r.Context(), request)
+	if err != nil {
+		h.logFor(r).Info("validation error", zap.String("error", err.Error()))
+		h.writeValidationErr(w, err)
+
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		h.logFor(r).Info("precondition failed", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	p, err := h.postService.SetPostStatus(r.Context(), id, request.Status, request.PublishAt, version)
+	if err != nil {
+		h.logFor(r).Info("failed to set post status", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(p.Version))
+	h.writeResponse(w, http.StatusOK, p)
+}
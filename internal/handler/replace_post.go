@@ -16,7 +16,7 @@ func (h *Handler) replacePost(w http.ResponseWriter, r *http.Request) {
 
 	err := jsoniter.ConfigFastest.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		h.log.Error("failed to unmarshal request", zap.Error(err))
+		h.logFor(r).Error("failed to unmarshal request", zap.Error(err))
 		h.writeApiError(w, http.StatusBadRequest, LevelUser, "failed to unmarshal json")
 
 		return
@@ -24,15 +24,27 @@ func (h *Handler) replacePost(w http.ResponseWriter, r *http.Request) {
 
 	err = h.validator.StructCtx(r.Context(), request)
 	if err != nil {
-		h.log.Info("validation error", zap.String("error", err.Error()))
+		h.logFor(r).Info("validation error", zap.String("error", err.Error()))
 		h.writeValidationErr(w, err)
 
 		return
 	}
 
-	err = h.postService.UpsertPost(id, request.Title, request.Content)
+	version, err := h.expectedVersion(r, id)
 	if err != nil {
-		h.log.Error("failed to upsert post", zap.Error(err))
+		h.logFor(r).Info("precondition failed", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	p := request.post()
+	p.ID = id
+	p.Version = version
+
+	err = h.postService.UpsertPost(r.Context(), p)
+	if err != nil {
+		h.logFor(r).Error("failed to upsert post", zap.Error(err))
 		h.writeError(w, err, err.Error())
 
 		return
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) restorePost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	p, err := h.postService.RestorePost(r.Context(), id)
+	if err != nil {
+		h.logFor(r).Info("failed to restore post", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(p.Version))
+	h.writeResponse(w, http.StatusOK, p)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+type postRevisionsResponse struct {
+	Items []post.Revision `json:"items"`
+}
+
+type diffRevisionsResponse struct {
+	From    int64              `json:"from"`
+	To      int64              `json:"to"`
+	Changes []post.FieldChange `json:"changes"`
+}
+
+func (h *Handler) postRevisions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	revisions, err := h.postService.PostRevisions(r.Context(), id)
+	if err != nil {
+		h.logFor(r).Info("failed to get post revisions", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, postRevisionsResponse{Items: revisions})
+}
+
+func (h *Handler) postRevision(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	number, err := strconv.ParseInt(params["n"], 10, 64)
+	if err != nil {
+		h.logFor(r).Info("atoi error. revision number", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "revision number should be integer")
+
+		return
+	}
+
+	revision, err := h.postService.PostRevision(r.Context(), id, number)
+	if err != nil {
+		h.logFor(r).Info("failed to get post revision", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, revision)
+}
+
+func (h *Handler) diffRevisions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	from, err := strconv.ParseInt(r.FormValue("from"), 10, 64)
+	if err != nil {
+		h.logFor(r).Info("atoi error. from", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "from query parameter should be revision number")
+
+		return
+	}
+
+	to, err := strconv.ParseInt(r.FormValue("to"), 10, 64)
+	if err != nil {
+		h.logFor(r).Info("atoi error. to", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "to query parameter should be revision number")
+
+		return
+	}
+
+	changes, err := h.postService.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		h.logFor(r).Info("failed to diff post revisions", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, diffRevisionsResponse{From: from, To: to, Changes: changes})
+}
+
+func (h *Handler) revertPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	number, err := strconv.ParseInt(params["n"], 10, 64)
+	if err != nil {
+		h.logFor(r).Info("atoi error. revision number", zap.Error(err))
+		h.writeApiError(w, http.StatusBadRequest, LevelUser, "revision number should be integer")
+
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		h.logFor(r).Info("precondition failed", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	p, err := h.postService.RevertPost(r.Context(), id, number, version)
+	if err != nil {
+		h.logFor(r).Error("failed to revert post", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(p.Version))
+	h.writeResponse(w, http.StatusOK, p)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sladonia/news-svc/internal/post"
+	"go.uber.org/zap"
+)
+
+type countsResponse struct {
+	Items []post.Count `json:"items"`
+}
+
+func (h *Handler) tags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.postService.Tags(r.Context())
+	if err != nil {
+		h.logFor(r).Error("failed to count tags", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, countsResponse{Items: tags})
+}
+
+func (h *Handler) categories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.postService.Categories(r.Context())
+	if err != nil {
+		h.logFor(r).Error("failed to count categories", zap.Error(err))
+		h.writeError(w, err, err.Error())
+
+		return
+	}
+
+	h.writeResponse(w, http.StatusOK, countsResponse{Items: categories})
+}
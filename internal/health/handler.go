@@ -0,0 +1,60 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+func NewHandler(log *zap.Logger, checker *Checker) *Handler {
+	return &Handler{log: log, checker: checker}
+}
+
+// Handler serves the liveness probe at /healthz and the readiness probe at /readyz,
+// which responds with 503 unless the service is ready
+type Handler struct {
+	log     *zap.Logger
+	checker *Checker
+}
+
+func (h *Handler) Register(r *mux.Router) {
+	r.HandleFunc("/healthz", h.healthz).Name("healthz").Methods("GET")
+	r.HandleFunc("/readyz", h.readyz).Name("readyz").Methods("GET")
+}
+
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	h.writeResponse(w, http.StatusOK, struct {
+		Status string `json:"status"`
+	}{Status: StatusOK})
+}
+
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Ready(r.Context())
+
+	status := http.StatusOK
+	if !report.IsReady() {
+		status = http.StatusServiceUnavailable
+	}
+
+	h.writeResponse(w, status, report)
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, status int, data interface{}) {
+	encoded, err := jsoniter.ConfigFastest.Marshal(data)
+	if err != nil {
+		h.log.Error("failed to marshal response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(encoded)
+	if err != nil {
+		h.log.Error("failed to write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFailed       = "failed"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc verifies the dependency is available. Returned details are reported along with
+// the result of the check, e.g. the schema version.
+type CheckFunc func(ctx context.Context) (details interface{}, err error)
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type CheckResult struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	LatencyMS float64     `json:"latency_ms"`
+	Details   interface{} `json:"details,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func (r Report) IsReady() bool {
+	return r.Status == StatusReady
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Checker runs the dependency checks concurrently, each one limited by the timeout.
+// The service is ready when all the checks pass and it isn't shutting down.
+type Checker struct {
+	timeout      time.Duration
+	checks       []check
+	shuttingDown int32
+}
+
+// Add is not safe to call concurrently with Ready
+func (c *Checker) Add(name string, fn CheckFunc) *Checker {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+
+	return c
+}
+
+// ShutDown makes the service not ready for good
+func (c *Checker) ShutDown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{
+		Status: StatusReady,
+		Checks: make([]CheckResult, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, ch := range c.checks {
+		wg.Add(1)
+
+		go func(i int, ch check) {
+			defer wg.Done()
+
+			report.Checks[i] = c.run(ctx, ch)
+		}(i, ch)
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusNotReady
+		}
+	}
+
+	if atomic.LoadInt32(&c.shuttingDown) == 1 {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, ch check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := ch.fn(ctx)
+
+	result := CheckResult{
+		Name:      ch.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
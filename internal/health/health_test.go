@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func ok(ctx context.Context) (interface{}, error) {
+	return map[string]int{"version": 9}, nil
+}
+
+func failing(ctx context.Context) (interface{}, error) {
+	return nil, errors.New("connection refused")
+}
+
+func slow(ctx context.Context) (interface{}, error) {
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func TestChecker(t *testing.T) {
+	t.Run("ready", func(t *testing.T) {
+		report := NewChecker(time.Second).Add("postgres", ok).Ready(context.Background())
+
+		assert.True(t, report.IsReady())
+		require.Len(t, report.Checks, 1)
+		assert.Equal(t, "postgres", report.Checks[0].Name)
+		assert.Equal(t, StatusOK, report.Checks[0].Status)
+		assert.Equal(t, map[string]int{"version": 9}, report.Checks[0].Details)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		report := NewChecker(time.Second).Add("postgres", ok).Add("cache", failing).Ready(context.Background())
+
+		assert.Equal(t, StatusNotReady, report.Status)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, StatusOK, report.Checks[0].Status)
+		assert.Equal(t, StatusFailed, report.Checks[1].Status)
+		assert.Equal(t, "connection refused", report.Checks[1].Error)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		report := NewChecker(10*time.Millisecond).Add("postgres", slow).Ready(context.Background())
+
+		assert.Equal(t, StatusNotReady, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+		assert.GreaterOrEqual(t, report.Checks[0].LatencyMS, float64(10))
+	})
+
+	t.Run("shutting_down", func(t *testing.T) {
+		checker := NewChecker(time.Second).Add("postgres", ok)
+		checker.ShutDown()
+
+		assert.Equal(t, StatusShuttingDown, checker.Ready(context.Background()).Status)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	checker := NewChecker(time.Second).Add("postgres", ok)
+
+	r := mux.NewRouter()
+	NewHandler(zap.NewNop(), checker).Register(r)
+
+	serve := func(path string) (*httptest.ResponseRecorder, Report) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		var report Report
+
+		err := jsoniter.ConfigFastest.Unmarshal(w.Body.Bytes(), &report)
+		require.NoError(t, err)
+
+		return w, report
+	}
+
+	w, report := serve("/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, StatusOK, report.Status)
+
+	w, report = serve("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, StatusReady, report.Status)
+	assert.Len(t, report.Checks, 1)
+
+	checker.ShutDown()
+
+	w, report = serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, StatusShuttingDown, report.Status)
+
+	w, _ = serve("/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerKey struct{}
+
+// WithContext returns the context carrying the request scoped logger
+func WithContext(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext reports false if the context carries no logger
+func FromContext(ctx context.Context) (*zap.Logger, bool) {
+	log, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+
+	return log, ok
+}
@@ -0,0 +1,19 @@
+package logger
+
+const maxRequestIDLength = 128
+
+// IsValidRequestID accepts the ids of printable ascii characters up to 128 bytes,
+// so the ids passed by the clients can't break the log lines
+func IsValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
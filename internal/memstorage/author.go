@@ -0,0 +1,159 @@
+package memstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// NewAuthorStorage returns the authors of the posts storage created by New. They behave like the tables
+// of the database: the first post of the author creates its profile and the posts of the removed author
+// become anonymous.
+func NewAuthorStorage(posts post.Storage) author.Storage {
+	s, ok := posts.(*storage)
+	if !ok {
+		panic("memstorage: posts storage isn't created by New")
+	}
+
+	return s.authors
+}
+
+type authorStorage struct {
+	mu      sync.RWMutex
+	authors map[string]author.Author
+	posts   *storage
+}
+
+func (s *authorStorage) ByID(ctx context.Context, id string) (author.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return author.Author{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.authors[id]
+	if !ok {
+		return author.Author{}, author.ErrNotFound
+	}
+
+	return a, nil
+}
+
+func (s *authorStorage) List(ctx context.Context, limit, offset uint) ([]author.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := make([]author.Author, 0, len(s.authors))
+
+	for _, a := range s.authors {
+		authors = append(authors, a)
+	}
+
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Name == authors[j].Name {
+			return authors[i].ID < authors[j].ID
+		}
+
+		return authors[i].Name < authors[j].Name
+	})
+
+	if offset >= uint(len(authors)) {
+		return []author.Author{}, nil
+	}
+
+	authors = authors[offset:]
+
+	if limit > 0 && limit < uint(len(authors)) {
+		authors = authors[:limit]
+	}
+
+	return authors, nil
+}
+
+func (s *authorStorage) Count(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.authors)), nil
+}
+
+func (s *authorStorage) Insert(ctx context.Context, a author.Author) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.authors[a.ID]; ok {
+		return author.ErrAlreadyExists
+	}
+
+	s.authors[a.ID] = a
+
+	return nil
+}
+
+func (s *authorStorage) Update(ctx context.Context, updated author.Author) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.authors[updated.ID]
+	if !ok {
+		return author.ErrNotFound
+	}
+
+	a.Name = updated.Name
+	a.Email = updated.Email
+	a.Bio = updated.Bio
+	a.UpdatedAt = time.Now().UTC().Round(time.Millisecond)
+
+	s.authors[a.ID] = a
+
+	return nil
+}
+
+func (s *authorStorage) Remove(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.authors, id)
+	s.mu.Unlock()
+
+	s.posts.clearAuthor(id)
+
+	return nil
+}
+
+// ensure creates the profile of the principal writing the first post named after the principal
+func (s *authorStorage) ensure(id string) {
+	if id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.authors[id]; !ok {
+		s.authors[id] = author.NewAuthor(id, id, "", "")
+	}
+}
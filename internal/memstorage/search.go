@@ -0,0 +1,144 @@
+package memstorage
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// weights follow the ones assigned to title and content in the search_vector column
+const (
+	titleWeight   = 1.0
+	contentWeight = 0.4
+
+	snippetMaxWords = 35
+)
+
+// match approximates postgres websearch_to_tsquery matching: every query term has to be present
+// in title or content. Stemming and stop words are not supported.
+func match(p post.Post, query string) (post.Post, bool) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return p, false
+	}
+
+	titleWords := tokenize(p.Title)
+	contentWords := tokenize(p.Content)
+
+	var rank float64
+
+	for _, term := range terms {
+		titleHits := count(titleWords, term)
+		contentHits := count(contentWords, term)
+
+		if titleHits+contentHits == 0 {
+			return p, false
+		}
+
+		rank += titleWeight*float64(titleHits) + contentWeight*float64(contentHits)
+	}
+
+	p.Rank = rank
+	p.Snippet = highlight(p.Title+" "+p.Content, terms)
+
+	return p, true
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func count(words []string, term string) int {
+	var n int
+
+	for _, word := range words {
+		if word == term {
+			n++
+		}
+	}
+
+	return n
+}
+
+// highlight wraps the query terms in <b></b> tags the same way ts_headline does. The text is HTML escaped,
+// so that the tags are the only markup of the snippet.
+func highlight(text string, terms []string) string {
+	words := strings.Fields(text)
+	first := -1
+
+	for i, word := range words {
+		var highlighted bool
+
+		words[i], highlighted = highlightWord(word, terms)
+
+		if highlighted && first < 0 {
+			first = i
+		}
+	}
+
+	if len(words) > snippetMaxWords {
+		start := first
+		if start < 0 {
+			start = 0
+		}
+
+		if start+snippetMaxWords > len(words) {
+			start = len(words) - snippetMaxWords
+		}
+
+		words = words[start : start+snippetMaxWords]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// highlightWord splits the word by the separators the way tokenize does and wraps the parts matching
+// the terms, e.g. "art" in "state-of-the-art". It reports whether any part is wrapped.
+func highlightWord(word string, terms []string) (string, bool) {
+	var (
+		b           strings.Builder
+		highlighted bool
+	)
+
+	for word != "" {
+		end := strings.IndexFunc(word, isSeparator)
+		if end == 0 {
+			end = strings.IndexFunc(word, func(r rune) bool { return !isSeparator(r) })
+		}
+
+		if end < 0 {
+			end = len(word)
+		}
+
+		part := word[:end]
+		word = word[end:]
+
+		if !isSeparator([]rune(part)[0]) && contains(terms, strings.ToLower(part)) {
+			b.WriteString("<b>" + html.EscapeString(part) + "</b>")
+
+			highlighted = true
+
+			continue
+		}
+
+		b.WriteString(html.EscapeString(part))
+	}
+
+	return b.String(), highlighted
+}
+
+func contains(terms []string, word string) bool {
+	for _, term := range terms {
+		if term == word {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,579 @@
+package memstorage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// New returns the post storage along with the author storage of its own, see NewAuthorStorage
+func New() post.Storage {
+	s := &storage{
+		posts:     make(map[string]post.Post),
+		revisions: make(map[string][]post.Revision),
+	}
+	s.authors = &authorStorage{authors: make(map[string]author.Author), posts: s}
+
+	return s
+}
+
+type storage struct {
+	mu        sync.RWMutex
+	posts     map[string]post.Post
+	revisions map[string][]post.Revision
+	authors   *authorStorage
+}
+
+func (s *storage) ByID(ctx context.Context, id string) (post.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return post.Post{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return post.Post{}, post.ErrNotFound
+	}
+
+	return p, nil
+}
+
+func (s *storage) ByFilter(ctx context.Context, filter post.Filter) ([]post.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.filtered(filter)
+
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].Rank != posts[j].Rank {
+			return posts[i].Rank > posts[j].Rank
+		}
+
+		if posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].ID > posts[j].ID
+		}
+
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+
+	return paginate(posts, filter.Limit, filter.Offset), nil
+}
+
+func (s *storage) Count(ctx context.Context, filter post.Filter) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filter.After = nil
+
+	return int64(len(s.filtered(filter))), nil
+}
+
+// filtered returns the unordered posts matching the filter, ignoring limit and offset
+func (s *storage) filtered(filter post.Filter) []post.Post {
+	posts := make([]post.Post, 0, len(s.posts))
+
+	for _, p := range s.posts {
+		if filter.Deleted != (p.DeletedAt != nil) {
+			continue
+		}
+
+		if len(filter.Statuses) > 0 && !hasStatus(filter.Statuses, p.Status) {
+			continue
+		}
+
+		if filter.AuthorID != "" && p.AuthorID != filter.AuthorID {
+			continue
+		}
+
+		if filter.Category != "" && p.Category != filter.Category {
+			continue
+		}
+
+		if len(filter.Tags) > 0 && !hasTags(p.Tags, filter.Tags, filter.AllTags) {
+			continue
+		}
+
+		if !filter.From.IsZero() && p.CreatedAt.Before(filter.From) {
+			continue
+		}
+
+		if !filter.To.IsZero() && p.CreatedAt.After(filter.To) {
+			continue
+		}
+
+		if filter.After != nil && !isAfter(p, *filter.After) {
+			continue
+		}
+
+		if filter.Query != "" {
+			var ok bool
+
+			p, ok = match(p, filter.Query)
+			if !ok {
+				continue
+			}
+		}
+
+		posts = append(posts, p)
+	}
+
+	return posts
+}
+
+func (s *storage) Insert(ctx context.Context, p post.Post) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[p.ID]; ok {
+		return post.ErrorAlreadyExists
+	}
+
+	p.Tags = copyTags(p.Tags)
+
+	s.posts[p.ID] = p
+	s.addRevision(ctx, p)
+	s.authors.ensure(p.AuthorID)
+
+	return nil
+}
+
+// clearAuthor makes the posts of the removed author anonymous the way the foreign key of the post table does
+func (s *storage) clearAuthor(authorID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, p := range s.posts {
+		if p.AuthorID == authorID {
+			p.AuthorID = ""
+			s.posts[id] = p
+		}
+	}
+}
+
+func (s *storage) Update(ctx context.Context, updated post.Post) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.live(updated.ID, updated.Version)
+	if err != nil {
+		return err
+	}
+
+	p.Title = updated.Title
+	p.Content = updated.Content
+	p.Tags = copyTags(updated.Tags)
+	p.Category = updated.Category
+	p.UpdatedAt = time.Now().UTC().Round(time.Millisecond)
+	p.Version++
+
+	s.posts[p.ID] = p
+	s.addRevision(ctx, p)
+
+	return nil
+}
+
+func (s *storage) Patch(ctx context.Context, id string, patch post.Patch, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.live(id, version)
+	if err != nil {
+		return err
+	}
+
+	if patch.Title != nil {
+		p.Title = *patch.Title
+	}
+
+	if patch.Content != nil {
+		p.Content = *patch.Content
+	}
+
+	if patch.Tags != nil {
+		p.Tags = copyTags(*patch.Tags)
+	}
+
+	if patch.Category != nil {
+		p.Category = *patch.Category
+	}
+
+	p.UpdatedAt = time.Now().UTC().Round(time.Millisecond)
+	p.Version++
+
+	s.posts[p.ID] = p
+	s.addRevision(ctx, p)
+
+	return nil
+}
+
+func (s *storage) Remove(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.live(id, version)
+	if errors.Is(err, post.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now().UTC().Round(time.Millisecond)
+	p.DeletedAt = &deletedAt
+	p.Version++
+
+	s.posts[id] = p
+
+	return nil
+}
+
+func (s *storage) Restore(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.DeletedAt == nil {
+		return post.ErrNotFound
+	}
+
+	p.DeletedAt = nil
+	p.Version++
+
+	s.posts[id] = p
+
+	return nil
+}
+
+func (s *storage) Purge(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.byVersion(id, version)
+	if errors.Is(err, post.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	delete(s.posts, id)
+	delete(s.revisions, id)
+
+	return nil
+}
+
+func (s *storage) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+
+	for id, p := range s.posts {
+		if p.DeletedAt != nil && p.DeletedAt.Before(before) {
+			delete(s.posts, id)
+			delete(s.revisions, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (s *storage) SetStatus(
+	ctx context.Context,
+	id string,
+	status post.Status,
+	publishAt *time.Time,
+	version int64,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.live(id, version)
+	if err != nil {
+		return err
+	}
+
+	p.Status = status
+	p.PublishAt = publishAt
+	p.UpdatedAt = time.Now().UTC().Round(time.Millisecond)
+	p.Version++
+
+	s.posts[id] = p
+
+	return nil
+}
+
+func (s *storage) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var published int64
+
+	for id, p := range s.posts {
+		if p.Status != post.StatusScheduled || p.DeletedAt != nil || p.PublishAt == nil || p.PublishAt.After(now) {
+			continue
+		}
+
+		p.Status = post.StatusPublished
+		p.UpdatedAt = now.Round(time.Millisecond)
+		p.Version++
+
+		s.posts[id] = p
+		published++
+	}
+
+	return published, nil
+}
+
+func (s *storage) Tags(ctx context.Context) ([]post.Count, error) {
+	return s.counts(ctx, func(p post.Post) []string {
+		return p.Tags
+	})
+}
+
+func (s *storage) Categories(ctx context.Context) ([]post.Count, error) {
+	return s.counts(ctx, func(p post.Post) []string {
+		if p.Category == "" {
+			return nil
+		}
+
+		return []string{p.Category}
+	})
+}
+
+// counts counts live published posts per name returned by names, the most used names go first
+func (s *storage) counts(ctx context.Context, names func(p post.Post) []string) ([]post.Count, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make(map[string]int64)
+
+	for _, p := range s.posts {
+		if p.DeletedAt != nil || p.Status != post.StatusPublished {
+			continue
+		}
+
+		for _, name := range names(p) {
+			posts[name]++
+		}
+	}
+
+	counts := make([]post.Count, 0, len(posts))
+
+	for name, n := range posts {
+		counts = append(counts, post.Count{Name: name, Posts: n})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Posts == counts[j].Posts {
+			return counts[i].Name < counts[j].Name
+		}
+
+		return counts[i].Posts > counts[j].Posts
+	})
+
+	return counts, nil
+}
+
+func (s *storage) Revisions(ctx context.Context, postID string) ([]post.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := make([]post.Revision, len(s.revisions[postID]))
+	copy(revisions, s.revisions[postID])
+
+	return revisions, nil
+}
+
+func (s *storage) Revision(ctx context.Context, postID string, number int64) (post.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return post.Revision{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisions[postID]
+
+	if number < 1 || number > int64(len(revisions)) {
+		return post.Revision{}, post.ErrNotFound
+	}
+
+	return revisions[number-1], nil
+}
+
+// addRevision snapshots the post as its next revision
+func (s *storage) addRevision(ctx context.Context, p post.Post) {
+	tags := append([]string{}, p.Tags...)
+	category := p.Category
+
+	s.revisions[p.ID] = append(s.revisions[p.ID], post.Revision{
+		PostID:    p.ID,
+		Number:    int64(len(s.revisions[p.ID]) + 1),
+		Author:    post.ActorFromContext(ctx).ID,
+		Title:     p.Title,
+		Content:   p.Content,
+		Tags:      &tags,
+		Category:  &category,
+		CreatedAt: p.UpdatedAt,
+	})
+}
+
+// byVersion mimics the conditional modification of the sql storage. Zero version matches any post.
+func (s *storage) byVersion(id string, version int64) (post.Post, error) {
+	p, ok := s.posts[id]
+
+	switch {
+	case version != 0 && (!ok || p.Version != version):
+		return post.Post{}, post.ErrVersionMismatch
+	case !ok:
+		return post.Post{}, post.ErrNotFound
+	default:
+		return p, nil
+	}
+}
+
+// live is byVersion ignoring soft deleted posts
+func (s *storage) live(id string, version int64) (post.Post, error) {
+	p, err := s.byVersion(id, version)
+
+	switch {
+	case err != nil:
+		return post.Post{}, err
+	case p.DeletedAt == nil:
+		return p, nil
+	case version != 0:
+		return post.Post{}, post.ErrVersionMismatch
+	default:
+		return post.Post{}, post.ErrNotFound
+	}
+}
+
+func hasStatus(statuses []post.Status, status post.Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasTags reports whether the post tags contain any or all of the wanted ones
+func hasTags(tags, wanted []string, all bool) bool {
+	for _, w := range wanted {
+		found := false
+
+		for _, tag := range tags {
+			if tag == w {
+				found = true
+				break
+			}
+		}
+
+		if found && !all {
+			return true
+		}
+
+		if !found && all {
+			return false
+		}
+	}
+
+	return all
+}
+
+// copyTags keeps the stored post from sharing the tags with the caller
+func copyTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return append([]string(nil), tags...)
+}
+
+// isAfter reports whether p goes after the cursor in created_at, id descending order.
+func isAfter(p post.Post, c post.Cursor) bool {
+	if p.CreatedAt.Equal(c.CreatedAt) {
+		return p.ID < c.ID
+	}
+
+	return p.CreatedAt.Before(c.CreatedAt)
+}
+
+// paginate mimics LIMIT/OFFSET of the sql storage, where zero limit means no limit.
+func paginate(posts []post.Post, limit, offset uint) []post.Post {
+	if offset >= uint(len(posts)) {
+		return []post.Post{}
+	}
+
+	posts = posts[offset:]
+
+	if limit > 0 && limit < uint(len(posts)) {
+		posts = posts[:limit]
+	}
+
+	return posts
+}
@@ -0,0 +1,29 @@
+package memstorage
+
+import (
+	"testing"
+
+	"github.com/sladonia/news-svc/internal/storagetest"
+	"github.com/stretchr/testify/suite"
+)
+
+type Suite struct {
+	storagetest.Suite
+}
+
+func (s *Suite) SetupSuite() {
+	s.Storage = New()
+	s.Authors = NewAuthorStorage(s.Storage)
+	s.Truncate = s.deleteAllData
+}
+
+func TestRunSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) deleteAllData() error {
+	s.Storage = New()
+	s.Authors = NewAuthorStorage(s.Storage)
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package migrator
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"2_add_index.up.sql":     {Data: []byte("CREATE INDEX idx on post(title);")},
+			"1_create_post.up.sql":   {Data: []byte("CREATE TABLE post (id TEXT);")},
+			"1_create_post.down.sql": {Data: []byte("DROP TABLE post;")},
+			"migration.go":           {Data: []byte("package migration")},
+		}
+
+		migrations, err := Load(fsys)
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+
+		assert.Equal(t, uint(1), migrations[0].Version)
+		assert.Equal(t, "create_post", migrations[0].Name)
+		assert.Equal(t, "DROP TABLE post;", migrations[0].Down)
+		assert.Len(t, migrations[0].Checksum, 64)
+
+		assert.Equal(t, uint(2), migrations[1].Version)
+		assert.Empty(t, migrations[1].Down)
+		assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+	})
+
+	t.Run("checksum_covers_down", func(t *testing.T) {
+		up := fstest.MapFS{"1_create_post.up.sql": {Data: []byte("CREATE TABLE post (id TEXT);")}}
+		upDown := fstest.MapFS{
+			"1_create_post.up.sql":   up["1_create_post.up.sql"],
+			"1_create_post.down.sql": {Data: []byte("DROP TABLE post;")},
+		}
+
+		withoutDown, err := Load(up)
+		require.NoError(t, err)
+
+		withDown, err := Load(upDown)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, withoutDown[0].Checksum, withDown[0].Checksum)
+	})
+
+	t.Run("down_without_up", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"1_create_post.down.sql": {Data: []byte("DROP TABLE post;")},
+		}
+
+		_, err := Load(fsys)
+		assert.Error(t, err)
+	})
+
+	t.Run("name_mismatch", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"1_create_post.up.sql":    {Data: []byte("CREATE TABLE post (id TEXT);")},
+			"1_create_posts.down.sql": {Data: []byte("DROP TABLE post;")},
+		}
+
+		_, err := Load(fsys)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,363 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"go.uber.org/zap"
+)
+
+const (
+	tableName = "schema_migrations"
+
+	columnVersion   = "version"
+	columnName      = "name"
+	columnChecksum  = "checksum"
+	columnAppliedAt = "applied_at"
+
+	// lockKey identifies the advisory lock held while migrations are running,
+	// so several instances starting at the same time don't migrate concurrently
+	lockKey = 7_346_271_905
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration checksum mismatch")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+)
+
+var dialect = goqu.Dialect("postgres")
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type appliedSQL struct {
+	Version   uint      `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+func New(db *sql.DB, migrations []Migration, log *zap.Logger) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		log:        log,
+	}
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	log        *zap.Logger
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint]appliedSQL) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.revert(ctx, conn, m.migrations[i])
+			}
+		}
+
+		return nil
+	})
+}
+
+// To applies or reverts migrations until the schema is at the given version. Zero version reverts everything.
+func (m *Migrator) To(ctx context.Context, version uint) error {
+	if _, ok := m.byVersion(version); version != 0 && !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint]appliedSQL) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+				continue
+			}
+
+			err := m.revert(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+
+			err := m.apply(ctx, conn, migration)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Baseline records migrations up to the given version as applied without running them. It adopts
+// the databases which schema was created before the migrator, e.g. by the postgres docker-entrypoint.
+func (m *Migrator) Baseline(ctx context.Context, version uint) error {
+	if _, ok := m.byVersion(version); !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint]appliedSQL) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+
+			query, _, err := dialect.Insert(tableName).Rows(newAppliedSQL(migration)).ToSQL()
+			if err != nil {
+				return err
+			}
+
+			_, err = conn.ExecContext(ctx, query)
+			if err != nil {
+				return fmt.Errorf("baseline %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			m.log.Info("migration baselined", zap.Uint("version", migration.Version), zap.String("name", migration.Name))
+		}
+
+		return nil
+	})
+}
+
+// Status lists all known migrations and whether they are applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(_ *sql.Conn, applied map[uint]appliedSQL) error {
+		statuses = make([]Status, len(m.migrations))
+
+		for i, migration := range m.migrations {
+			a, ok := applied[migration.Version]
+
+			statuses[i] = Status{
+				Migration: migration,
+				Applied:   ok,
+				AppliedAt: a.AppliedAt,
+			}
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Version returns the latest applied migration version, zero if none were applied or the table
+// of the applied migrations isn't created yet. It doesn't take the lock, so it is cheap enough
+// for health checks.
+func (m *Migrator) Version(ctx context.Context) (uint, error) {
+	var exists bool
+
+	err := m.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", tableName).Scan(&exists)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	query, _, err := dialect.From(tableName).
+		Select(goqu.COALESCE(goqu.MAX(columnVersion), 0)).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint
+
+	err = m.db.QueryRowContext(ctx, query).Scan(&version)
+
+	return version, err
+}
+
+// withLock runs fn holding the advisory lock on a dedicated connection,
+// after the applied migrations were verified against the known ones.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[uint]appliedSQL) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+
+	defer func() {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+		if err != nil {
+			m.log.Error("failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+tableName+` (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at timestamp NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("create %s table: %w", tableName, err)
+	}
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	err = m.verify(applied)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[uint]appliedSQL, error) {
+	query, _, err := dialect.From(tableName).
+		Select(columnVersion, columnName, columnChecksum, columnAppliedAt).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[uint]appliedSQL)
+
+	for rows.Next() {
+		var a appliedSQL
+
+		err = rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[a.Version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) verify(applied map[uint]appliedSQL) error {
+	for version, a := range applied {
+		migration, ok := m.byVersion(version)
+		if !ok {
+			return fmt.Errorf("%w: %d_%s is applied but not found", ErrUnknownVersion, a.Version, a.Name)
+		}
+
+		if migration.Checksum != a.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, a.Version, a.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) byVersion(version uint) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	record, _, err := dialect.Insert(tableName).Rows(newAppliedSQL(migration)).ToSQL()
+	if err != nil {
+		return err
+	}
+
+	err = m.inTx(ctx, conn, migration.Up, record)
+	if err != nil {
+		return fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	m.log.Info("migration applied", zap.Uint("version", migration.Version), zap.String("name", migration.Name))
+
+	return nil
+}
+
+func newAppliedSQL(migration Migration) appliedSQL {
+	return appliedSQL{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Checksum:  migration.Checksum,
+		AppliedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+
+	record, _, err := dialect.Delete(tableName).
+		Where(goqu.C(columnVersion).Eq(migration.Version)).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	err = m.inTx(ctx, conn, migration.Down, record)
+	if err != nil {
+		return fmt.Errorf("revert %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	m.log.Info("migration reverted", zap.Uint("version", migration.Version), zap.String("name", migration.Name))
+
+	return nil
+}
+
+// inTx runs migration sql along with the schema_migrations bookkeeping in a single transaction
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, migrationSQL, recordSQL string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, migrationSQL)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, recordSQL)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
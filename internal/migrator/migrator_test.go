@@ -0,0 +1,199 @@
+package migrator_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/ory/dockertest/v3"
+	"github.com/sladonia/news-svc/internal/migrator"
+	"github.com/sladonia/news-svc/internal/testtool"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const testDBNAME = "migrator_test"
+
+var (
+	ctx = context.Background()
+
+	migrations = fstest.MapFS{
+		"1_create_post.up.sql":   {Data: []byte("CREATE TABLE post (id TEXT);")},
+		"1_create_post.down.sql": {Data: []byte("DROP TABLE post;")},
+		"2_add_title.up.sql":     {Data: []byte("ALTER TABLE post ADD COLUMN title TEXT;")},
+		"2_add_title.down.sql":   {Data: []byte("ALTER TABLE post DROP COLUMN title;")},
+	}
+)
+
+type Suite struct {
+	suite.Suite
+
+	db                *sql.DB
+	migrator          *migrator.Migrator
+	dockerPool        *dockertest.Pool
+	postgresContainer *dockertest.Resource
+}
+
+func (s *Suite) SetupSuite() {
+	pool, err := testtool.NewPool()
+	if err != nil {
+		s.T().Skipf("docker is not available: %s", err)
+	}
+	s.dockerPool = pool
+
+	postgresContainer, dbDSN, err := testtool.NewPostgresContainer(pool, testDBNAME)
+	if err != nil {
+		panic(err)
+	}
+	s.postgresContainer = postgresContainer
+
+	pool.MaxWait = 120 * time.Second
+	err = pool.Retry(func() error {
+		s.db, err = sql.Open("postgres", dbDSN)
+		if err != nil {
+			panic(err)
+		}
+
+		return s.db.Ping()
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	s.migrator = s.newMigrator(migrations)
+}
+
+func (s *Suite) TearDownSuite() {
+	err := s.dockerPool.Purge(s.postgresContainer)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Suite) TearDownTest() {
+	_, err := s.db.Exec("DROP TABLE IF EXISTS post; DROP TABLE IF EXISTS schema_migrations;")
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestRunSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestUp() {
+	err := s.migrator.Up(ctx)
+	s.NoError(err)
+
+	version, err := s.migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(uint(2), version)
+
+	_, err = s.db.Exec("INSERT INTO post (id, title) VALUES ('1', 'title')")
+	s.NoError(err)
+
+	// applied migrations are not executed again
+	err = s.migrator.Up(ctx)
+	s.NoError(err)
+}
+
+func (s *Suite) TestVersionWithoutTable() {
+	version, err := s.migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(uint(0), version, "nothing is applied before the table is created")
+}
+
+func (s *Suite) TestDownAndTo() {
+	s.Require().NoError(s.migrator.Up(ctx))
+
+	err := s.migrator.Down(ctx)
+	s.NoError(err)
+
+	version, err := s.migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(uint(1), version)
+
+	err = s.migrator.To(ctx, 0)
+	s.NoError(err)
+
+	version, err = s.migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(uint(0), version)
+
+	err = s.migrator.To(ctx, 2)
+	s.NoError(err)
+
+	err = s.migrator.To(ctx, 42)
+	s.ErrorIs(err, migrator.ErrUnknownVersion)
+}
+
+func (s *Suite) TestStatus() {
+	s.Require().NoError(s.migrator.To(ctx, 1))
+
+	statuses, err := s.migrator.Status(ctx)
+	s.NoError(err)
+	s.Require().Len(statuses, 2)
+	s.True(statuses[0].Applied)
+	s.False(statuses[0].AppliedAt.IsZero())
+	s.False(statuses[1].Applied)
+}
+
+func (s *Suite) TestBaseline() {
+	// schema created before the migrator
+	_, err := s.db.Exec("CREATE TABLE post (id TEXT);")
+	s.Require().NoError(err)
+
+	err = s.migrator.Baseline(ctx, 1)
+	s.NoError(err)
+
+	version, err := s.migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(uint(1), version)
+
+	err = s.migrator.Up(ctx)
+	s.NoError(err)
+
+	_, err = s.db.Exec("INSERT INTO post (id, title) VALUES ('1', 'title')")
+	s.NoError(err)
+
+	err = s.migrator.Baseline(ctx, 42)
+	s.ErrorIs(err, migrator.ErrUnknownVersion)
+}
+
+func (s *Suite) TestChecksumMismatch() {
+	s.Require().NoError(s.migrator.To(ctx, 1))
+
+	changed := fstest.MapFS{
+		"1_create_post.up.sql": {Data: []byte("CREATE TABLE post (id INT);")},
+		"2_add_title.up.sql":   migrations["2_add_title.up.sql"],
+	}
+
+	err := s.newMigrator(changed).Up(ctx)
+	s.ErrorIs(err, migrator.ErrChecksumMismatch)
+}
+
+func (s *Suite) TestNoDownMigration() {
+	withoutDown := fstest.MapFS{
+		"1_create_post.up.sql":   migrations["1_create_post.up.sql"],
+		"1_create_post.down.sql": migrations["1_create_post.down.sql"],
+		"2_add_title.up.sql":     migrations["2_add_title.up.sql"],
+	}
+
+	m := s.newMigrator(withoutDown)
+	s.Require().NoError(m.Up(ctx))
+
+	err := m.Down(ctx)
+	s.ErrorIs(err, migrator.ErrNoDownMigration)
+}
+
+func (s *Suite) newMigrator(fsys fstest.MapFS) *migrator.Migrator {
+	loaded, err := migrator.Load(fsys)
+	if err != nil {
+		panic(err)
+	}
+
+	return migrator.New(s.db, loaded, zap.NewNop())
+}
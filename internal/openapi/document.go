@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	Version = "3.1.0"
+
+	// MediaTypeJSON is the media type of the request and response bodies unless specified otherwise
+	MediaTypeJSON = "application/json"
+)
+
+// Document is the subset of OpenAPI 3.1 document describing the service. Schemas are JSON Schema 2020-12.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+
+	operations map[string]route
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	ID          string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is the path, query or header parameter. Arrays are comma separated, as style form
+// is not exploded.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement lists the schemes required together, empty requirement makes security optional
+type SecurityRequirement map[string][]string
+
+type route struct {
+	path      string
+	method    string
+	operation *Operation
+}
+
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+		operations: make(map[string]route),
+	}
+}
+
+// Add adds the operation of the method at the path. Operation IDs are unique, Add panics on duplicates
+// as the document is built from the code.
+func (d *Document) Add(method, path string, operation *Operation) *Document {
+	if _, ok := d.operations[operation.ID]; ok {
+		panic(fmt.Sprintf("duplicate operation %q", operation.ID))
+	}
+
+	method = strings.ToLower(method)
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+
+	item[method] = operation
+	d.operations[operation.ID] = route{path: path, method: method, operation: operation}
+
+	return d
+}
+
+// Operation returns the operation by ID along with its method and path
+func (d *Document) Operation(id string) (method, path string, operation *Operation, ok bool) {
+	r, ok := d.operations[id]
+	if !ok {
+		return "", "", nil, false
+	}
+
+	return strings.ToUpper(r.method), r.path, r.operation, true
+}
+
+// Operations lists the operation IDs
+func (d *Document) Operations() []string {
+	ids := make([]string, 0, len(d.operations))
+
+	for id := range d.operations {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Response returns the response documented for the status, falling back to the default one
+func (o *Operation) Response(status int) (Response, bool) {
+	if response, ok := o.Responses[fmt.Sprint(status)]; ok {
+		return response, true
+	}
+
+	response, ok := o.Responses["default"]
+
+	return response, ok
+}
+
+// JSON returns the content of the single JSON media type with the schema
+func JSON(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{MediaTypeJSON: {Schema: schema}}
+}
@@ -0,0 +1,178 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kind string
+
+type embedded struct {
+	Title string `json:"title" validate:"required,max=5"`
+}
+
+type itemRequest struct {
+	embedded
+	Kind   kind       `json:"kind" validate:"omitempty,oneof=a b"`
+	Tags   []string   `json:"tags" validate:"max=2,dive,required"`
+	Email  string     `json:"email" validate:"omitempty,email"`
+	At     *time.Time `json:"at"`
+	hidden string
+}
+
+type item struct {
+	ID      string
+	Kind    kind
+	Tags    []string   `json:",omitempty"`
+	At      *time.Time `json:",omitempty"`
+	Links   []string   `json:"links"`
+	Skipped string     `json:"-"`
+}
+
+type page struct {
+	Items interface{} `json:"items"`
+	Next  *string     `json:"next,omitempty"`
+}
+
+func newTestDocument() *Document {
+	doc := New(Info{Title: "test", Version: "1"})
+	g := NewGenerator(doc).Enum(kind(""), "a", "b", "c")
+
+	doc.Add("POST", "/items", &Operation{
+		ID:          "createItem",
+		RequestBody: &RequestBody{Content: JSON(g.Request(itemRequest{}))},
+		Responses:   map[string]Response{"201": {Content: JSON(g.Response(item{}))}},
+	})
+
+	doc.Add("GET", "/items", &Operation{
+		ID: "listItems",
+		Parameters: []Parameter{
+			{Name: "limit", In: "query", Schema: Integer().WithMinimum(0)},
+			{Name: "kinds", In: "query", Schema: Array(Ref("Kind"))},
+		},
+		Responses: map[string]Response{"200": {Content: JSON(g.NamedResponse("ItemPage", page{Items: []item{}}))}},
+	})
+
+	return doc
+}
+
+func TestGenerator(t *testing.T) {
+	doc := newTestDocument()
+	schemas := doc.Components.Schemas
+
+	t.Run("request", func(t *testing.T) {
+		s := schemas["ItemRequest"]
+		require.NotNil(t, s)
+		assert.Equal(t, []string{"title"}, s.Required)
+		assert.NotContains(t, s.Properties, "hidden")
+
+		assert.Equal(t, 1, *s.Properties["title"].MinLength)
+		assert.Equal(t, 5, *s.Properties["title"].MaxLength)
+		assert.Equal(t, []interface{}{"a", "b", ""}, s.Properties["kind"].Enum)
+		assert.Equal(t, "email", s.Properties["email"].Format)
+		assert.Equal(t, Types{"string", "null"}, s.Properties["at"].Type)
+		assert.Equal(t, "date-time", s.Properties["at"].Format)
+
+		tags := s.Properties["tags"]
+		assert.Equal(t, Types{"array", "null"}, tags.Type)
+		assert.Equal(t, 2, *tags.MaxItems)
+		assert.Equal(t, 1, *tags.Items.MinLength)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		s := schemas["Item"]
+		require.NotNil(t, s)
+		assert.Equal(t, []string{"ID", "Kind", "links"}, s.Required)
+		assert.NotContains(t, s.Properties, "Skipped")
+		assert.Equal(t, "#/components/schemas/Kind", s.Properties["Kind"].Ref)
+		assert.Equal(t, Types{"array"}, s.Properties["Tags"].Type)
+		assert.Equal(t, Types{"string"}, s.Properties["At"].Type)
+		assert.Equal(t, Types{"array", "null"}, s.Properties["links"].Type)
+		assert.Equal(t, []interface{}{"a", "b", "c"}, schemas["Kind"].Enum)
+	})
+
+	t.Run("dynamic_type", func(t *testing.T) {
+		s := schemas["ItemPage"]
+		require.NotNil(t, s)
+		assert.Equal(t, []string{"items"}, s.Required)
+		assert.Equal(t, Types{"array", "null"}, s.Properties["items"].Type)
+		assert.Equal(t, "#/components/schemas/Item", s.Properties["items"].Items.Ref)
+		assert.Equal(t, Types{"string"}, s.Properties["next"].Type)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		encoded, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(doc)
+		require.NoError(t, err)
+
+		var decoded Document
+
+		err = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(encoded, &decoded)
+		require.NoError(t, err)
+		assert.Equal(t, Version, decoded.OpenAPI)
+		assert.Equal(t, doc.Components.Schemas, decoded.Components.Schemas)
+		assert.Equal(t, "createItem", decoded.Paths["/items"]["post"].ID)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	doc := newTestDocument()
+	_, _, create, ok := doc.Operation("createItem")
+	require.True(t, ok)
+
+	request := create.RequestBody.Content[MediaTypeJSON].Schema
+	response := create.Responses["201"].Content[MediaTypeJSON].Schema
+
+	for _, tc := range []struct {
+		name   string
+		schema *Schema
+		body   string
+		err    string
+	}{
+		{"valid_request", request, `{"title": "title", "tags": null, "at": "2022-01-02T15:04:05Z"}`, ""},
+		{"missing", request, `{}`, "validation error: title required"},
+		{"empty", request, `{"title": ""}`, "validation error: title required"},
+		{"too_long", request, `{"title": "title!"}`, "validation error: title max"},
+		{"enum", request, `{"title": "t", "kind": "c"}`, "validation error: kind oneof"},
+		{"empty_enum", request, `{"title": "t", "kind": ""}`, ""},
+		{"email", request, `{"title": "t", "email": "bob"}`, "validation error: email email"},
+		{"date_time", request, `{"title": "t", "at": "today"}`, "validation error: at date-time"},
+		{"items", request, `{"title": "t", "tags": ["a", "", "c"]}`, "validation error: tags max tags[1] required"},
+		{"type", request, `{"title": 1}`, "validation error: title type"},
+		{"body_type", request, `[]`, "validation error: body type"},
+		{"unknown_fields", request, `{"title": "t", "unknown": 1}`, ""},
+		{"valid_response", response, `{"ID": "1", "Kind": "c", "links": null}`, ""},
+		{"ref", response, `{"ID": "1", "Kind": "d", "links": []}`, "validation error: Kind oneof"},
+		{"omitted", response, `{"ID": "1", "Kind": "a", "Tags": null, "links": []}`, "validation error: Tags type"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var value interface{}
+
+			require.NoError(t, jsoniter.ConfigFastest.UnmarshalFromString(tc.body, &value))
+
+			err := doc.Validate(tc.schema, value)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateParameter(t *testing.T) {
+	doc := newTestDocument()
+	_, _, list, ok := doc.Operation("listItems")
+	require.True(t, ok)
+
+	limit, kinds := list.Parameters[0], list.Parameters[1]
+
+	assert.NoError(t, doc.ValidateParameter(limit, "10"))
+	assert.EqualError(t, doc.ValidateParameter(limit, "ten"), "validation error: limit type")
+	assert.EqualError(t, doc.ValidateParameter(limit, "-1"), "validation error: limit min")
+	assert.NoError(t, doc.ValidateParameter(kinds, "a,c"))
+	assert.EqualError(t, doc.ValidateParameter(kinds, "a,d"), "validation error: kinds[1] oneof")
+}
@@ -0,0 +1,480 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Schema is the subset of JSON Schema used to describe the bodies and parameters
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// Types are the JSON types of the schema, marshaled as a string when there is only one
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t Types) has(typ string) bool {
+	for _, tt := range t {
+		if tt == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ref references the component schema by name
+func Ref(name string) *Schema {
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// String, Integer and Boolean are the shortcuts for the schemas of the primitives
+func String() *Schema {
+	return &Schema{Type: Types{"string"}}
+}
+
+func Integer() *Schema {
+	return &Schema{Type: Types{"integer"}}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: Types{"boolean"}}
+}
+
+// Array is the schema of the array of the items
+func Array(items *Schema) *Schema {
+	return &Schema{Type: Types{"array"}, Items: items}
+}
+
+// WithFormat sets the format of the schema
+func (s *Schema) WithFormat(format string) *Schema {
+	s.Format = format
+
+	return s
+}
+
+// WithEnum restricts the schema to the values
+func (s *Schema) WithEnum(values ...interface{}) *Schema {
+	s.Enum = values
+
+	return s
+}
+
+// WithMinimum sets the minimum of the numeric schema
+func (s *Schema) WithMinimum(min float64) *Schema {
+	s.Minimum = &min
+
+	return s
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(jsoniter.RawMessage{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Generator generates the component schemas of the Go types from their json and validate tags.
+// Fields of the request bodies are required only by the required rule and may be null if Go value
+// may be nil, as the JSON decoder accepts that. Fields of the responses are required unless they
+// are omitempty, and may be null if they aren't.
+type Generator struct {
+	doc   *Document
+	types map[string]component
+	enums map[reflect.Type][]interface{}
+}
+
+type component struct {
+	t       reflect.Type
+	request bool
+}
+
+func NewGenerator(doc *Document) *Generator {
+	return &Generator{
+		doc:   doc,
+		types: make(map[string]component),
+		enums: make(map[reflect.Type][]interface{}),
+	}
+}
+
+// Enum makes the named type of v the component with the values
+func (g *Generator) Enum(v interface{}, values ...interface{}) *Generator {
+	g.enums[reflect.TypeOf(v)] = values
+
+	return g
+}
+
+// Response returns the schema of the response body. Interface fields are described by the dynamic
+// type of their value in v.
+func (g *Generator) Response(v interface{}) *Schema {
+	value := reflect.ValueOf(v)
+
+	return g.schema(value, value.Type(), false)
+}
+
+// Request returns the schema of the request body
+func (g *Generator) Request(v interface{}) *Schema {
+	value := reflect.ValueOf(v)
+
+	return g.schema(value, value.Type(), true)
+}
+
+// NamedResponse adds the component of the response struct under the name and references it. It allows
+// describing the same struct with different dynamic types of the interface fields.
+func (g *Generator) NamedResponse(name string, v interface{}) *Schema {
+	value := reflect.ValueOf(v)
+
+	g.register(name, component{t: value.Type()})
+	g.doc.Components.Schemas[name] = g.object(value, value.Type(), false)
+
+	return Ref(name)
+}
+
+// schema returns the schema of t, v is the value of type t if known, invalid value otherwise
+func (g *Generator) schema(v reflect.Value, t reflect.Type, request bool) *Schema {
+	if values, ok := g.enums[t]; ok {
+		name := componentName(t)
+		if g.register(name, component{t: t}) {
+			g.doc.Components.Schemas[name] = g.primitive(t).WithEnum(values...)
+		}
+
+		return Ref(name)
+	}
+
+	switch t {
+	case timeType:
+		return String().WithFormat("date-time")
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	// the types decoding themselves may accept any json
+	if t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(elem(v), t.Elem(), request)
+	case reflect.Interface:
+		if v.IsValid() && !v.IsNil() {
+			return g.schema(v.Elem(), v.Elem().Type(), request)
+		}
+
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(v, t, request)
+		}
+
+		name := componentName(t)
+		if g.register(name, component{t: t, request: request}) {
+			// the placeholder stops the recursion of the self referencing types
+			g.doc.Components.Schemas[name] = &Schema{}
+			*g.doc.Components.Schemas[name] = *g.object(v, t, request)
+		}
+
+		return Ref(name)
+	case reflect.Slice, reflect.Array:
+		return Array(g.schema(reflect.Value{}, t.Elem(), request))
+	case reflect.Map:
+		return &Schema{
+			Type:                 Types{"object"},
+			AdditionalProperties: g.schema(reflect.Value{}, t.Elem(), request),
+		}
+	default:
+		return g.primitive(t)
+	}
+}
+
+func (g *Generator) primitive(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return String()
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Integer()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer().WithMinimum(0)
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	default:
+		panic(fmt.Sprintf("unsupported type %s", t))
+	}
+}
+
+// register reports whether the component is new. Components are named by the Go types, so two types
+// of the same name in different packages can't be described by the same generator.
+func (g *Generator) register(name string, c component) bool {
+	existing, ok := g.types[name]
+	if !ok {
+		g.types[name] = c
+
+		return true
+	}
+
+	if existing != c {
+		panic(fmt.Sprintf("component %q describes both %s and %s", name, existing.t, c.t))
+	}
+
+	return false
+}
+
+func (g *Generator) object(v reflect.Value, t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema)}
+
+	g.fields(s, v, t, request)
+
+	return s
+}
+
+// fields adds the properties of the struct fields to s, the fields of the embedded structs are promoted
+func (g *Generator) fields(s *Schema, v reflect.Value, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+
+		name, omitempty := parseJSONTag(f.Tag.Get("json"))
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft, fv = ft.Elem(), elem(fv)
+			}
+
+			if ft.Kind() == reflect.Struct {
+				g.fields(s, fv, ft, request)
+
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.schema(fv, f.Type, request)
+
+		// the decoder accepts null unless the field is required, while the encoder omits nil values
+		// of omitempty fields only
+		var required, null bool
+		if request {
+			required = constrain(fs, f.Type, f.Tag.Get("validate"))
+			null = nillable(f.Type) && !required
+		} else {
+			required = !omitempty
+			null = nillable(f.Type) && !omitempty
+		}
+
+		if null {
+			fs = nullable(fs)
+		}
+
+		if required {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = fs
+	}
+}
+
+// constrain applies the validator rules to the schema of the field of type t and reports
+// whether the field is required
+func constrain(s *Schema, t reflect.Type, rules string) bool {
+	if rules == "" {
+		return false
+	}
+
+	var required, omitempty, dived bool
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, param := rule, ""
+		if j := strings.Index(rule, "="); j >= 0 {
+			name, param = rule[:j], rule[j+1:]
+		}
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch name {
+		case "required":
+			// required slices may be empty, they only can't be null
+			required = required || !dived
+
+			if t.Kind() == reflect.String {
+				s.MinLength = intPtr(1)
+			}
+		case "omitempty":
+			omitempty = true
+		case "max", "min":
+			limit, err := strconv.Atoi(param)
+			if err != nil {
+				panic(fmt.Sprintf("invalid %s rule %q", name, rule))
+			}
+
+			setLimit(s, t, name, limit)
+		case "oneof":
+			values := make([]interface{}, 0)
+
+			for _, value := range strings.Fields(param) {
+				values = append(values, value)
+			}
+
+			if omitempty {
+				values = append(values, "")
+			}
+
+			// the oneof rule of the enum type replaces its values
+			*s = Schema{Type: Types{"string"}, Enum: values, MinLength: s.MinLength}
+		case "email":
+			s.Format = "email"
+		case "dive":
+			s, t = s.Items, t.Elem()
+			omitempty, dived = false, true
+		}
+	}
+
+	return required
+}
+
+func setLimit(s *Schema, t reflect.Type, rule string, limit int) {
+	switch t.Kind() {
+	case reflect.String:
+		if rule == "max" {
+			s.MaxLength = intPtr(limit)
+		} else {
+			s.MinLength = intPtr(limit)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if rule == "max" {
+			s.MaxItems = intPtr(limit)
+		} else {
+			s.MinItems = intPtr(limit)
+		}
+	default:
+		value := float64(limit)
+
+		if rule == "max" {
+			s.Maximum = &value
+		} else {
+			s.Minimum = &value
+		}
+	}
+}
+
+// nullable makes the schema accept null too
+func nullable(s *Schema) *Schema {
+	if len(s.Type) == 0 || s.Ref != "" {
+		if s.Ref == "" {
+			// the empty schema accepts anything
+			return s
+		}
+
+		return &Schema{AnyOf: []*Schema{s, {Type: Types{"null"}}}}
+	}
+
+	n := *s
+	n.Type = append(append(Types{}, s.Type...), "null")
+
+	if len(n.Enum) > 0 {
+		n.Enum = append(append([]interface{}{}, s.Enum...), nil)
+	}
+
+	return &n
+}
+
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return t != rawMessageType
+	default:
+		return false
+	}
+}
+
+func parseJSONTag(tag string) (name string, omitempty bool) {
+	parts := strings.Split(tag, ",")
+
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return parts[0], omitempty
+}
+
+// componentName capitalizes the name of the type, so that the unexported types are named as exported ones
+func componentName(t reflect.Type) string {
+	r, size := utf8.DecodeRuneInString(t.Name())
+
+	return string(unicode.ToUpper(r)) + t.Name()[size:]
+}
+
+func elem(v reflect.Value) reflect.Value {
+	if v.IsValid() && !v.IsNil() {
+		return v.Elem()
+	}
+
+	return reflect.Value{}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
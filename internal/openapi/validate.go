@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError is the failed validation rule of the field. Rules are named after the validator tags,
+// so that the errors read as the ones of the handlers.
+type FieldError struct {
+	Field string
+	Rule  string
+}
+
+// ValidationError lists the failed rules
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msg := "validation error:"
+
+	for _, err := range e {
+		msg += fmt.Sprintf(" %s %s", err.Field, err.Rule)
+	}
+
+	return msg
+}
+
+// bodyField names the body itself in the errors
+const bodyField = "body"
+
+// Validate validates the decoded JSON value against the schema. Objects are map[string]interface{},
+// arrays are []interface{} and numbers are float64, as decoded into interface{}.
+func (d *Document) Validate(schema *Schema, value interface{}) error {
+	return d.result(d.validate(schema, value, ""))
+}
+
+// ValidateParameter parses the raw value of the parameter according to its schema and validates it.
+// Array items are comma separated.
+func (d *Document) ValidateParameter(p Parameter, raw string) error {
+	value, ok := d.parseParameter(p.Schema, raw)
+	if !ok {
+		return ValidationError{{Field: p.Name, Rule: "type"}}
+	}
+
+	return d.result(d.validate(p.Schema, value, p.Name))
+}
+
+func (d *Document) result(errs ValidationError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func (d *Document) validate(s *Schema, value interface{}, field string) ValidationError {
+	var errs ValidationError
+
+	fail := func(rule string) {
+		name := field
+		if name == "" {
+			name = bodyField
+		}
+
+		errs = append(errs, FieldError{Field: name, Rule: rule})
+	}
+
+	if s.Ref != "" {
+		target, ok := d.resolve(s.Ref)
+		if !ok {
+			fail("$ref")
+
+			return errs
+		}
+
+		errs = append(errs, d.validate(target, value, field)...)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var best ValidationError
+
+		for i, option := range s.AnyOf {
+			optionErrs := d.validate(option, value, field)
+			if len(optionErrs) == 0 {
+				best = nil
+
+				break
+			}
+
+			if i == 0 || len(optionErrs) < len(best) {
+				best = optionErrs
+			}
+		}
+
+		errs = append(errs, best...)
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		fail("type")
+
+		return errs
+	}
+
+	// empty string is what the validator required rule rejects, the other rules don't matter then
+	if value == "" && s.MinLength != nil && *s.MinLength == 1 {
+		fail("required")
+
+		return errs
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("oneof")
+	}
+
+	switch value := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(value)
+
+		switch {
+		case s.MinLength != nil && length < *s.MinLength:
+			fail("min")
+		case s.MaxLength != nil && length > *s.MaxLength:
+			fail("max")
+		}
+
+		if s.Format != "" && !matchesFormat(s.Format, value) {
+			fail(s.Format)
+		}
+	case float64:
+		switch {
+		case s.Minimum != nil && value < *s.Minimum:
+			fail("min")
+		case s.Maximum != nil && value > *s.Maximum:
+			fail("max")
+		}
+	case []interface{}:
+		switch {
+		case s.MinItems != nil && len(value) < *s.MinItems:
+			fail("min")
+		case s.MaxItems != nil && len(value) > *s.MaxItems:
+			fail("max")
+		}
+
+		if s.Items != nil {
+			for i, item := range value {
+				errs = append(errs, d.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				errs = append(errs, FieldError{Field: join(field, name), Rule: "required"})
+			}
+		}
+
+		// sorted, so that the errors are stable
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				property = s.AdditionalProperties
+			}
+
+			if property != nil {
+				errs = append(errs, d.validate(property, value[name], join(field, name))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (d *Document) resolve(ref string) (*Schema, bool) {
+	if !strings.HasPrefix(ref, schemaRefPrefix) {
+		return nil, false
+	}
+
+	s, ok := d.Components.Schemas[strings.TrimPrefix(ref, schemaRefPrefix)]
+
+	return s, ok
+}
+
+// parseParameter converts the raw value to the type of the schema, the value is left as is if the type
+// is unknown, so that it fails the validation
+func (d *Document) parseParameter(s *Schema, raw string) (interface{}, bool) {
+	for s.Ref != "" {
+		target, ok := d.resolve(s.Ref)
+		if !ok {
+			return raw, true
+		}
+
+		s = target
+	}
+
+	switch {
+	case s.Type.has("array"):
+		parts := strings.Split(raw, ",")
+		items := make([]interface{}, 0, len(parts))
+
+		for _, part := range parts {
+			item := interface{}(part)
+
+			if s.Items != nil {
+				var ok bool
+
+				item, ok = d.parseParameter(s.Items, part)
+				if !ok {
+					return nil, false
+				}
+			}
+
+			items = append(items, item)
+		}
+
+		return items, true
+	case s.Type.has("integer"):
+		i, err := strconv.ParseInt(raw, 10, 64)
+
+		return float64(i), err == nil
+	case s.Type.has("number"):
+		f, err := strconv.ParseFloat(raw, 64)
+
+		return f, err == nil
+	case s.Type.has("boolean"):
+		b, err := strconv.ParseBool(raw)
+
+		return b, err == nil
+	default:
+		return raw, true
+	}
+}
+
+func matchesType(types Types, value interface{}) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && value == math.Trunc(value)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, v := range enum {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesFormat checks the formats in use, the others are annotations only
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+
+		return err == nil && address.Address == value
+	default:
+		return true
+	}
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+
+	return field + "." + name
+}
@@ -0,0 +1,5 @@
+package policy
+
+import "errors"
+
+var ErrDenied = errors.New("operation is not allowed")
@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// Action is the post operation checked by the Policy
+type Action string
+
+const (
+	ActionRead    Action = "read"    // get or list the post and its revisions, listing the trash is unconditional
+	ActionCreate  Action = "create"  // create post, including upsert of the missing one
+	ActionUpdate  Action = "update"  // replace, patch, revert or move the post back to draft
+	ActionPublish Action = "publish" // schedule or publish the post
+	ActionArchive Action = "archive"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge"
+)
+
+var actions = map[Action]struct{}{
+	ActionRead:    {},
+	ActionCreate:  {},
+	ActionUpdate:  {},
+	ActionPublish: {},
+	ActionArchive: {},
+	ActionDelete:  {},
+	ActionRestore: {},
+	ActionPurge:   {},
+}
+
+// Rule allows the action. Own and Statuses narrow it down to the posts of the principal and to the posts
+// in the given statuses. The status of created post is the one it is created with.
+type Rule struct {
+	Action   Action        `json:"action"`
+	Own      bool          `json:"own"`
+	Statuses []post.Status `json:"statuses"`
+}
+
+// allows reports whether the rule allows the action on the target. Restored and purged posts are in the
+// trash and aren't known to the policy, so only unconditional rules allow actions on nil target.
+func (r Rule) allows(subject string, action Action, target *post.Post) bool {
+	if r.Action != action {
+		return false
+	}
+
+	if target == nil {
+		return !r.Own && len(r.Statuses) == 0
+	}
+
+	if r.Own && target.AuthorID != subject {
+		return false
+	}
+
+	if len(r.Statuses) == 0 {
+		return true
+	}
+
+	for _, status := range r.Statuses {
+		if status == target.Status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Policy lists the rules of each role. Roles without rules may not access posts, anonymous callers
+// read posts as readers.
+type Policy map[auth.Role][]Rule
+
+// DefaultPolicy lets everyone read published posts, writers to read, create and update their own drafts,
+// editors to do anything but purging posts and admins to do anything
+func DefaultPolicy() Policy {
+	readPublished := Rule{Action: ActionRead, Statuses: []post.Status{post.StatusPublished}}
+
+	editor := []Rule{
+		{Action: ActionRead},
+		{Action: ActionCreate},
+		{Action: ActionUpdate},
+		{Action: ActionPublish},
+		{Action: ActionArchive},
+		{Action: ActionDelete},
+		{Action: ActionRestore},
+	}
+
+	return Policy{
+		auth.RoleReader: {readPublished},
+		auth.RoleWriter: {
+			readPublished,
+			{Action: ActionRead, Own: true},
+			{Action: ActionCreate, Statuses: []post.Status{post.StatusDraft}},
+			{Action: ActionUpdate, Own: true, Statuses: []post.Status{post.StatusDraft}},
+		},
+		auth.RoleEditor: editor,
+		auth.RoleAdmin:  append(editor[:len(editor):len(editor)], Rule{Action: ActionPurge}),
+	}
+}
+
+// LoadPolicy reads the JSON object of rules by role from the file. Policies without read rules get
+// the read rules of the DefaultPolicy, so that posts stay readable.
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+
+	err = jsoniter.ConfigFastest.Unmarshal(data, &p)
+	if err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+
+	if !p.hasAction(ActionRead) {
+		for role, rules := range DefaultPolicy() {
+			for _, rule := range rules {
+				if rule.Action == ActionRead {
+					p[role] = append(p[role], rule)
+				}
+			}
+		}
+	}
+
+	return p, p.validate()
+}
+
+func (p Policy) hasAction(action Action) bool {
+	for _, rules := range p {
+		for _, rule := range rules {
+			if rule.Action == action {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Authorize returns ErrDenied unless one of the rules of the principal role allows the action on the target
+func (p Policy) Authorize(principal auth.Principal, action Action, target *post.Post) error {
+	for _, rule := range p[principal.Role] {
+		if rule.allows(principal.Subject, action, target) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s may not %s the post", ErrDenied, principal.Role, action)
+}
+
+func (p Policy) validate() error {
+	for role, rules := range p {
+		if !role.IsValid() {
+			return fmt.Errorf("unknown role %q", role)
+		}
+
+		for _, rule := range rules {
+			if _, ok := actions[rule.Action]; !ok {
+				return fmt.Errorf("unknown action %q of role %q", rule.Action, role)
+			}
+
+			for _, status := range rule.Statuses {
+				if !status.IsValid() {
+					return fmt.Errorf("unknown status %q of role %q", status, role)
+				}
+			}
+		}
+	}
+
+	return nil
+}
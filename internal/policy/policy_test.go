@@ -0,0 +1,269 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/memstorage"
+	"github.com/sladonia/news-svc/internal/post"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	bob    = auth.Principal{Subject: "bob", Role: auth.RoleWriter}
+	alice  = auth.Principal{Subject: "alice", Role: auth.RoleWriter}
+	editor = auth.Principal{Subject: "editor", Role: auth.RoleEditor}
+	admin  = auth.Principal{Subject: "admin", Role: auth.RoleAdmin}
+	reader = auth.Principal{Subject: "reader", Role: auth.RoleReader}
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	p := DefaultPolicy()
+
+	bobsDraft := &post.Post{AuthorID: "bob", Status: post.StatusDraft}
+	bobsPublished := &post.Post{AuthorID: "bob", Status: post.StatusPublished}
+
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		action    Action
+		target    *post.Post
+		allowed   bool
+	}{
+		{"reader_reads_published", reader, ActionRead, bobsPublished, true},
+		{"reader_reads_draft", reader, ActionRead, bobsDraft, false},
+		{"reader_reads_trash", reader, ActionRead, nil, false},
+		{"writer_reads_own_draft", bob, ActionRead, bobsDraft, true},
+		{"writer_reads_others_draft", alice, ActionRead, bobsDraft, false},
+		{"writer_reads_others_published", alice, ActionRead, bobsPublished, true},
+		{"writer_reads_trash", bob, ActionRead, nil, false},
+		{"editor_reads_draft", editor, ActionRead, bobsDraft, true},
+		{"editor_reads_trash", editor, ActionRead, nil, true},
+		{"admin_reads_draft", admin, ActionRead, bobsDraft, true},
+		{"writer_creates_draft", bob, ActionCreate, bobsDraft, true},
+		{"writer_creates_published", bob, ActionCreate, bobsPublished, false},
+		{"writer_updates_own_draft", bob, ActionUpdate, bobsDraft, true},
+		{"writer_updates_own_published", bob, ActionUpdate, bobsPublished, false},
+		{"writer_updates_others_draft", alice, ActionUpdate, bobsDraft, false},
+		{"writer_publishes", bob, ActionPublish, bobsDraft, false},
+		{"writer_deletes", bob, ActionDelete, bobsDraft, false},
+		{"reader_creates", reader, ActionCreate, bobsDraft, false},
+		{"anonymous_creates", auth.Principal{}, ActionCreate, bobsDraft, false},
+		{"editor_updates_others", editor, ActionUpdate, bobsPublished, true},
+		{"editor_publishes", editor, ActionPublish, bobsDraft, true},
+		{"editor_deletes", editor, ActionDelete, bobsPublished, true},
+		{"editor_restores", editor, ActionRestore, nil, true},
+		{"editor_purges", editor, ActionPurge, nil, false},
+		{"admin_purges", admin, ActionPurge, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Authorize(tt.principal, tt.action, tt.target)
+
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrDenied)
+			}
+		})
+	}
+}
+
+func TestConditionalRuleOnTrashedPost(t *testing.T) {
+	p := Policy{auth.RoleWriter: {{Action: ActionRestore, Own: true}}}
+
+	assert.ErrorIs(t, p.Authorize(bob, ActionRestore, nil), ErrDenied)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		path := writePolicy(t, `{"writer":[
+			{"action":"read"},
+			{"action":"update","own":true,"statuses":["draft","scheduled"]}
+		]}`)
+
+		p, err := LoadPolicy(path)
+		require.NoError(t, err)
+		assert.Equal(t, Policy{
+			auth.RoleWriter: {
+				{Action: ActionRead},
+				{
+					Action:   ActionUpdate,
+					Own:      true,
+					Statuses: []post.Status{post.StatusDraft, post.StatusScheduled},
+				},
+			},
+		}, p)
+	})
+
+	t.Run("default_read_rules", func(t *testing.T) {
+		p, err := LoadPolicy(writePolicy(t, `{"writer":[{"action":"update","own":true}]}`))
+		require.NoError(t, err)
+
+		assert.NoError(t, p.Authorize(reader, ActionRead, &post.Post{Status: post.StatusPublished}))
+		assert.ErrorIs(t, p.Authorize(reader, ActionRead, &post.Post{Status: post.StatusDraft}), ErrDenied)
+		assert.NoError(t, p.Authorize(bob, ActionRead, &post.Post{AuthorID: "bob", Status: post.StatusDraft}))
+		assert.NoError(t, p.Authorize(editor, ActionRead, nil))
+	})
+
+	t.Run("unknown_role", func(t *testing.T) {
+		_, err := LoadPolicy(writePolicy(t, `{"owner":[{"action":"update"}]}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown_action", func(t *testing.T) {
+		_, err := LoadPolicy(writePolicy(t, `{"writer":[{"action":"approve"}]}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown_status", func(t *testing.T) {
+		_, err := LoadPolicy(writePolicy(t, `{"writer":[{"action":"update","statuses":["review"]}]}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestService(t *testing.T) {
+	s := NewService(post.NewService(memstorage.New()), DefaultPolicy())
+
+	var p post.Post
+
+	t.Run("create", func(t *testing.T) {
+		var err error
+
+		_, err = s.CreatePost(as(bob), post.Post{Title: "t", Content: "c", Status: post.StatusPublished})
+		assert.ErrorIs(t, err, ErrDenied)
+
+		p, err = s.CreatePost(as(bob), post.Post{Title: "t", Content: "c"})
+		require.NoError(t, err)
+		assert.Equal(t, "bob", p.AuthorID)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		title := "updated"
+
+		// bob's draft is hidden from alice, so the post service denies the patch before the policy
+		_, err := s.PatchPost(as(alice), p.ID, post.Patch{Title: &title}, 0)
+		assert.ErrorIs(t, err, post.ErrForbidden)
+
+		_, err = s.PatchPost(as(bob), p.ID, post.Patch{Title: &title}, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		_, err := s.SetPostStatus(as(bob), p.ID, post.StatusPublished, nil, 0)
+		assert.ErrorIs(t, err, ErrDenied)
+
+		p, err = s.SetPostStatus(as(editor), p.ID, post.StatusPublished, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, post.StatusPublished, p.Status)
+
+		err = s.UpsertPost(as(bob), post.Post{ID: p.ID, Title: "t", Content: "c"})
+		assert.ErrorIs(t, err, ErrDenied)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := s.DeletePost(as(bob), p.ID, 0)
+		assert.ErrorIs(t, err, ErrDenied)
+
+		err = s.DeletePost(as(editor), p.ID, 0)
+		require.NoError(t, err)
+
+		err = s.PurgePost(as(editor), p.ID, 0)
+		assert.ErrorIs(t, err, ErrDenied)
+
+		err = s.PurgePost(as(admin), p.ID, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		_, err := s.CreatePost(context.Background(), post.Post{Title: "t", Content: "c"})
+		assert.ErrorIs(t, err, ErrDenied)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		draft, err := s.CreatePost(as(bob), post.Post{Title: "t", Content: "c"})
+		require.NoError(t, err)
+
+		published := post.Filter{Statuses: []post.Status{post.StatusPublished}, Limit: 10}
+		bobsDrafts := post.Filter{Statuses: []post.Status{post.StatusDraft}, AuthorID: "bob", Limit: 10}
+		trash := post.Filter{Deleted: true, Limit: 10}
+
+		for _, tt := range []struct {
+			name      string
+			ctx       context.Context
+			draft     bool
+			bobsDraft bool
+			trash     bool
+		}{
+			{"anonymous", context.Background(), false, false, false},
+			{"reader", as(reader), false, false, false},
+			{"author", as(bob), true, true, false},
+			{"other_writer", as(alice), false, false, false},
+			{"editor", as(editor), true, true, true},
+			{"admin", as(admin), true, true, true},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := s.FindPosts(tt.ctx, published)
+				assert.NoError(t, err)
+
+				_, err = s.GetPost(tt.ctx, draft.ID)
+				assert.Equal(t, tt.draft, err == nil, err)
+
+				_, err = s.PostRevisions(tt.ctx, draft.ID)
+				assert.Equal(t, tt.draft, err == nil, err)
+
+				_, err = s.FindPosts(tt.ctx, bobsDrafts)
+				assert.Equal(t, tt.bobsDraft, err == nil, err)
+
+				_, err = s.CountPosts(tt.ctx, trash)
+				assert.Equal(t, tt.trash, err == nil, err)
+			})
+		}
+	})
+}
+
+func TestServiceReadRules(t *testing.T) {
+	published := Rule{Action: ActionRead, Statuses: []post.Status{post.StatusPublished}}
+	s := NewService(post.NewService(memstorage.New()), Policy{
+		auth.RoleEditor: {published, {Action: ActionCreate}},
+	})
+
+	draft, err := s.CreatePost(as(editor), post.Post{Title: "t", Content: "c"})
+	require.NoError(t, err)
+
+	_, err = s.GetPost(as(editor), draft.ID)
+	assert.ErrorIs(t, err, ErrDenied)
+
+	_, err = s.FindPosts(as(editor), post.Filter{Limit: 10})
+	assert.ErrorIs(t, err, ErrDenied, "empty statuses match drafts too")
+
+	_, err = s.FindPosts(as(editor), post.Filter{Statuses: []post.Status{post.StatusPublished}, Limit: 10})
+	assert.NoError(t, err)
+
+	_, err = s.FindPosts(context.Background(), post.Filter{Statuses: []post.Status{post.StatusPublished}, Limit: 10})
+	assert.ErrorIs(t, err, ErrDenied, "anonymous callers read as readers")
+}
+
+// as returns the context of the principal as the auth middleware sets it
+func as(principal auth.Principal) context.Context {
+	ctx := auth.WithPrincipal(context.Background(), principal)
+
+	return post.WithActor(ctx, post.Actor{
+		ID:     principal.Subject,
+		Editor: principal.HasScope(auth.ScopeEdit),
+	})
+}
+
+func writePolicy(t *testing.T, policy string) string {
+	path := filepath.Join(t.TempDir(), "policy.json")
+
+	err := os.WriteFile(path, []byte(policy), 0o600)
+	require.NoError(t, err)
+
+	return path
+}
@@ -0,0 +1,240 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// NewService authorizes post operations of the auth.PrincipalFromContext against the policy before
+// passing them to the service. Anonymous principals may not modify posts and read them as readers.
+// Tags, Categories, PurgeTrash and PublishScheduled are passed as is.
+func NewService(service post.Service, policy Policy) post.Service {
+	return &policyService{Service: service, policy: policy}
+}
+
+type policyService struct {
+	post.Service
+	policy Policy
+}
+
+func (s *policyService) GetPost(ctx context.Context, id string) (post.Post, error) {
+	p, err := s.Service.GetPost(ctx, id)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	err = s.authorizeRead(ctx, &p)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return p, nil
+}
+
+func (s *policyService) FindPosts(ctx context.Context, f post.Filter) ([]post.Post, error) {
+	err := s.authorizeFilter(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Service.FindPosts(ctx, f)
+}
+
+func (s *policyService) CountPosts(ctx context.Context, f post.Filter) (int64, error) {
+	err := s.authorizeFilter(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Service.CountPosts(ctx, f)
+}
+
+func (s *policyService) PostRevisions(ctx context.Context, id string) ([]post.Revision, error) {
+	_, err := s.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Service.PostRevisions(ctx, id)
+}
+
+func (s *policyService) PostRevision(ctx context.Context, id string, number int64) (post.Revision, error) {
+	_, err := s.GetPost(ctx, id)
+	if err != nil {
+		return post.Revision{}, err
+	}
+
+	return s.Service.PostRevision(ctx, id, number)
+}
+
+func (s *policyService) DiffRevisions(ctx context.Context, id string, from, to int64) ([]post.FieldChange, error) {
+	_, err := s.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Service.DiffRevisions(ctx, id, from, to)
+}
+
+func (s *policyService) CreatePost(ctx context.Context, p post.Post) (post.Post, error) {
+	err := s.authorizeCreate(ctx, p)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return s.Service.CreatePost(ctx, p)
+}
+
+func (s *policyService) UpsertPost(ctx context.Context, p post.Post) error {
+	current, err := s.Service.GetPost(ctx, p.ID)
+
+	switch {
+	case errors.Is(err, post.ErrNotFound):
+		err = s.authorizeCreate(ctx, p)
+	case err == nil:
+		err = s.authorize(ctx, ActionUpdate, &current)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.Service.UpsertPost(ctx, p)
+}
+
+func (s *policyService) PatchPost(ctx context.Context, id string, patch post.Patch, version int64) (post.Post, error) {
+	err := s.authorizeLive(ctx, ActionUpdate, id)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return s.Service.PatchPost(ctx, id, patch, version)
+}
+
+func (s *policyService) DeletePost(ctx context.Context, id string, version int64) error {
+	err := s.authorizeLive(ctx, ActionDelete, id)
+	if err != nil {
+		return err
+	}
+
+	return s.Service.DeletePost(ctx, id, version)
+}
+
+func (s *policyService) RestorePost(ctx context.Context, id string) (post.Post, error) {
+	err := s.authorize(ctx, ActionRestore, nil)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return s.Service.RestorePost(ctx, id)
+}
+
+func (s *policyService) PurgePost(ctx context.Context, id string, version int64) error {
+	err := s.authorize(ctx, ActionPurge, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.Service.PurgePost(ctx, id, version)
+}
+
+func (s *policyService) RevertPost(ctx context.Context, id string, number int64, version int64) (post.Post, error) {
+	err := s.authorizeLive(ctx, ActionUpdate, id)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return s.Service.RevertPost(ctx, id, number, version)
+}
+
+func (s *policyService) SetPostStatus(
+	ctx context.Context,
+	id string,
+	status post.Status,
+	publishAt *time.Time,
+	version int64,
+) (post.Post, error) {
+	action := ActionUpdate
+
+	switch status {
+	case post.StatusScheduled, post.StatusPublished:
+		action = ActionPublish
+	case post.StatusArchived:
+		action = ActionArchive
+	}
+
+	err := s.authorizeLive(ctx, action, id)
+	if err != nil {
+		return post.Post{}, err
+	}
+
+	return s.Service.SetPostStatus(ctx, id, status, publishAt, version)
+}
+
+// authorizeCreate authorizes creation of p in the status it is created with
+func (s *policyService) authorizeCreate(ctx context.Context, p post.Post) error {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	p.AuthorID = principal.Subject
+
+	if p.Status == "" {
+		p.Status = post.StatusDraft
+	}
+
+	return s.authorize(ctx, ActionCreate, &p)
+}
+
+// authorizeLive authorizes the action on the live post. Missing post is left to the service to report.
+func (s *policyService) authorizeLive(ctx context.Context, action Action, id string) error {
+	current, err := s.Service.GetPost(ctx, id)
+	if errors.Is(err, post.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.authorize(ctx, action, &current)
+}
+
+// authorizeRead authorizes reading of the post, nil target is the trash
+func (s *policyService) authorizeRead(ctx context.Context, target *post.Post) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		principal.Role = auth.RoleReader
+	}
+
+	return s.policy.Authorize(principal, ActionRead, target)
+}
+
+// authorizeFilter authorizes reading of the posts in every status the filter matches. Own rules allow
+// listing only along with the author filter of the principal.
+func (s *policyService) authorizeFilter(ctx context.Context, f post.Filter) error {
+	if f.Deleted {
+		return s.authorizeRead(ctx, nil)
+	}
+
+	statuses := f.Statuses
+	if len(statuses) == 0 {
+		statuses = post.Statuses()
+	}
+
+	for _, status := range statuses {
+		err := s.authorizeRead(ctx, &post.Post{AuthorID: f.AuthorID, Status: status})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *policyService) authorize(ctx context.Context, action Action, target *post.Post) error {
+	principal, _ := auth.PrincipalFromContext(ctx)
+
+	return s.policy.Authorize(principal, action, target)
+}
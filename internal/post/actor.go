@@ -0,0 +1,53 @@
+package post
+
+import "context"
+
+type actorKey struct{}
+
+// Actor is the one performing the operation. It is recorded as the author of post revisions
+// and of the posts it creates.
+type Actor struct {
+	ID     string // id of the author.Author
+	Editor bool   // editors may modify posts of any author
+}
+
+// CanModify reports whether the actor owns the post or is an editor
+func (a Actor) CanModify(p Post) bool {
+	return a.Editor || a.ID == p.AuthorID
+}
+
+// CanRead reports whether the actor may read the post. Unpublished posts are visible to their author and editors.
+func (a Actor) CanRead(p Post) bool {
+	return p.Status == StatusPublished || a.Editor || (a.ID != "" && a.ID == p.AuthorID)
+}
+
+// CanList reports whether the actor may list the posts matching the filter. Editors list anything, the others
+// list unpublished posts only along with the author filter of their own and can't list the trash.
+func (a Actor) CanList(f Filter) bool {
+	if a.Editor {
+		return true
+	}
+
+	if f.Deleted {
+		return false
+	}
+
+	published := len(f.Statuses) > 0
+
+	for _, status := range f.Statuses {
+		published = published && status == StatusPublished
+	}
+
+	return published || (a.ID != "" && a.ID == f.AuthorID)
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns zero Actor for anonymous operations
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+
+	return actor
+}
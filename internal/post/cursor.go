@@ -0,0 +1,50 @@
+package post
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to the position in the posts list ordered by created_at and id descending.
+// Next page starts right after the post the cursor was made of.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func NewCursor(p Post) Cursor {
+	return Cursor{
+		CreatedAt: p.CreatedAt,
+		ID:        p.ID,
+	}
+}
+
+// Encode returns an opaque string representation of the cursor safe to use in urls.
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: createdAt, ID: parts[1]}, nil
+}
@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrNotFound        = errors.New("record not found")
 	ErrorAlreadyExists = errors.New("record already exists")
+	ErrVersionMismatch = errors.New("record version mismatch")
+	ErrForbidden       = errors.New("only the author or an editor can modify the post or read it unpublished")
+
+	ErrInvalidTransition = errors.New("post status transition is not allowed")
+	ErrInvalidPublishAt  = errors.New("publish_at should be in the future for scheduled posts and in the past for published ones")
 )
@@ -1,6 +1,7 @@
 package post
 
 import (
+	"context"
 	"time"
 
 	"github.com/rs/xid"
@@ -10,8 +11,19 @@ type Post struct {
 	ID        string
 	Title     string
 	Content   string
+	AuthorID  string   `json:",omitempty"`
+	Tags      []string `json:",omitempty"` // normalized by NormalizeTags
+	Category  string   `json:",omitempty"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64      // incremented on every update
+	DeletedAt *time.Time `json:",omitempty"` // set for soft deleted posts
+	Status    Status
+	PublishAt *time.Time `json:",omitempty"` // publication time, set for scheduled and published posts
+
+	// Rank and Snippet are set only for posts found by the full text search Filter.Query
+	Rank    float64 `json:",omitempty"`
+	Snippet string  `json:",omitempty"`
 }
 
 func NewPost(title, content string) Post {
@@ -21,20 +33,68 @@ func NewPost(title, content string) Post {
 		Content:   content,
 		CreatedAt: time.Now().UTC().Round(time.Millisecond),
 		UpdatedAt: time.Now().UTC().Round(time.Millisecond),
+		Version:   1,
+		Status:    StatusDraft,
 	}
 }
 
+// Storage records a Revision authored by the ActorFromContext on every Insert, Update and Patch.
 type Storage interface {
-	ByID(id string) (Post, error)
-	ByFilter(filter Filter) ([]Post, error)
-	Insert(post Post) error
-	Update(id, title, content string) error
-	Remove(id string) error
+	ByID(ctx context.Context, id string) (Post, error)
+	ByFilter(ctx context.Context, filter Filter) ([]Post, error)
+	// Count counts the posts matching the filter regardless of After, Limit and Offset
+	Count(ctx context.Context, filter Filter) (int64, error)
+	Insert(ctx context.Context, post Post) error
+	// Update replaces title, content, tags and category of the post with p.ID. If p.Version is not zero,
+	// the post is updated only when its version matches, ErrVersionMismatch is returned otherwise.
+	Update(ctx context.Context, p Post) error
+	// Patch updates only the fields set in the patch, version is matched the same way as in Update.
+	Patch(ctx context.Context, id string, patch Patch, version int64) error
+	// Remove soft deletes the post, so it is excluded from ByID and ByFilter until restored.
+	// If version is not zero, the post is deleted only when its version matches.
+	Remove(ctx context.Context, id string, version int64) error
+	// Restore brings soft deleted post back. ErrNotFound is returned if there is no such deleted post.
+	Restore(ctx context.Context, id string) error
+	// Purge deletes the post permanently whether it was soft deleted or not.
+	Purge(ctx context.Context, id string, version int64) error
+	// PurgeDeleted permanently deletes the posts soft deleted before the given time.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// Revisions lists all the revisions of the post ordered by number
+	Revisions(ctx context.Context, postID string) ([]Revision, error)
+	Revision(ctx context.Context, postID string, number int64) (Revision, error)
+	// SetStatus moves the live post to the status, version is matched the same way as in Update.
+	SetStatus(ctx context.Context, id string, status Status, publishAt *time.Time, version int64) error
+	// PublishDue publishes the scheduled posts which PublishAt is not after now.
+	PublishDue(ctx context.Context, now time.Time) (int64, error)
+	// Tags counts published posts per tag, the most used tags go first
+	Tags(ctx context.Context) ([]Count, error)
+	// Categories counts published posts per category, the most used categories go first
+	Categories(ctx context.Context) ([]Count, error)
+}
+
+// Patch is a partial update of the post. Nil fields are left untouched.
+type Patch struct {
+	Title    *string
+	Content  *string
+	Tags     *[]string
+	Category *string
+}
+
+func (p Patch) IsEmpty() bool {
+	return p.Title == nil && p.Content == nil && p.Tags == nil && p.Category == nil
 }
 
 type Filter struct {
-	From   time.Time
-	To     time.Time
-	Limit  uint // required
-	Offset uint
+	From     time.Time
+	To       time.Time
+	Query    string   // full text search over title and content. results are ordered by relevance
+	Deleted  bool     // list soft deleted posts instead of the live ones
+	Statuses []Status // empty matches posts in any status
+	Tags     []string // posts having any of the tags, or all of them if AllTags is set
+	AllTags  bool
+	Category string
+	AuthorID string
+	After    *Cursor // keyset pagination, applied before offset
+	Limit    uint    // required
+	Offset   uint
 }
@@ -0,0 +1,63 @@
+package post
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	FieldTitle    = "title"
+	FieldContent  = "content"
+	FieldTags     = "tags"
+	FieldCategory = "category"
+)
+
+// Revision is an immutable snapshot of the post recorded on every create and update.
+// Revisions of a post are numbered sequentially starting from 1. Tags and Category are nil
+// in the revisions recorded before they were tracked.
+type Revision struct {
+	PostID    string
+	Number    int64
+	Author    string
+	Title     string
+	Content   string
+	Tags      *[]string `json:",omitempty"`
+	Category  *string   `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+// FieldChange of the tags lists them separated by comma
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Diff lists the fields changed between two revisions, tags and category are compared
+// if both of them recorded those
+func Diff(from, to Revision) []FieldChange {
+	changes := make([]FieldChange, 0, 4)
+
+	if from.Title != to.Title {
+		changes = append(changes, FieldChange{Field: FieldTitle, From: from.Title, To: to.Title})
+	}
+
+	if from.Content != to.Content {
+		changes = append(changes, FieldChange{Field: FieldContent, From: from.Content, To: to.Content})
+	}
+
+	if from.Tags != nil && to.Tags != nil && !reflect.DeepEqual(NormalizeTags(*from.Tags), NormalizeTags(*to.Tags)) {
+		changes = append(changes, FieldChange{
+			Field: FieldTags,
+			From:  strings.Join(*from.Tags, ","),
+			To:    strings.Join(*to.Tags, ","),
+		})
+	}
+
+	if from.Category != nil && to.Category != nil && *from.Category != *to.Category {
+		changes = append(changes, FieldChange{Field: FieldCategory, From: *from.Category, To: *to.Category})
+	}
+
+	return changes
+}
@@ -0,0 +1,48 @@
+package post_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sladonia/news-svc/internal/memstorage"
+	"github.com/sladonia/news-svc/internal/post"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unrecordedStorage returns the first revisions the way they were recorded before tags and category were tracked
+type unrecordedStorage struct {
+	post.Storage
+}
+
+func (s unrecordedStorage) Revision(ctx context.Context, postID string, number int64) (post.Revision, error) {
+	revision, err := s.Storage.Revision(ctx, postID, number)
+	if number == 1 {
+		revision.Tags, revision.Category = nil, nil
+	}
+
+	return revision, err
+}
+
+func TestUnrecordedRevisionTags(t *testing.T) {
+	ctx := post.WithActor(context.Background(), post.Actor{ID: "bob"})
+	service := post.NewService(unrecordedStorage{memstorage.New()})
+
+	created, err := service.CreatePost(ctx, post.Post{Title: "title", Content: "content"})
+	require.NoError(t, err)
+
+	title, tags, category := "updated", []string{"go"}, "tech"
+
+	_, err = service.PatchPost(ctx, created.ID, post.Patch{Title: &title, Tags: &tags, Category: &category}, 0)
+	require.NoError(t, err)
+
+	changes, err := service.DiffRevisions(ctx, created.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []post.FieldChange{{Field: post.FieldTitle, From: "title", To: "updated"}}, changes)
+
+	reverted, err := service.RevertPost(ctx, created.ID, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "title", reverted.Title)
+	assert.Equal(t, []string{"go"}, reverted.Tags, "tags the revision didn't record are kept")
+	assert.Equal(t, "tech", reverted.Category)
+}
@@ -1,11 +1,49 @@
 package post
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Service attributes created posts to the ActorFromContext. Only the author of the post or an editor
+// can modify the live post, ErrForbidden is returned to the others. Unpublished posts and revisions are
+// readable by the same actors, see Actor.CanRead and Actor.CanList.
 type Service interface {
-	GetPost(id string) (Post, error)
-	CreatePost(title, content string) (Post, error)
-	UpsertPost(id, title, content string) error
-	DeletePost(id string) error
-	FindPosts(f Filter) ([]Post, error)
+	GetPost(ctx context.Context, id string) (Post, error)
+	// CreatePost creates a post with the title, content, tags and category of p in draft, scheduled
+	// or published status. Empty status means draft.
+	CreatePost(ctx context.Context, p Post) (Post, error)
+	// UpsertPost creates or replaces the title, content, tags and category of the post with p.ID.
+	// Non zero p.Version makes it a conditional update of the existing post.
+	UpsertPost(ctx context.Context, p Post) error
+	// PatchPost partially updates the post and returns its new state. Non zero version makes it conditional.
+	PatchPost(ctx context.Context, id string, patch Patch, version int64) (Post, error)
+	// DeletePost soft deletes the post. Non zero version makes removal conditional.
+	DeletePost(ctx context.Context, id string, version int64) error
+	FindPosts(ctx context.Context, f Filter) ([]Post, error)
+	// CountPosts counts the posts FindPosts would find on all the pages
+	CountPosts(ctx context.Context, f Filter) (int64, error)
+	// RestorePost brings soft deleted post back and returns it
+	RestorePost(ctx context.Context, id string) (Post, error)
+	// PurgePost deletes the post permanently. Non zero version makes it conditional.
+	PurgePost(ctx context.Context, id string, version int64) error
+	// PurgeTrash permanently deletes the posts which are soft deleted longer than retention
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	PostRevisions(ctx context.Context, id string) ([]Revision, error)
+	PostRevision(ctx context.Context, id string, number int64) (Revision, error)
+	// DiffRevisions lists the fields changed between two revisions of the post
+	DiffRevisions(ctx context.Context, id string, from, to int64) ([]FieldChange, error)
+	// RevertPost replaces the post with the content of the revision, which creates a new revision.
+	// Tags and category are kept unless the revision recorded them. Non zero version makes it conditional.
+	RevertPost(ctx context.Context, id string, number int64, version int64) (Post, error)
+	// SetPostStatus moves the post through its lifecycle, ErrInvalidTransition is returned for disallowed moves.
+	// Non zero version makes it conditional.
+	SetPostStatus(ctx context.Context, id string, status Status, publishAt *time.Time, version int64) (Post, error)
+	// PublishScheduled publishes the scheduled posts which publish time has come
+	PublishScheduled(ctx context.Context) (int64, error)
+	Tags(ctx context.Context) ([]Count, error)
+	Categories(ctx context.Context) ([]Count, error)
 }
 
 func NewService(storage Storage) Service {
@@ -16,34 +54,279 @@ type service struct {
 	storage Storage
 }
 
-func (s *service) GetPost(id string) (Post, error) {
-	return s.storage.ByID(id)
+// GetPost reports the unpublished posts of other authors as missing, so that their existence isn't disclosed
+func (s *service) GetPost(ctx context.Context, id string) (Post, error) {
+	p, err := s.storage.ByID(ctx, id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	if !ActorFromContext(ctx).CanRead(p) {
+		return Post{}, ErrNotFound
+	}
+
+	return p, nil
 }
 
-func (s *service) CreatePost(title, content string) (Post, error) {
-	p := NewPost(title, content)
+func (s *service) CreatePost(ctx context.Context, p Post) (Post, error) {
+	created := NewPost(p.Title, p.Content)
+	created.Tags = NormalizeTags(p.Tags)
+	created.Category = NormalizeCategory(p.Category)
+	created.AuthorID = ActorFromContext(ctx).ID
+
+	switch p.Status {
+	case "", StatusDraft:
+	case StatusScheduled, StatusPublished:
+		created.Status = p.Status
+	default:
+		return Post{}, ErrInvalidTransition
+	}
+
+	publishAt, err := resolvePublishAt(created.Status, nil, p.PublishAt, created.CreatedAt)
+	if err != nil {
+		return Post{}, err
+	}
 
-	err := s.storage.Insert(p)
+	created.PublishAt = publishAt
 
-	return p, err
+	err = s.storage.Insert(ctx, created)
+
+	return created, err
 }
 
-func (s *service) UpsertPost(id, title, content string) error {
-	err := s.storage.Update(id, title, content)
+func (s *service) UpsertPost(ctx context.Context, p Post) error {
+	upserted := NewPost(p.Title, p.Content)
+	upserted.ID = p.ID
+	upserted.Version = p.Version
+	upserted.Tags = NormalizeTags(p.Tags)
+	upserted.Category = NormalizeCategory(p.Category)
+
+	err := s.authorize(ctx, upserted.ID)
 	if err == nil {
-		return nil
+		err = s.storage.Update(ctx, upserted)
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	// conditional update of the missing post
+	if upserted.Version != 0 {
+		return ErrVersionMismatch
+	}
+
+	upserted.Version = 1
+	upserted.AuthorID = ActorFromContext(ctx).ID
+
+	return s.storage.Insert(ctx, upserted)
+}
+
+func (s *service) PatchPost(ctx context.Context, id string, patch Patch, version int64) (Post, error) {
+	if patch.Tags != nil {
+		tags := NormalizeTags(*patch.Tags)
+		patch.Tags = &tags
+	}
+
+	if patch.Category != nil {
+		category := NormalizeCategory(*patch.Category)
+		patch.Category = &category
+	}
+
+	if !patch.IsEmpty() {
+		err := s.authorize(ctx, id)
+		if err != nil {
+			return Post{}, err
+		}
+
+		err = s.storage.Patch(ctx, id, patch, version)
+		if err != nil {
+			return Post{}, err
+		}
+	}
+
+	return s.storage.ByID(ctx, id)
+}
+
+func (s *service) DeletePost(ctx context.Context, id string, version int64) error {
+	// removal of the missing post is a no-op, unless it is conditional
+	err := s.authorize(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	return s.storage.Remove(ctx, id, version)
+}
+
+func (s *service) FindPosts(ctx context.Context, f Filter) ([]Post, error) {
+	if !ActorFromContext(ctx).CanList(f) {
+		return nil, ErrForbidden
+	}
+
+	f.Tags = NormalizeTags(f.Tags)
+	f.Category = NormalizeCategory(f.Category)
+
+	return s.storage.ByFilter(ctx, f)
+}
+
+func (s *service) CountPosts(ctx context.Context, f Filter) (int64, error) {
+	if !ActorFromContext(ctx).CanList(f) {
+		return 0, ErrForbidden
+	}
+
+	f.Tags = NormalizeTags(f.Tags)
+	f.Category = NormalizeCategory(f.Category)
+
+	return s.storage.Count(ctx, f)
+}
+
+func (s *service) RestorePost(ctx context.Context, id string) (Post, error) {
+	err := s.storage.Restore(ctx, id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return s.storage.ByID(ctx, id)
+}
+
+func (s *service) PurgePost(ctx context.Context, id string, version int64) error {
+	return s.storage.Purge(ctx, id, version)
+}
+
+func (s *service) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.storage.PurgeDeleted(ctx, time.Now().UTC().Add(-retention))
+}
+
+func (s *service) PostRevisions(ctx context.Context, id string) ([]Revision, error) {
+	err := s.authorizeRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.Revisions(ctx, id)
+}
+
+func (s *service) PostRevision(ctx context.Context, id string, number int64) (Revision, error) {
+	err := s.authorizeRevisions(ctx, id)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return s.storage.Revision(ctx, id, number)
+}
+
+func (s *service) DiffRevisions(ctx context.Context, id string, from, to int64) ([]FieldChange, error) {
+	fromRevision, err := s.PostRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := s.storage.Revision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(fromRevision, toRevision), nil
+}
+
+func (s *service) RevertPost(ctx context.Context, id string, number int64, version int64) (Post, error) {
+	err := s.authorize(ctx, id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	revision, err := s.storage.Revision(ctx, id, number)
+	if err != nil {
+		return Post{}, err
+	}
+
+	err = s.storage.Patch(ctx, id, Patch{
+		Title:    &revision.Title,
+		Content:  &revision.Content,
+		Tags:     revision.Tags,
+		Category: revision.Category,
+	}, version)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return s.storage.ByID(ctx, id)
+}
+
+func (s *service) SetPostStatus(
+	ctx context.Context,
+	id string,
+	status Status,
+	publishAt *time.Time,
+	version int64,
+) (Post, error) {
+	current, err := s.storage.ByID(ctx, id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	if version != 0 && version != current.Version {
+		return Post{}, ErrVersionMismatch
+	}
+
+	if !ActorFromContext(ctx).CanModify(current) {
+		return Post{}, ErrForbidden
+	}
+
+	if !current.Status.CanTransitionTo(status) {
+		return Post{}, ErrInvalidTransition
+	}
+
+	publishAt, err = resolvePublishAt(status, current.PublishAt, publishAt, time.Now().UTC().Round(time.Millisecond))
+	if err != nil {
+		return Post{}, err
 	}
 
-	newPost := NewPost(title, content)
-	newPost.ID = id
+	// the transition is validated against the current status, so it is applied only if the post is unchanged since
+	err = s.storage.SetStatus(ctx, id, status, publishAt, current.Version)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return s.storage.ByID(ctx, id)
+}
+
+func (s *service) PublishScheduled(ctx context.Context) (int64, error) {
+	return s.storage.PublishDue(ctx, time.Now().UTC())
+}
+
+func (s *service) Tags(ctx context.Context) ([]Count, error) {
+	return s.storage.Tags(ctx)
+}
 
-	return s.storage.Insert(newPost)
+func (s *service) Categories(ctx context.Context) ([]Count, error) {
+	return s.storage.Categories(ctx)
 }
 
-func (s *service) DeletePost(id string) error {
-	return s.storage.Remove(id)
+// authorize checks whether the actor can modify the live post
+func (s *service) authorize(ctx context.Context, id string) error {
+	p, err := s.storage.ByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !ActorFromContext(ctx).CanModify(p) {
+		return ErrForbidden
+	}
+
+	return nil
 }
 
-func (s *service) FindPosts(f Filter) ([]Post, error) {
-	return s.storage.ByFilter(f)
+// authorizeRevisions checks whether the actor can read the revisions of the live post. Revisions keep
+// the unpublished changes, so they are readable by the ones who can modify the post.
+func (s *service) authorizeRevisions(ctx context.Context, id string) error {
+	p, err := s.GetPost(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !ActorFromContext(ctx).CanModify(p) {
+		return ErrForbidden
+	}
+
+	return nil
 }
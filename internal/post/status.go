@@ -0,0 +1,75 @@
+package post
+
+import "time"
+
+// Status is the stage of the post lifecycle. Only published posts are visible to readers.
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusScheduled Status = "scheduled" // published automatically once PublishAt comes
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+)
+
+// transitions lists the statuses the post can be moved to from the given one
+var transitions = map[Status][]Status{
+	StatusDraft:     {StatusScheduled, StatusPublished, StatusArchived},
+	StatusScheduled: {StatusDraft, StatusScheduled, StatusPublished, StatusArchived},
+	StatusPublished: {StatusDraft, StatusArchived},
+	StatusArchived:  {StatusDraft, StatusPublished},
+}
+
+// Statuses lists the statuses of the post lifecycle
+func Statuses() []Status {
+	return []Status{StatusDraft, StatusScheduled, StatusPublished, StatusArchived}
+}
+
+func (s Status) IsValid() bool {
+	_, ok := transitions[s]
+
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, status := range transitions[s] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolvePublishAt validates the requested publish time of the post moving to the status.
+// Scheduled posts require it in the future, published ones get the current time unless already published before.
+func resolvePublishAt(status Status, current, requested *time.Time, now time.Time) (*time.Time, error) {
+	switch status {
+	case StatusScheduled:
+		if requested == nil || !requested.After(now) {
+			return nil, ErrInvalidPublishAt
+		}
+
+		return requested, nil
+	case StatusPublished:
+		if requested != nil {
+			if requested.After(now) {
+				return nil, ErrInvalidPublishAt
+			}
+
+			return requested, nil
+		}
+
+		if current != nil && !current.After(now) {
+			return current, nil
+		}
+
+		return &now, nil
+	default:
+		if requested != nil {
+			return requested, nil
+		}
+
+		return current, nil
+	}
+}
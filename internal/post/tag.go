@@ -0,0 +1,37 @@
+package post
+
+import (
+	"sort"
+	"strings"
+)
+
+// Count is the number of published posts having the tag or category
+type Count struct {
+	Name  string
+	Posts int64
+}
+
+// NormalizeTags lowercases, deduplicates and sorts the tags. Nil is returned when there are no tags.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+
+	var normalized []string
+
+	for _, tag := range tags {
+		tag = NormalizeCategory(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+func NormalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
@@ -0,0 +1,192 @@
+package post
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/sladonia/news-svc/internal/post"
+
+var attributePostID = attribute.Key("post.id")
+
+// NewTracedService wraps the calls of the service into spans of the global tracer provider
+func NewTracedService(service Service) Service {
+	return &tracedService{service: service}
+}
+
+type tracedService struct {
+	service Service
+}
+
+func (s *tracedService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "post.Service/"+method, trace.WithAttributes(attrs...))
+}
+
+// end records the error returned by the call and ends the span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func (s *tracedService) GetPost(ctx context.Context, id string) (Post, error) {
+	ctx, span := s.start(ctx, "GetPost", attributePostID.String(id))
+	p, err := s.service.GetPost(ctx, id)
+	end(span, err)
+
+	return p, err
+}
+
+func (s *tracedService) CreatePost(ctx context.Context, p Post) (Post, error) {
+	ctx, span := s.start(ctx, "CreatePost")
+	created, err := s.service.CreatePost(ctx, p)
+	span.SetAttributes(attributePostID.String(created.ID))
+	end(span, err)
+
+	return created, err
+}
+
+func (s *tracedService) UpsertPost(ctx context.Context, p Post) error {
+	ctx, span := s.start(ctx, "UpsertPost", attributePostID.String(p.ID))
+	err := s.service.UpsertPost(ctx, p)
+	end(span, err)
+
+	return err
+}
+
+func (s *tracedService) PatchPost(ctx context.Context, id string, patch Patch, version int64) (Post, error) {
+	ctx, span := s.start(ctx, "PatchPost", attributePostID.String(id))
+	p, err := s.service.PatchPost(ctx, id, patch, version)
+	end(span, err)
+
+	return p, err
+}
+
+func (s *tracedService) DeletePost(ctx context.Context, id string, version int64) error {
+	ctx, span := s.start(ctx, "DeletePost", attributePostID.String(id))
+	err := s.service.DeletePost(ctx, id, version)
+	end(span, err)
+
+	return err
+}
+
+func (s *tracedService) FindPosts(ctx context.Context, f Filter) ([]Post, error) {
+	ctx, span := s.start(ctx, "FindPosts")
+	posts, err := s.service.FindPosts(ctx, f)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	end(span, err)
+
+	return posts, err
+}
+
+func (s *tracedService) CountPosts(ctx context.Context, f Filter) (int64, error) {
+	ctx, span := s.start(ctx, "CountPosts")
+	count, err := s.service.CountPosts(ctx, f)
+	end(span, err)
+
+	return count, err
+}
+
+func (s *tracedService) RestorePost(ctx context.Context, id string) (Post, error) {
+	ctx, span := s.start(ctx, "RestorePost", attributePostID.String(id))
+	p, err := s.service.RestorePost(ctx, id)
+	end(span, err)
+
+	return p, err
+}
+
+func (s *tracedService) PurgePost(ctx context.Context, id string, version int64) error {
+	ctx, span := s.start(ctx, "PurgePost", attributePostID.String(id))
+	err := s.service.PurgePost(ctx, id, version)
+	end(span, err)
+
+	return err
+}
+
+func (s *tracedService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := s.start(ctx, "PurgeTrash")
+	purged, err := s.service.PurgeTrash(ctx, retention)
+	span.SetAttributes(attribute.Int64("post.count", purged))
+	end(span, err)
+
+	return purged, err
+}
+
+func (s *tracedService) PostRevisions(ctx context.Context, id string) ([]Revision, error) {
+	ctx, span := s.start(ctx, "PostRevisions", attributePostID.String(id))
+	revisions, err := s.service.PostRevisions(ctx, id)
+	end(span, err)
+
+	return revisions, err
+}
+
+func (s *tracedService) PostRevision(ctx context.Context, id string, number int64) (Revision, error) {
+	ctx, span := s.start(ctx, "PostRevision", attributePostID.String(id))
+	revision, err := s.service.PostRevision(ctx, id, number)
+	end(span, err)
+
+	return revision, err
+}
+
+func (s *tracedService) DiffRevisions(ctx context.Context, id string, from, to int64) ([]FieldChange, error) {
+	ctx, span := s.start(ctx, "DiffRevisions", attributePostID.String(id))
+	changes, err := s.service.DiffRevisions(ctx, id, from, to)
+	end(span, err)
+
+	return changes, err
+}
+
+func (s *tracedService) RevertPost(ctx context.Context, id string, number int64, version int64) (Post, error) {
+	ctx, span := s.start(ctx, "RevertPost", attributePostID.String(id))
+	p, err := s.service.RevertPost(ctx, id, number, version)
+	end(span, err)
+
+	return p, err
+}
+
+func (s *tracedService) SetPostStatus(
+	ctx context.Context,
+	id string,
+	status Status,
+	publishAt *time.Time,
+	version int64,
+) (Post, error) {
+	ctx, span := s.start(ctx, "SetPostStatus", attributePostID.String(id), attribute.String("post.status", string(status)))
+	p, err := s.service.SetPostStatus(ctx, id, status, publishAt, version)
+	end(span, err)
+
+	return p, err
+}
+
+func (s *tracedService) PublishScheduled(ctx context.Context) (int64, error) {
+	ctx, span := s.start(ctx, "PublishScheduled")
+	published, err := s.service.PublishScheduled(ctx)
+	span.SetAttributes(attribute.Int64("post.count", published))
+	end(span, err)
+
+	return published, err
+}
+
+func (s *tracedService) Tags(ctx context.Context) ([]Count, error) {
+	ctx, span := s.start(ctx, "Tags")
+	counts, err := s.service.Tags(ctx)
+	end(span, err)
+
+	return counts, err
+}
+
+func (s *tracedService) Categories(ctx context.Context) ([]Count, error) {
+	ctx, span := s.start(ctx, "Categories")
+	counts, err := s.service.Categories(ctx)
+	end(span, err)
+
+	return counts, err
+}
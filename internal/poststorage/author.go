@@ -0,0 +1,156 @@
+package poststorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+	"github.com/sladonia/news-svc/internal/author"
+)
+
+const (
+	authorTableName = "author"
+
+	columnEmail = "email"
+	columnBio   = "bio"
+)
+
+type AuthorSQL struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	Email     string    `db:"email"`
+	Bio       string    `db:"bio"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewAuthorSQL(a author.Author) AuthorSQL {
+	return AuthorSQL{
+		ID:        a.ID,
+		Name:      a.Name,
+		Email:     a.Email,
+		Bio:       a.Bio,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+func NewAuthorFromSQL(authorSQL AuthorSQL) author.Author {
+	return author.Author{
+		ID:        authorSQL.ID,
+		Name:      authorSQL.Name,
+		Email:     authorSQL.Email,
+		Bio:       authorSQL.Bio,
+		CreatedAt: authorSQL.CreatedAt.UTC(),
+		UpdatedAt: authorSQL.UpdatedAt.UTC(),
+	}
+}
+
+func NewAuthorStorage(db *goqu.Database) author.Storage {
+	return &authorStorage{db: db}
+}
+
+type authorStorage struct {
+	db *goqu.Database
+}
+
+func (s *authorStorage) ByID(ctx context.Context, id string) (author.Author, error) {
+	var a AuthorSQL
+
+	ok, err := s.db.From(authorTableName).
+		Where(goqu.C(columnID).Eq(id)).
+		ScanStructContext(ctx, &a)
+	if err != nil {
+		return author.Author{}, err
+	}
+
+	if !ok {
+		return author.Author{}, author.ErrNotFound
+	}
+
+	return NewAuthorFromSQL(a), nil
+}
+
+func (s *authorStorage) Count(ctx context.Context) (int64, error) {
+	return s.db.From(authorTableName).CountContext(ctx)
+}
+
+func (s *authorStorage) List(ctx context.Context, limit, offset uint) ([]author.Author, error) {
+	q := s.db.From(authorTableName).
+		Order(goqu.C(columnName).Asc(), goqu.C(columnID).Asc()).
+		Offset(offset)
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var authorsSQL []AuthorSQL
+
+	err := q.ScanStructsContext(ctx, &authorsSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make([]author.Author, len(authorsSQL))
+
+	for i, authorSQL := range authorsSQL {
+		authors[i] = NewAuthorFromSQL(authorSQL)
+	}
+
+	return authors, nil
+}
+
+func (s *authorStorage) Insert(ctx context.Context, a author.Author) error {
+	_, err := s.db.Insert(authorTableName).
+		Rows(NewAuthorSQL(a)).
+		Executor().
+		ExecContext(ctx)
+
+	var errDuplicate *pq.Error
+
+	if errors.As(err, &errDuplicate) {
+		if errDuplicate.Code == "23505" {
+			return author.ErrAlreadyExists
+		}
+	}
+
+	return err
+}
+
+func (s *authorStorage) Update(ctx context.Context, a author.Author) error {
+	res, err := s.db.Update(authorTableName).
+		Where(goqu.C(columnID).Eq(a.ID)).
+		Set(goqu.Record{
+			columnName:      a.Name,
+			columnEmail:     a.Email,
+			columnBio:       a.Bio,
+			columnUpdatedAt: time.Now().UTC().Round(time.Millisecond),
+		}).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return author.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *authorStorage) Remove(ctx context.Context, id string) error {
+	_, err := s.db.Delete(authorTableName).
+		Where(goqu.C(columnID).Eq(id)).
+		Executor().
+		ExecContext(ctx)
+
+	return err
+}
@@ -0,0 +1,251 @@
+package poststorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/sladonia/news-svc/internal/poststorage"
+
+// startQuery starts the span of the storage operation. Returned function ends it and records the metrics.
+func startQuery(ctx context.Context, metrics *Metrics, storage, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		storage+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationKey.String(operation)),
+	)
+
+	return ctx, func(err error) {
+		metrics.observe(storage, operation, start, err)
+
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		if isFailure(err) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+}
+
+// NewInstrumented records the metrics and traces of the post storage queries
+func NewInstrumented(storage post.Storage, metrics *Metrics) post.Storage {
+	return &instrumentedStorage{storage: storage, metrics: metrics}
+}
+
+type instrumentedStorage struct {
+	storage post.Storage
+	metrics *Metrics
+}
+
+func (s *instrumentedStorage) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	return startQuery(ctx, s.metrics, "post", operation)
+}
+
+func (s *instrumentedStorage) ByID(ctx context.Context, id string) (post.Post, error) {
+	ctx, done := s.start(ctx, "by_id")
+	p, err := s.storage.ByID(ctx, id)
+	done(err)
+
+	return p, err
+}
+
+func (s *instrumentedStorage) ByFilter(ctx context.Context, filter post.Filter) ([]post.Post, error) {
+	ctx, done := s.start(ctx, "by_filter")
+	posts, err := s.storage.ByFilter(ctx, filter)
+	done(err)
+
+	return posts, err
+}
+
+func (s *instrumentedStorage) Count(ctx context.Context, filter post.Filter) (int64, error) {
+	ctx, done := s.start(ctx, "count")
+	count, err := s.storage.Count(ctx, filter)
+	done(err)
+
+	return count, err
+}
+
+func (s *instrumentedStorage) Insert(ctx context.Context, p post.Post) error {
+	ctx, done := s.start(ctx, "insert")
+	err := s.storage.Insert(ctx, p)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Update(ctx context.Context, p post.Post) error {
+	ctx, done := s.start(ctx, "update")
+	err := s.storage.Update(ctx, p)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Patch(ctx context.Context, id string, patch post.Patch, version int64) error {
+	ctx, done := s.start(ctx, "patch")
+	err := s.storage.Patch(ctx, id, patch, version)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Remove(ctx context.Context, id string, version int64) error {
+	ctx, done := s.start(ctx, "remove")
+	err := s.storage.Remove(ctx, id, version)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Restore(ctx context.Context, id string) error {
+	ctx, done := s.start(ctx, "restore")
+	err := s.storage.Restore(ctx, id)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) Purge(ctx context.Context, id string, version int64) error {
+	ctx, done := s.start(ctx, "purge")
+	err := s.storage.Purge(ctx, id, version)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := s.start(ctx, "purge_deleted")
+	purged, err := s.storage.PurgeDeleted(ctx, before)
+	done(err)
+
+	return purged, err
+}
+
+func (s *instrumentedStorage) Revisions(ctx context.Context, postID string) ([]post.Revision, error) {
+	ctx, done := s.start(ctx, "revisions")
+	revisions, err := s.storage.Revisions(ctx, postID)
+	done(err)
+
+	return revisions, err
+}
+
+func (s *instrumentedStorage) Revision(ctx context.Context, postID string, number int64) (post.Revision, error) {
+	ctx, done := s.start(ctx, "revision")
+	revision, err := s.storage.Revision(ctx, postID, number)
+	done(err)
+
+	return revision, err
+}
+
+func (s *instrumentedStorage) SetStatus(
+	ctx context.Context,
+	id string,
+	status post.Status,
+	publishAt *time.Time,
+	version int64,
+) error {
+	ctx, done := s.start(ctx, "set_status")
+	err := s.storage.SetStatus(ctx, id, status, publishAt, version)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedStorage) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	ctx, done := s.start(ctx, "publish_due")
+	published, err := s.storage.PublishDue(ctx, now)
+	done(err)
+
+	return published, err
+}
+
+func (s *instrumentedStorage) Tags(ctx context.Context) ([]post.Count, error) {
+	ctx, done := s.start(ctx, "tags")
+	counts, err := s.storage.Tags(ctx)
+	done(err)
+
+	return counts, err
+}
+
+func (s *instrumentedStorage) Categories(ctx context.Context) ([]post.Count, error) {
+	ctx, done := s.start(ctx, "categories")
+	counts, err := s.storage.Categories(ctx)
+	done(err)
+
+	return counts, err
+}
+
+// NewInstrumentedAuthorStorage records the metrics and traces of the author storage queries
+func NewInstrumentedAuthorStorage(storage author.Storage, metrics *Metrics) author.Storage {
+	return &instrumentedAuthorStorage{storage: storage, metrics: metrics}
+}
+
+type instrumentedAuthorStorage struct {
+	storage author.Storage
+	metrics *Metrics
+}
+
+func (s *instrumentedAuthorStorage) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	return startQuery(ctx, s.metrics, "author", operation)
+}
+
+func (s *instrumentedAuthorStorage) ByID(ctx context.Context, id string) (author.Author, error) {
+	ctx, done := s.start(ctx, "by_id")
+	a, err := s.storage.ByID(ctx, id)
+	done(err)
+
+	return a, err
+}
+
+func (s *instrumentedAuthorStorage) List(ctx context.Context, limit, offset uint) ([]author.Author, error) {
+	ctx, done := s.start(ctx, "list")
+	authors, err := s.storage.List(ctx, limit, offset)
+	done(err)
+
+	return authors, err
+}
+
+func (s *instrumentedAuthorStorage) Count(ctx context.Context) (int64, error) {
+	ctx, done := s.start(ctx, "count")
+	count, err := s.storage.Count(ctx)
+	done(err)
+
+	return count, err
+}
+
+func (s *instrumentedAuthorStorage) Insert(ctx context.Context, a author.Author) error {
+	ctx, done := s.start(ctx, "insert")
+	err := s.storage.Insert(ctx, a)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedAuthorStorage) Update(ctx context.Context, a author.Author) error {
+	ctx, done := s.start(ctx, "update")
+	err := s.storage.Update(ctx, a)
+	done(err)
+
+	return err
+}
+
+func (s *instrumentedAuthorStorage) Remove(ctx context.Context, id string) error {
+	ctx, done := s.start(ctx, "remove")
+	err := s.storage.Remove(ctx, id)
+	done(err)
+
+	return err
+}
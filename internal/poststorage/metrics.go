@@ -0,0 +1,61 @@
+package poststorage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+// Metrics are the latency and error counters of the storage queries
+type Metrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "news",
+			Subsystem: "storage",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of storage queries by storage and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"storage", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "news",
+			Subsystem: "storage",
+			Name:      "query_errors_total",
+			Help:      "Number of failed storage queries by storage and operation. Not found, already exists and version mismatch results are not counted.",
+		}, []string{"storage", "operation"}),
+	}
+
+	registerer.MustRegister(m.duration, m.errors)
+
+	return m
+}
+
+func (m *Metrics) observe(storage, operation string, start time.Time, err error) {
+	m.duration.WithLabelValues(storage, operation).Observe(time.Since(start).Seconds())
+
+	if isFailure(err) {
+		m.errors.WithLabelValues(storage, operation).Inc()
+	}
+}
+
+// isFailure tells the storage failures apart from the expected outcomes reported as errors
+func isFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, post.ErrNotFound),
+		errors.Is(err, post.ErrorAlreadyExists),
+		errors.Is(err, post.ErrVersionMismatch),
+		errors.Is(err, author.ErrNotFound),
+		errors.Is(err, author.ErrAlreadyExists):
+		return false
+	default:
+		return true
+	}
+}
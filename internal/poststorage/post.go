@@ -1,8 +1,11 @@
 package poststorage
 
 import (
+	"html"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/sladonia/news-svc/internal/post"
 )
 
@@ -10,16 +13,48 @@ const (
 	columnID        = "id"
 	columnTitle     = "title"
 	columnContent   = "content"
+	columnAuthorID  = "author_id"
+	columnCategory  = "category"
+	columnTags      = "tags"
 	columnCreatedAt = "created_at"
 	columnUpdatedAt = "updated_at"
+	columnVersion   = "version"
+	columnDeletedAt = "deleted_at"
+	columnStatus    = "status"
+	columnPublishAt = "publish_at"
+	columnRank      = "rank"
+	columnSnippet   = "snippet"
+
+	searchConfig = "english"
+
+	// ts_headline marks the matches with the control characters, which are replaced with the tags
+	// once the snippet is HTML escaped
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
 )
 
 type PostSQL struct {
-	ID        string    `db:"id"`
-	Title     string    `db:"title"`
-	Content   string    `db:"content"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        string     `db:"id"`
+	Title     string     `db:"title"`
+	Content   string     `db:"content"`
+	AuthorID  *string    `db:"author_id"` // null for anonymous posts and deleted authors
+	Category  string     `db:"category"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	Version   int64      `db:"version"`
+	DeletedAt *time.Time `db:"deleted_at"`
+	Status    string     `db:"status"`
+	PublishAt *time.Time `db:"publish_at"`
+
+	// Tags are aggregated from the tag tables, see storage.selectPosts
+	Tags pq.StringArray `db:"tags" goqu:"skipinsert,skipupdate"`
+}
+
+// PostSearchSQL is a post found by full text search along with its relevance
+type PostSearchSQL struct {
+	PostSQL
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
 }
 
 func NewPostSQL(post post.Post) PostSQL {
@@ -27,8 +62,14 @@ func NewPostSQL(post post.Post) PostSQL {
 		ID:        post.ID,
 		Title:     post.Title,
 		Content:   post.Content,
+		AuthorID:  nullString(post.AuthorID),
+		Category:  post.Category,
 		CreatedAt: post.CreatedAt,
 		UpdatedAt: post.UpdatedAt,
+		Version:   post.Version,
+		DeletedAt: post.DeletedAt,
+		Status:    string(post.Status),
+		PublishAt: post.PublishAt,
 	}
 }
 
@@ -37,7 +78,62 @@ func NewPostFromSQL(postSQL PostSQL) post.Post {
 		ID:        postSQL.ID,
 		Title:     postSQL.Title,
 		Content:   postSQL.Content,
+		AuthorID:  stringValue(postSQL.AuthorID),
+		Tags:      tags(postSQL.Tags),
+		Category:  postSQL.Category,
 		CreatedAt: postSQL.CreatedAt.UTC(),
 		UpdatedAt: postSQL.UpdatedAt.UTC(),
+		Version:   postSQL.Version,
+		DeletedAt: utc(postSQL.DeletedAt),
+		Status:    post.Status(postSQL.Status),
+		PublishAt: utc(postSQL.PublishAt),
+	}
+}
+
+func NewPostFromSearchSQL(postSearchSQL PostSearchSQL) post.Post {
+	p := NewPostFromSQL(postSearchSQL.PostSQL)
+	p.Rank = postSearchSQL.Rank
+	p.Snippet = escapeSnippet(postSearchSQL.Snippet)
+
+	return p
+}
+
+// escapeSnippet escapes the snippet, so that the <b></b> tags around the matches are its only markup
+func escapeSnippet(snippet string) string {
+	return strings.NewReplacer(highlightStart, "<b>", highlightStop, "</b>").Replace(html.EscapeString(snippet))
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
+
+	utc := t.UTC()
+
+	return &utc
+}
+
+// tags turns no tags into nil, the same as post.NormalizeTags does
+func tags(tags pq.StringArray) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return tags
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
 }
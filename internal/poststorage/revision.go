@@ -0,0 +1,47 @@
+package poststorage
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+const (
+	revisionTableSuffix = "_revision"
+
+	columnPostID = "post_id"
+	columnNumber = "number"
+	columnAuthor = "author"
+)
+
+type RevisionSQL struct {
+	PostID    string         `db:"post_id"`
+	Number    int64          `db:"number"`
+	Author    string         `db:"author"`
+	Title     string         `db:"title"`
+	Content   string         `db:"content"`
+	Tags      pq.StringArray `db:"tags"`
+	Category  *string        `db:"category"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func NewRevisionFromSQL(revisionSQL RevisionSQL) post.Revision {
+	revision := post.Revision{
+		PostID:    revisionSQL.PostID,
+		Number:    revisionSQL.Number,
+		Author:    revisionSQL.Author,
+		Title:     revisionSQL.Title,
+		Content:   revisionSQL.Content,
+		Category:  revisionSQL.Category,
+		CreatedAt: revisionSQL.CreatedAt.UTC(),
+	}
+
+	// NULL tags are scanned as nil, the recorded empty ones as the empty array
+	if revisionSQL.Tags != nil {
+		recorded := []string(revisionSQL.Tags)
+		revision.Tags = &recorded
+	}
+
+	return revision
+}
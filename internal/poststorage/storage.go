@@ -1,33 +1,40 @@
 package poststorage
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/lib/pq"
+	"github.com/sladonia/news-svc/internal/author"
 	"github.com/sladonia/news-svc/internal/post"
 )
 
 func New(db *goqu.Database, postTableName string) post.Storage {
 	return &storage{
-		db:            db,
-		postTableName: postTableName,
+		db:                db,
+		postTableName:     postTableName,
+		revisionTableName: postTableName + revisionTableSuffix,
+		postTagTableName:  postTableName + postTagTableSuffix,
 	}
 }
 
 type storage struct {
-	db            *goqu.Database
-	postTableName string
+	db                *goqu.Database
+	postTableName     string
+	revisionTableName string
+	postTagTableName  string
 }
 
-func (s *storage) ByID(id string) (post.Post, error) {
-	query := s.db.From(s.postTableName).
-		Where(goqu.C(columnID).Eq(id))
+func (s *storage) ByID(ctx context.Context, id string) (post.Post, error) {
+	query := s.selectPosts().
+		Where(live(id, 0))
 
 	var p PostSQL
 
-	ok, err := query.ScanStruct(&p)
+	ok, err := query.ScanStructContext(ctx, &p)
 	if err != nil {
 		return post.Post{}, err
 	}
@@ -39,24 +46,67 @@ func (s *storage) ByID(id string) (post.Post, error) {
 	return NewPostFromSQL(p), nil
 }
 
-func (s *storage) ByFilter(filter post.Filter) ([]post.Post, error) {
-	q := s.db.From(s.postTableName)
+func (s *storage) ByFilter(ctx context.Context, filter post.Filter) ([]post.Post, error) {
+	if filter.Query != "" {
+		return s.search(ctx, filter)
+	}
 
-	if !filter.From.IsZero() {
-		q = q.Where(goqu.C(columnCreatedAt).Gte(filter.From))
+	q := s.filtered(filter).
+		Order(goqu.C(columnCreatedAt).Desc(), goqu.C(columnID).Desc()).
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+
+	var postsSQL []PostSQL
+
+	err := q.ScanStructsContext(ctx, &postsSQL)
+	if err != nil {
+		return nil, err
 	}
 
-	if !filter.To.IsZero() {
-		q = q.Where(goqu.C(columnCreatedAt).Lte(filter.To))
+	posts := make([]post.Post, len(postsSQL))
+
+	for i, postSQL := range postsSQL {
+		posts[i] = NewPostFromSQL(postSQL)
+	}
+
+	return posts, nil
+}
+
+func (s *storage) Count(ctx context.Context, filter post.Filter) (int64, error) {
+	filter.After = nil
+
+	q := s.filtered(filter)
+
+	if filter.Query != "" {
+		q = q.Where(goqu.L("search_vector @@ websearch_to_tsquery(?, ?)", searchConfig, filter.Query))
 	}
 
-	q = q.Order(goqu.C(columnCreatedAt).Desc()).
+	return q.CountContext(ctx)
+}
+
+// search orders the posts matching filter.Query by relevance and highlights the matches
+func (s *storage) search(ctx context.Context, filter post.Filter) ([]post.Post, error) {
+	tsQuery := goqu.L("websearch_to_tsquery(?, ?)", searchConfig, filter.Query)
+	rank := goqu.L("ts_rank(search_vector, ?)", tsQuery)
+
+	q := s.filtered(filter).
+		SelectAppend(
+			rank.As(columnRank),
+			goqu.L(
+				"ts_headline(?, title || ' ' || content, ?, ?)",
+				searchConfig,
+				tsQuery,
+				"StartSel="+highlightStart+", StopSel="+highlightStop,
+			).As(columnSnippet),
+		).
+		Where(goqu.L("search_vector @@ ?", tsQuery)).
+		Order(goqu.I(columnRank).Desc(), goqu.C(columnCreatedAt).Desc(), goqu.C(columnID).Desc()).
 		Limit(filter.Limit).
 		Offset(filter.Offset)
 
-	var postsSQL []PostSQL
+	var postsSQL []PostSearchSQL
 
-	err := q.ScanStructs(&postsSQL)
+	err := q.ScanStructsContext(ctx, &postsSQL)
 	if err != nil {
 		return nil, err
 	}
@@ -64,16 +114,119 @@ func (s *storage) ByFilter(filter post.Filter) ([]post.Post, error) {
 	posts := make([]post.Post, len(postsSQL))
 
 	for i, postSQL := range postsSQL {
-		posts[i] = NewPostFromSQL(postSQL)
+		posts[i] = NewPostFromSearchSQL(postSQL)
 	}
 
 	return posts, nil
 }
 
-func (s *storage) Insert(p post.Post) error {
+// selectPosts selects the post columns along with the tags of every post
+func (s *storage) selectPosts() *goqu.SelectDataset {
+	tags := s.taggedPosts().
+		Select(goqu.T(tagTableName).Col(columnName)).
+		Order(goqu.T(tagTableName).Col(columnName).Asc())
+
+	return s.db.From(s.postTableName).
+		Select(
+			goqu.C(columnID),
+			goqu.C(columnTitle),
+			goqu.C(columnContent),
+			goqu.C(columnAuthorID),
+			goqu.C(columnCategory),
+			goqu.C(columnCreatedAt),
+			goqu.C(columnUpdatedAt),
+			goqu.C(columnVersion),
+			goqu.C(columnDeletedAt),
+			goqu.C(columnStatus),
+			goqu.C(columnPublishAt),
+			goqu.L("ARRAY?", tags).As(columnTags),
+		)
+}
+
+// taggedPosts joins the tags of the post selected by the outer query
+func (s *storage) taggedPosts() *goqu.SelectDataset {
+	return s.db.From(s.postTagTableName).
+		Join(
+			goqu.T(tagTableName),
+			goqu.On(goqu.T(tagTableName).Col(columnID).Eq(goqu.T(s.postTagTableName).Col(columnTagID))),
+		).
+		Where(goqu.T(s.postTagTableName).Col(columnPostID).Eq(goqu.T(s.postTableName).Col(columnID)))
+}
+
+func (s *storage) filtered(filter post.Filter) *goqu.SelectDataset {
+	q := s.selectPosts()
+
+	if filter.Deleted {
+		q = q.Where(goqu.C(columnDeletedAt).IsNotNull())
+	} else {
+		q = q.Where(goqu.C(columnDeletedAt).IsNull())
+	}
+
+	if len(filter.Statuses) > 0 {
+		q = q.Where(goqu.C(columnStatus).In(filter.Statuses))
+	}
+
+	if filter.AuthorID != "" {
+		q = q.Where(goqu.C(columnAuthorID).Eq(filter.AuthorID))
+	}
+
+	if filter.Category != "" {
+		q = q.Where(goqu.C(columnCategory).Eq(filter.Category))
+	}
+
+	if len(filter.Tags) > 0 {
+		tagged := s.taggedPosts().
+			Where(goqu.T(tagTableName).Col(columnName).In(filter.Tags))
+
+		if filter.AllTags {
+			q = q.Where(goqu.L("? = ?", tagged.Select(goqu.COUNT(goqu.Star())), len(filter.Tags)))
+		} else {
+			q = q.Where(goqu.L("EXISTS ?", tagged.Select(goqu.L("1"))))
+		}
+	}
+
+	if !filter.From.IsZero() {
+		q = q.Where(goqu.C(columnCreatedAt).Gte(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		q = q.Where(goqu.C(columnCreatedAt).Lte(filter.To))
+	}
+
+	if filter.After != nil {
+		q = q.Where(goqu.Or(
+			goqu.C(columnCreatedAt).Lt(filter.After.CreatedAt),
+			goqu.And(
+				goqu.C(columnCreatedAt).Eq(filter.After.CreatedAt),
+				goqu.C(columnID).Lt(filter.After.ID),
+			),
+		))
+	}
+
+	return q
+}
+
+func (s *storage) Insert(ctx context.Context, p post.Post) error {
 	postSQL := NewPostSQL(p)
 
-	_, err := s.db.Insert(s.postTableName).Rows(postSQL).Executor().Exec()
+	err := s.inTx(ctx, func(tx *goqu.TxDatabase) error {
+		err := s.ensureAuthor(ctx, tx, p.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Insert(s.postTableName).Rows(postSQL).Executor().ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = s.setTags(ctx, tx, p.ID, p.Tags)
+		if err != nil {
+			return err
+		}
+
+		return s.insertRevision(ctx, tx, p.ID)
+	})
 
 	var errDuplicate *pq.Error
 
@@ -86,31 +239,418 @@ func (s *storage) Insert(p post.Post) error {
 	return err
 }
 
-func (s *storage) Update(id, title, content string) error {
+// ensureAuthor creates the author profile of the principal writing the first post, so that the post
+// refers to the existing author. Profiles are named after the principals until the authors rename them.
+func (s *storage) ensureAuthor(ctx context.Context, tx *goqu.TxDatabase, authorID string) error {
+	if authorID == "" {
+		return nil
+	}
+
+	_, err := tx.Insert(authorTableName).
+		Rows(NewAuthorSQL(author.NewAuthor(authorID, authorID, "", ""))).
+		OnConflict(goqu.DoNothing()).
+		Executor().
+		ExecContext(ctx)
+
+	return err
+}
+
+func (s *storage) Update(ctx context.Context, p post.Post) error {
+	return s.update(ctx, p.ID, p.Version, goqu.Record{
+		columnTitle:    p.Title,
+		columnContent:  p.Content,
+		columnCategory: p.Category,
+	}, &p.Tags)
+}
+
+func (s *storage) Patch(ctx context.Context, id string, patch post.Patch, version int64) error {
+	record := goqu.Record{}
+
+	if patch.Title != nil {
+		record[columnTitle] = *patch.Title
+	}
+
+	if patch.Content != nil {
+		record[columnContent] = *patch.Content
+	}
+
+	if patch.Category != nil {
+		record[columnCategory] = *patch.Category
+	}
+
+	return s.update(ctx, id, version, record, patch.Tags)
+}
+
+// update sets the record columns and tags unless nil of the live post and records a revision of the result
+func (s *storage) update(
+	ctx context.Context,
+	id string,
+	version int64,
+	record goqu.Record,
+	tags *[]string,
+) error {
+	record[columnUpdatedAt] = time.Now().UTC().Round(time.Millisecond)
+	record[columnVersion] = goqu.L("? + 1", goqu.C(columnVersion))
+
+	return s.inTx(ctx, func(tx *goqu.TxDatabase) error {
+		res, err := tx.Update(s.postTableName).
+			Where(live(id, version)).
+			Set(record).
+			Executor().
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = checkAffected(res, version)
+		if err != nil {
+			return err
+		}
+
+		if tags != nil {
+			err = s.setTags(ctx, tx, id, *tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.insertRevision(ctx, tx, id)
+	})
+}
+
+func (s *storage) Remove(ctx context.Context, id string, version int64) error {
 	res, err := s.db.Update(s.postTableName).
-		Where(goqu.C(columnID).Eq(id)).
+		Where(live(id, version)).
 		Set(goqu.Record{
-			columnTitle:     title,
-			columnContent:   content,
+			columnDeletedAt: time.Now().UTC().Round(time.Millisecond),
+			columnVersion:   goqu.L("? + 1", goqu.C(columnVersion)),
+		}).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = checkAffected(res, version)
+	if errors.Is(err, post.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *storage) Restore(ctx context.Context, id string) error {
+	res, err := s.db.Update(s.postTableName).
+		Where(goqu.C(columnID).Eq(id), goqu.C(columnDeletedAt).IsNotNull()).
+		Set(goqu.Record{
+			columnDeletedAt: nil,
+			columnVersion:   goqu.L("? + 1", goqu.C(columnVersion)),
+		}).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, 0)
+}
+
+func (s *storage) Purge(ctx context.Context, id string, version int64) error {
+	res, err := s.db.Delete(s.postTableName).
+		Where(byIDAndVersion(id, version)).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = checkAffected(res, version)
+	if errors.Is(err, post.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *storage) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.Delete(s.postTableName).
+		Where(goqu.C(columnDeletedAt).Lt(before)).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *storage) SetStatus(
+	ctx context.Context,
+	id string,
+	status post.Status,
+	publishAt *time.Time,
+	version int64,
+) error {
+	res, err := s.db.Update(s.postTableName).
+		Where(live(id, version)).
+		Set(goqu.Record{
+			columnStatus:    status,
+			columnPublishAt: publishAt,
 			columnUpdatedAt: time.Now().UTC().Round(time.Millisecond),
+			columnVersion:   goqu.L("? + 1", goqu.C(columnVersion)),
 		}).
 		Executor().
-		Exec()
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
 
-	rowsAffected, err := res.RowsAffected()
+	return checkAffected(res, version)
+}
+
+func (s *storage) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.Update(s.postTableName).
+		Where(
+			goqu.C(columnStatus).Eq(post.StatusScheduled),
+			goqu.C(columnPublishAt).Lte(now),
+			goqu.C(columnDeletedAt).IsNull(),
+		).
+		Set(goqu.Record{
+			columnStatus:    post.StatusPublished,
+			columnUpdatedAt: now.Round(time.Millisecond),
+			columnVersion:   goqu.L("? + 1", goqu.C(columnVersion)),
+		}).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *storage) Revisions(ctx context.Context, postID string) ([]post.Revision, error) {
+	var revisionsSQL []RevisionSQL
+
+	err := s.db.From(s.revisionTableName).
+		Where(goqu.C(columnPostID).Eq(postID)).
+		Order(goqu.C(columnNumber).Asc()).
+		ScanStructsContext(ctx, &revisionsSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]post.Revision, len(revisionsSQL))
+
+	for i, revisionSQL := range revisionsSQL {
+		revisions[i] = NewRevisionFromSQL(revisionSQL)
+	}
+
+	return revisions, nil
+}
+
+func (s *storage) Revision(ctx context.Context, postID string, number int64) (post.Revision, error) {
+	var revisionSQL RevisionSQL
+
+	ok, err := s.db.From(s.revisionTableName).
+		Where(goqu.C(columnPostID).Eq(postID), goqu.C(columnNumber).Eq(number)).
+		ScanStructContext(ctx, &revisionSQL)
+	if err != nil {
+		return post.Revision{}, err
+	}
+
+	if !ok {
+		return post.Revision{}, post.ErrNotFound
+	}
+
+	return NewRevisionFromSQL(revisionSQL), nil
+}
+
+func (s *storage) Tags(ctx context.Context) ([]post.Count, error) {
+	q := s.db.From(s.postTagTableName).
+		Join(
+			goqu.T(tagTableName),
+			goqu.On(goqu.T(tagTableName).Col(columnID).Eq(goqu.T(s.postTagTableName).Col(columnTagID))),
+		).
+		Join(
+			goqu.T(s.postTableName),
+			goqu.On(goqu.T(s.postTableName).Col(columnID).Eq(goqu.T(s.postTagTableName).Col(columnPostID))),
+		).
+		Select(
+			goqu.T(tagTableName).Col(columnName),
+			goqu.COUNT(goqu.Star()).As(columnPosts),
+		).
+		Where(
+			goqu.T(s.postTableName).Col(columnDeletedAt).IsNull(),
+			goqu.T(s.postTableName).Col(columnStatus).Eq(post.StatusPublished),
+		).
+		GroupBy(goqu.T(tagTableName).Col(columnName))
+
+	return s.counts(ctx, q)
+}
+
+func (s *storage) Categories(ctx context.Context) ([]post.Count, error) {
+	q := s.db.From(s.postTableName).
+		Select(
+			goqu.C(columnCategory).As(columnName),
+			goqu.COUNT(goqu.Star()).As(columnPosts),
+		).
+		Where(
+			goqu.C(columnDeletedAt).IsNull(),
+			goqu.C(columnStatus).Eq(post.StatusPublished),
+			goqu.C(columnCategory).Neq(""),
+		).
+		GroupBy(goqu.C(columnCategory))
+
+	return s.counts(ctx, q)
+}
+
+// counts orders the name, posts rows by the number of posts
+func (s *storage) counts(ctx context.Context, q *goqu.SelectDataset) ([]post.Count, error) {
+	var countsSQL []CountSQL
+
+	err := q.Order(goqu.I(columnPosts).Desc(), goqu.I(columnName).Asc()).
+		ScanStructsContext(ctx, &countsSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]post.Count, len(countsSQL))
+
+	for i, countSQL := range countsSQL {
+		counts[i] = NewCountFromSQL(countSQL)
+	}
+
+	return counts, nil
+}
+
+// setTags replaces the tags of the post creating the missing ones
+func (s *storage) setTags(ctx context.Context, tx *goqu.TxDatabase, postID string, tags []string) error {
+	_, err := tx.Delete(s.postTagTableName).
+		Where(goqu.C(columnPostID).Eq(postID)).
+		Executor().
+		ExecContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return post.ErrNotFound
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, len(tags))
+
+	for i, tag := range tags {
+		rows[i] = goqu.Record{columnName: tag}
+	}
+
+	_, err = tx.Insert(tagTableName).
+		Rows(rows...).
+		OnConflict(goqu.DoNothing()).
+		Executor().
+		ExecContext(ctx)
+	if err != nil {
+		return err
 	}
 
+	tagIDs := tx.From(tagTableName).
+		Select(goqu.V(postID), goqu.C(columnID)).
+		Where(goqu.C(columnName).In(tags))
+
+	_, err = tx.Insert(s.postTagTableName).
+		Cols(columnPostID, columnTagID).
+		FromQuery(tagIDs).
+		Executor().
+		ExecContext(ctx)
+
 	return err
 }
 
-func (s *storage) Remove(id string) error {
-	_, err := s.db.Delete(s.postTableName).Where(goqu.C(columnID).Eq(id)).Executor().Exec()
+// insertRevision snapshots the current state of the post as its next revision
+func (s *storage) insertRevision(ctx context.Context, tx *goqu.TxDatabase, postID string) error {
+	nextNumber := tx.From(s.revisionTableName).
+		Select(goqu.L("COALESCE(MAX(?), 0) + 1", goqu.C(columnNumber))).
+		Where(goqu.C(columnPostID).Eq(postID))
+
+	tags := s.taggedPosts().
+		Select(goqu.T(tagTableName).Col(columnName)).
+		Order(goqu.T(tagTableName).Col(columnName).Asc())
+
+	snapshot := tx.From(s.postTableName).
+		Select(
+			goqu.C(columnID),
+			nextNumber,
+			goqu.V(post.ActorFromContext(ctx).ID),
+			goqu.C(columnTitle),
+			goqu.C(columnContent),
+			goqu.L("ARRAY?", tags),
+			goqu.C(columnCategory),
+			goqu.C(columnUpdatedAt),
+		).
+		Where(goqu.C(columnID).Eq(postID))
+
+	_, err := tx.Insert(s.revisionTableName).
+		Cols(
+			columnPostID,
+			columnNumber,
+			columnAuthor,
+			columnTitle,
+			columnContent,
+			columnTags,
+			columnCategory,
+			columnCreatedAt,
+		).
+		FromQuery(snapshot).
+		Executor().
+		ExecContext(ctx)
 
 	return err
 }
+
+func (s *storage) inTx(ctx context.Context, fn func(tx *goqu.TxDatabase) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Wrap(func() error {
+		return fn(tx)
+	})
+}
+
+// live matches the post unless it is soft deleted
+func live(id string, version int64) goqu.Ex {
+	ex := byIDAndVersion(id, version)
+	ex[columnDeletedAt] = nil
+
+	return ex
+}
+
+// byIDAndVersion matches any version of the post if version is zero
+func byIDAndVersion(id string, version int64) goqu.Ex {
+	ex := goqu.Ex{columnID: id}
+
+	if version != 0 {
+		ex[columnVersion] = version
+	}
+
+	return ex
+}
+
+// checkAffected turns a no-op modification into ErrNotFound, or ErrVersionMismatch if the version was expected
+func checkAffected(res sql.Result, version int64) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	if version != 0 {
+		return post.ErrVersionMismatch
+	}
+
+	return post.ErrNotFound
+}
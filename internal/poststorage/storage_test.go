@@ -9,7 +9,7 @@ import (
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
 	_ "github.com/lib/pq"
 	"github.com/ory/dockertest/v3"
-	"github.com/sladonia/news-svc/internal/post"
+	"github.com/sladonia/news-svc/internal/storagetest"
 	"github.com/sladonia/news-svc/internal/testtool"
 	"github.com/stretchr/testify/suite"
 )
@@ -20,30 +20,18 @@ const (
 	migrationsDir = "../../migration"
 )
 
-// db fixtures
-var (
-	post1 = post.Post{
-		ID:        "1",
-		Title:     "exclusive",
-		Content:   "new era beginning!",
-		CreatedAt: time.Now().UTC().Round(time.Millisecond),
-		UpdatedAt: time.Now().UTC().Round(time.Millisecond),
-	}
-)
-
 type Suite struct {
-	suite.Suite
+	storagetest.Suite
 
 	db                *goqu.Database
-	storage           post.Storage
 	dockerPool        *dockertest.Pool
 	postgresContainer *dockertest.Resource
 }
 
 func (s *Suite) SetupSuite() {
-	pool, err := dockertest.NewPool("")
+	pool, err := testtool.NewPool()
 	if err != nil {
-		panic(err)
+		s.T().Skipf("docker is not available: %s", err)
 	}
 	s.dockerPool = pool
 
@@ -69,7 +57,9 @@ func (s *Suite) SetupSuite() {
 	}
 
 	s.db = goqu.New("postgres", postgresClient)
-	s.storage = New(s.db, postTableName)
+	s.Storage = New(s.db, postTableName)
+	s.Authors = NewAuthorStorage(s.db)
+	s.Truncate = s.deleteAllData
 
 	loc, err := time.LoadLocation("UTC")
 	if err != nil {
@@ -77,7 +67,7 @@ func (s *Suite) SetupSuite() {
 	}
 	goqu.SetTimeLocation(loc)
 
-	err = testtool.ApplyDBMigrations(s.db, migrationsDir)
+	err = testtool.ApplyDBMigrations(postgresClient, migrationsDir)
 	if err != nil {
 		panic(err)
 	}
@@ -94,142 +84,13 @@ func TestRunSuite(t *testing.T) {
 	suite.Run(t, new(Suite))
 }
 
-func (s *Suite) SetupTest() {
-	err := s.insertFixtures()
-	if err != nil {
-		panic(err)
-	}
-}
-
-func (s *Suite) TearDownTest() {
-	err := s.deleteAllData()
+func (s *Suite) deleteAllData() error {
+	_, err := s.db.Delete(postTableName).Executor().Exec()
 	if err != nil {
-		panic(err)
+		return err
 	}
-}
-
-func (s *Suite) TestInsertPost() {
-	s.Run("success", func() {
-		p2 := post.NewPost("title2", "content2")
-		p2.ID = "2"
-
-		err := s.storage.Insert(p2)
-		s.NoError(err)
-
-		fromStorage, err := s.storage.ByID("2")
-
-		s.NoError(err)
-		s.Equal(p2, fromStorage)
-	})
-
-	s.Run("conflict", func() {
-		err := s.storage.Insert(post1)
-		s.Error(err)
-		s.ErrorIs(err, post.ErrorAlreadyExists)
-	})
-}
-
-func (s *Suite) TestByID() {
-	s.Run("success", func() {
-		p, err := s.storage.ByID("1")
-
-		s.NoError(err)
-		s.Equal(post1, p)
-	})
-
-	s.Run("no_documents", func() {
-		_, err := s.storage.ByID("unexisting")
-
-		s.Error(err)
-		s.ErrorIs(err, post.ErrNotFound)
-	})
-}
-
-func (s *Suite) TestByFilter() {
-	s.Run("latest", func() {
-		f := post.Filter{
-			Limit:  10,
-			Offset: 0,
-		}
 
-		posts, err := s.storage.ByFilter(f)
-		s.NoError(err)
-		s.Len(posts, 1)
-	})
-
-	s.Run("after_date", func() {
-		f := post.Filter{
-			From:   time.Now().Add(-time.Hour),
-			Limit:  10,
-			Offset: 0,
-		}
-
-		posts, err := s.storage.ByFilter(f)
-		s.NoError(err)
-		s.Len(posts, 1)
-	})
-
-	s.Run("no_results_found", func() {
-		f := post.Filter{
-			From:   time.Now().Add(-2 * time.Hour),
-			To:     time.Now().Add(-1 * time.Hour),
-			Limit:  10,
-			Offset: 0,
-		}
-
-		posts, err := s.storage.ByFilter(f)
-		s.NoError(err)
-		s.Len(posts, 0)
-	})
-}
-
-func (s *Suite) TestReplace() {
-	s.Run("replace", func() {
-		newTitle := "new_title"
-		newContent := "new_content"
-
-		err := s.storage.Update("1", newTitle, newContent)
-		s.NoError(err)
-
-		retrieved, err := s.storage.ByID(post1.ID)
-		s.NoError(err)
-		s.Equal(newTitle, retrieved.Title)
-		s.Equal(newContent, retrieved.Content)
-		s.Equal(post1.CreatedAt, retrieved.CreatedAt)
-		s.True(retrieved.UpdatedAt.After(post1.UpdatedAt))
-	})
-
-	s.Run("not_found", func() {
-		err := s.storage.Update("unexisting", "eq", "qw")
-		s.Error(err)
-		s.ErrorIs(err, post.ErrNotFound)
-	})
-}
-
-func (s *Suite) TestRemove() {
-	s.Run("no_documents", func() {
-		err := s.storage.Remove("42")
-		s.NoError(err)
-	})
-
-	s.Run("success", func() {
-		err := s.storage.Remove("1")
-		s.NoError(err)
-
-		_, err = s.storage.ByID("1")
-		s.ErrorIs(err, post.ErrNotFound)
-	})
-}
-
-func (s *Suite) insertFixtures() error {
-	post1SQL := NewPostSQL(post1)
-	_, err := s.db.Insert(postTableName).Rows(post1SQL).Executor().Exec()
-
-	return err
-}
-
-func (s *Suite) deleteAllData() error {
-	_, err := s.db.Delete(postTableName).Executor().Exec()
+	_, err = s.db.Delete(authorTableName).Executor().Exec()
 
 	return err
 }
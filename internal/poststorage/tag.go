@@ -0,0 +1,23 @@
+package poststorage
+
+import "github.com/sladonia/news-svc/internal/post"
+
+const (
+	tagTableName       = "tag"
+	postTagTableSuffix = "_tag"
+	columnTagID        = "tag_id"
+	columnName         = "name"
+	columnPosts        = "posts"
+)
+
+type CountSQL struct {
+	Name  string `db:"name"`
+	Posts int64  `db:"posts"`
+}
+
+func NewCountFromSQL(countSQL CountSQL) post.Count {
+	return post.Count{
+		Name:  countSQL.Name,
+		Posts: countSQL.Posts,
+	}
+}
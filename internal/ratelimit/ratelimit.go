@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is the token bucket of Burst tokens refilled at Rate tokens per second.
+// Every request takes a token. Zero Limit means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) IsZero() bool {
+	return l.Rate == 0 && l.Burst == 0
+}
+
+// Result of taking the token from the bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // burst of the bucket
+	Remaining  int           // whole tokens left in the bucket
+	RetryAfter time.Duration // until the next token is available, zero if allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// Store keeps the buckets of the clients. Store shared by the service instances makes them share the quotas.
+type Store interface {
+	// Take takes a token from the bucket of the key, creating a full bucket if there is none
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take takes a token from the bucket with tokens left at the updated time and returns the tokens left now
+func take(tokens float64, updated time.Time, limit Limit, now time.Time) (float64, Result) {
+	burst := float64(limit.Burst)
+
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*limit.Rate)
+	}
+
+	result := Result{Limit: limit.Burst}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = seconds((burst - tokens) / limit.Rate)
+
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	if math.IsInf(s, 0) || math.IsNaN(s) {
+		return 0
+	}
+
+	return time.Duration(s * float64(time.Second))
+}
+
+// ParseLimits parses comma separated route limits as route=rate:burst, e.g. "createPost=1:5,findPosts=10:20"
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid route limit %q, expected route=rate:burst", item)
+		}
+
+		route := strings.TrimSpace(parts[0])
+
+		limit, err := ParseLimit(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route, err)
+		}
+
+		limits[route] = limit
+	}
+
+	return limits, nil
+}
+
+// ParseLimit parses rate:burst limit, 0:0 means no limit
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid limit %q, expected rate:burst", s)
+	}
+
+	var (
+		rate, burst = parts[0], parts[1]
+		limit       Limit
+		err         error
+	)
+
+	limit.Rate, err = strconv.ParseFloat(rate, 64)
+	if err != nil || limit.Rate < 0 {
+		return Limit{}, fmt.Errorf("invalid rate %q", rate)
+	}
+
+	limit.Burst, err = strconv.Atoi(burst)
+	if err != nil || limit.Burst < 0 {
+		return Limit{}, fmt.Errorf("invalid burst %q", burst)
+	}
+
+	if !limit.IsZero() && (limit.Rate == 0 || limit.Burst == 0) {
+		return Limit{}, fmt.Errorf("invalid limit %q, rate and burst should be both positive or both zero", s)
+	}
+
+	return limit, nil
+}
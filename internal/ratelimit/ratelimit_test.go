@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		s     = NewMemoryStore()
+		ctx   = context.Background()
+		limit = Limit{Rate: 2, Burst: 3}
+		now   = time.Now()
+	)
+
+	t.Run("burst", func(t *testing.T) {
+		for i := 2; i >= 0; i-- {
+			result, err := s.Take(ctx, "bob", limit, now)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 3, result.Limit)
+			assert.Equal(t, i, result.Remaining)
+		}
+
+		result, err := s.Take(ctx, "bob", limit, now)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+		assert.Equal(t, 1500*time.Millisecond, result.Reset)
+	})
+
+	t.Run("other_key", func(t *testing.T) {
+		result, err := s.Take(ctx, "alice", limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		result, err := s.Take(ctx, "bob", limit, now.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		result, err = s.Take(ctx, "bob", limit, now.Add(10*time.Second))
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Remaining)
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		_, err := s.Take(ctx, "bob", limit, now.Add(10*time.Minute))
+		require.NoError(t, err)
+
+		assert.Len(t, s.(*memoryStore).buckets, 1)
+	})
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("createPost=1:5, findPosts=0.5:10,healthz=0:0,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"createPost": {Rate: 1, Burst: 5},
+		"findPosts":  {Rate: 0.5, Burst: 10},
+		"healthz":    {},
+	}, limits)
+
+	for _, s := range []string{"createPost", "createPost=1", "createPost=a:5", "createPost=1:-1", "createPost=0:5"} {
+		_, err = ParseLimits(s)
+		assert.Error(t, err, s)
+	}
+}
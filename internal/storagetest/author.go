@@ -0,0 +1,108 @@
+package storagetest
+
+import (
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+)
+
+func (s *Suite) TestAuthors() {
+	bob := author.NewAuthor("bob", "bob", "bob@example.com", "")
+	alice := author.NewAuthor("alice", "alice", "", "reporter")
+
+	s.NoError(s.Authors.Insert(ctx, bob))
+	s.NoError(s.Authors.Insert(ctx, alice))
+	s.ErrorIs(s.Authors.Insert(ctx, bob), author.ErrAlreadyExists)
+
+	s.Run("by_id", func() {
+		a, err := s.Authors.ByID(ctx, bob.ID)
+		s.NoError(err)
+		s.Equal(bob, a)
+
+		_, err = s.Authors.ByID(ctx, "unexisting_id")
+		s.ErrorIs(err, author.ErrNotFound)
+	})
+
+	s.Run("list", func() {
+		authors, err := s.Authors.List(ctx, 0, 0)
+		s.NoError(err)
+		s.Equal([]author.Author{alice, bob}, authors)
+
+		authors, err = s.Authors.List(ctx, 1, 1)
+		s.NoError(err)
+		s.Equal([]author.Author{bob}, authors)
+
+		count, err := s.Authors.Count(ctx)
+		s.NoError(err)
+		s.Equal(int64(2), count)
+	})
+
+	s.Run("update", func() {
+		updated := bob
+		updated.Name = "robert"
+
+		s.NoError(s.Authors.Update(ctx, updated))
+
+		a, err := s.Authors.ByID(ctx, bob.ID)
+		s.NoError(err)
+		s.Equal("robert", a.Name)
+		s.Equal(bob.Email, a.Email)
+
+		updated.ID = "unexisting_id"
+		s.ErrorIs(s.Authors.Update(ctx, updated), author.ErrNotFound)
+	})
+
+	s.Run("remove", func() {
+		s.NoError(s.Authors.Remove(ctx, bob.ID))
+		s.NoError(s.Authors.Remove(ctx, bob.ID))
+
+		_, err := s.Authors.ByID(ctx, bob.ID)
+		s.ErrorIs(err, author.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestByFilterAuthor() {
+	authored := post.NewPost("authored", "authored content")
+	authored.AuthorID = "bob"
+
+	s.NoError(s.Storage.Insert(ctx, authored))
+
+	posts, err := s.Storage.ByFilter(ctx, post.Filter{AuthorID: "bob", Limit: 10})
+	s.NoError(err)
+	s.Equal([]post.Post{authored}, posts)
+}
+
+func (s *Suite) TestAuthorOfFirstPost() {
+	named := author.NewAuthor("alice", "Alice", "alice@example.com", "")
+	s.NoError(s.Authors.Insert(ctx, named))
+
+	for _, authorID := range []string{"bob", "bob", "alice"} {
+		p := post.NewPost("authored", "authored content")
+		p.AuthorID = authorID
+
+		s.NoError(s.Storage.Insert(ctx, p))
+	}
+
+	a, err := s.Authors.ByID(ctx, "bob")
+	s.NoError(err)
+	s.Equal("bob", a.Name, "profile is named after the principal")
+
+	a, err = s.Authors.ByID(ctx, "alice")
+	s.NoError(err)
+	s.Equal(named, a, "existing profile is kept")
+
+	count, err := s.Authors.Count(ctx)
+	s.NoError(err)
+	s.Equal(int64(2), count)
+}
+
+func (s *Suite) TestRemovedAuthorPosts() {
+	authored := post.NewPost("authored", "authored content")
+	authored.AuthorID = "bob"
+	s.NoError(s.Storage.Insert(ctx, authored))
+
+	s.NoError(s.Authors.Remove(ctx, "bob"))
+
+	p, err := s.Storage.ByID(ctx, authored.ID)
+	s.NoError(err)
+	s.Empty(p.AuthorID, "posts of the removed author become anonymous")
+}
@@ -0,0 +1,695 @@
+package storagetest
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/post"
+	"github.com/stretchr/testify/suite"
+)
+
+var ctx = context.Background()
+
+// db fixtures
+var (
+	post1 = post.Post{
+		ID:        "1",
+		Title:     "exclusive",
+		Content:   "new era beginning!",
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		UpdatedAt: time.Now().UTC().Round(time.Millisecond),
+		Version:   1,
+		Status:    post.StatusPublished,
+	}
+)
+
+// Suite is a conformance suite every post.Storage and author.Storage implementation has to pass.
+// Embedding suite is responsible for setting Storage, Authors and Truncate in its SetupSuite.
+type Suite struct {
+	suite.Suite
+
+	Storage  post.Storage
+	Authors  author.Storage
+	Truncate func() error
+}
+
+func (s *Suite) SetupTest() {
+	err := s.Storage.Insert(ctx, post1)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Suite) TearDownTest() {
+	err := s.Truncate()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Suite) TestInsertPost() {
+	s.Run("success", func() {
+		p2 := post.NewPost("title2", "content2")
+		p2.ID = "2"
+
+		err := s.Storage.Insert(ctx, p2)
+		s.NoError(err)
+
+		fromStorage, err := s.Storage.ByID(ctx, "2")
+
+		s.NoError(err)
+		s.Equal(p2, fromStorage)
+	})
+
+	s.Run("conflict", func() {
+		err := s.Storage.Insert(ctx, post1)
+		s.Error(err)
+		s.ErrorIs(err, post.ErrorAlreadyExists)
+	})
+}
+
+func (s *Suite) TestByID() {
+	s.Run("success", func() {
+		p, err := s.Storage.ByID(ctx, "1")
+
+		s.NoError(err)
+		s.Equal(post1, p)
+	})
+
+	s.Run("no_documents", func() {
+		_, err := s.Storage.ByID(ctx, "unexisting")
+
+		s.Error(err)
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestByFilter() {
+	s.Run("latest", func() {
+		f := post.Filter{
+			Limit:  10,
+			Offset: 0,
+		}
+
+		posts, err := s.Storage.ByFilter(ctx, f)
+		s.NoError(err)
+		s.Len(posts, 1)
+	})
+
+	s.Run("after_date", func() {
+		f := post.Filter{
+			From:   time.Now().Add(-time.Hour),
+			Limit:  10,
+			Offset: 0,
+		}
+
+		posts, err := s.Storage.ByFilter(ctx, f)
+		s.NoError(err)
+		s.Len(posts, 1)
+	})
+
+	s.Run("no_results_found", func() {
+		f := post.Filter{
+			From:   time.Now().Add(-2 * time.Hour),
+			To:     time.Now().Add(-1 * time.Hour),
+			Limit:  10,
+			Offset: 0,
+		}
+
+		posts, err := s.Storage.ByFilter(ctx, f)
+		s.NoError(err)
+		s.NotNil(posts)
+		s.Len(posts, 0)
+	})
+
+	s.Run("inclusive_bounds", func() {
+		f := post.Filter{
+			From:  post1.CreatedAt,
+			To:    post1.CreatedAt,
+			Limit: 10,
+		}
+
+		posts, err := s.Storage.ByFilter(ctx, f)
+		s.NoError(err)
+		s.Equal([]post.Post{post1}, posts)
+	})
+}
+
+func (s *Suite) TestCanceledContext() {
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := s.Storage.ByID(canceledCtx, post1.ID)
+	s.ErrorIs(err, context.Canceled)
+
+	_, err = s.Storage.ByFilter(canceledCtx, post.Filter{Limit: 10})
+	s.ErrorIs(err, context.Canceled)
+
+	err = s.Storage.Update(canceledCtx, post.Post{ID: post1.ID, Title: "title", Content: "content"})
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *Suite) TestByFilterOrdering() {
+	older := post.NewPost("older", "older content")
+	older.CreatedAt = post1.CreatedAt.Add(-time.Minute)
+
+	newer := post.NewPost("newer", "newer content")
+	newer.CreatedAt = post1.CreatedAt.Add(time.Minute)
+
+	s.NoError(s.Storage.Insert(ctx, older))
+	s.NoError(s.Storage.Insert(ctx, newer))
+
+	s.Run("created_at_desc", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 10})
+		s.NoError(err)
+		s.Equal([]post.Post{newer, post1, older}, posts)
+	})
+
+	s.Run("limit_offset", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 1, Offset: 1})
+		s.NoError(err)
+		s.Equal([]post.Post{post1}, posts)
+	})
+
+	s.Run("offset_out_of_range", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 10, Offset: 3})
+		s.NoError(err)
+		s.Len(posts, 0)
+	})
+
+	s.Run("count", func() {
+		count, err := s.Storage.Count(ctx, post.Filter{Limit: 1, Offset: 1})
+		s.NoError(err)
+		s.Equal(int64(3), count)
+
+		cursor := post.NewCursor(newer)
+
+		count, err = s.Storage.Count(ctx, post.Filter{After: &cursor, From: post1.CreatedAt})
+		s.NoError(err)
+		s.Equal(int64(2), count)
+	})
+}
+
+func (s *Suite) TestByFilterCursor() {
+	older := post.NewPost("older", "older content")
+	older.CreatedAt = post1.CreatedAt.Add(-time.Minute)
+
+	sameTime := post.NewPost("same time", "same time content")
+	sameTime.ID = "0"
+	sameTime.CreatedAt = post1.CreatedAt
+
+	s.NoError(s.Storage.Insert(ctx, older))
+	s.NoError(s.Storage.Insert(ctx, sameTime))
+
+	s.Run("pages", func() {
+		first, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 2})
+		s.NoError(err)
+		s.Equal([]post.Post{post1, sameTime}, first)
+
+		cursor := post.NewCursor(first[len(first)-1])
+
+		second, err := s.Storage.ByFilter(ctx, post.Filter{After: &cursor, Limit: 2})
+		s.NoError(err)
+		s.Equal([]post.Post{older}, second)
+	})
+
+	s.Run("with_offset", func() {
+		cursor := post.NewCursor(post1)
+
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{After: &cursor, Limit: 2, Offset: 1})
+		s.NoError(err)
+		s.Equal([]post.Post{older}, posts)
+	})
+
+	s.Run("last_page", func() {
+		cursor := post.NewCursor(older)
+
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{After: &cursor, Limit: 2})
+		s.NoError(err)
+		s.Len(posts, 0)
+	})
+}
+
+func (s *Suite) TestByFilterQuery() {
+	inTitle := post.NewPost("Election results", "the votes are counted")
+	inContent := post.NewPost("Weather", "sunny on the election day")
+
+	s.NoError(s.Storage.Insert(ctx, inTitle))
+	s.NoError(s.Storage.Insert(ctx, inContent))
+
+	s.Run("ranked", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Query: "election", Limit: 10})
+		s.NoError(err)
+		s.Require().Len(posts, 2)
+
+		s.Equal(inTitle.ID, posts[0].ID)
+		s.Equal(inContent.ID, posts[1].ID)
+		s.Greater(posts[0].Rank, posts[1].Rank)
+		s.Contains(posts[0].Snippet, "<b>Election</b>")
+		s.Contains(posts[1].Snippet, "<b>election</b>")
+	})
+
+	s.Run("escaped_snippet", func() {
+		markup := post.NewPost("Markup", `<script>alert(1)</script> polls & elections "today"`)
+		s.NoError(s.Storage.Insert(ctx, markup))
+		defer func() { s.NoError(s.Storage.Purge(ctx, markup.ID, 0)) }()
+
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Query: "polls", Limit: 10})
+		s.NoError(err)
+		s.Require().Len(posts, 1)
+		s.Contains(posts[0].Snippet, "<b>polls</b> &amp;")
+		s.Contains(posts[0].Snippet, "&lt;script&gt;")
+		s.NotContains(posts[0].Snippet, "<script>")
+	})
+
+	s.Run("hyphenated_match", func() {
+		long := post.NewPost("Gadgets", strings.Repeat("plain words of the review ", 8)+"a state-of-the-art phone")
+		s.NoError(s.Storage.Insert(ctx, long))
+		defer func() { s.NoError(s.Storage.Purge(ctx, long.ID, 0)) }()
+
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Query: "art", Limit: 10})
+		s.NoError(err)
+		s.Require().Len(posts, 1)
+		s.Contains(posts[0].Snippet, "<b>art</b>")
+	})
+
+	s.Run("all_terms_required", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Query: "election weather", Limit: 10})
+		s.NoError(err)
+		s.Require().Len(posts, 1)
+		s.Equal(inContent.ID, posts[0].ID)
+	})
+
+	s.Run("no_match", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Query: "football", Limit: 10})
+		s.NoError(err)
+		s.Len(posts, 0)
+	})
+
+	s.Run("count", func() {
+		count, err := s.Storage.Count(ctx, post.Filter{Query: "election", Limit: 1})
+		s.NoError(err)
+		s.Equal(int64(2), count)
+	})
+
+	s.Run("not_ranked_without_query", func() {
+		p, err := s.Storage.ByID(ctx, inTitle.ID)
+		s.NoError(err)
+		s.Zero(p.Rank)
+		s.Empty(p.Snippet)
+	})
+}
+
+func (s *Suite) TestReplace() {
+	s.Run("replace", func() {
+		newTitle := "new_title"
+		newContent := "new_content"
+
+		err := s.Storage.Update(ctx, post.Post{ID: "1", Title: newTitle, Content: newContent})
+		s.NoError(err)
+
+		retrieved, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Equal(newTitle, retrieved.Title)
+		s.Equal(newContent, retrieved.Content)
+		s.Equal(post1.CreatedAt, retrieved.CreatedAt)
+		s.True(retrieved.UpdatedAt.After(post1.UpdatedAt))
+		s.Equal(post1.Version+1, retrieved.Version)
+	})
+
+	s.Run("not_found", func() {
+		err := s.Storage.Update(ctx, post.Post{ID: "unexisting", Title: "eq", Content: "qw"})
+		s.Error(err)
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestReplaceVersion() {
+	s.Run("version_mismatch", func() {
+		err := s.Storage.Update(ctx, post.Post{ID: "1", Title: "title", Content: "content", Version: 42})
+		s.ErrorIs(err, post.ErrVersionMismatch)
+
+		retrieved, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Equal(post1, retrieved)
+	})
+
+	s.Run("version_match", func() {
+		err := s.Storage.Update(ctx, post.Post{ID: "1", Title: "title", Content: "content", Version: post1.Version})
+		s.NoError(err)
+
+		retrieved, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Equal("title", retrieved.Title)
+		s.Equal(post1.Version+1, retrieved.Version)
+	})
+
+	s.Run("not_found", func() {
+		err := s.Storage.Update(ctx, post.Post{ID: "unexisting", Title: "title", Content: "content", Version: 1})
+		s.ErrorIs(err, post.ErrVersionMismatch)
+	})
+}
+
+func (s *Suite) TestPatch() {
+	s.Run("title_only", func() {
+		title := "patched title"
+
+		err := s.Storage.Patch(ctx, post1.ID, post.Patch{Title: &title}, 0)
+		s.NoError(err)
+
+		retrieved, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Equal(title, retrieved.Title)
+		s.Equal(post1.Content, retrieved.Content)
+		s.True(retrieved.UpdatedAt.After(post1.UpdatedAt))
+		s.Equal(post1.Version+1, retrieved.Version)
+	})
+
+	s.Run("version_mismatch", func() {
+		content := "patched content"
+
+		err := s.Storage.Patch(ctx, post1.ID, post.Patch{Content: &content}, post1.Version)
+		s.ErrorIs(err, post.ErrVersionMismatch)
+	})
+
+	s.Run("content_with_version", func() {
+		content := "patched content"
+
+		err := s.Storage.Patch(ctx, post1.ID, post.Patch{Content: &content}, post1.Version+1)
+		s.NoError(err)
+
+		retrieved, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Equal("patched title", retrieved.Title)
+		s.Equal(content, retrieved.Content)
+	})
+
+	s.Run("not_found", func() {
+		title := "patched title"
+
+		err := s.Storage.Patch(ctx, "unexisting", post.Patch{Title: &title}, 0)
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestRemove() {
+	s.Run("no_documents", func() {
+		err := s.Storage.Remove(ctx, "42", 0)
+		s.NoError(err)
+	})
+
+	s.Run("version_mismatch", func() {
+		err := s.Storage.Remove(ctx, "1", 42)
+		s.ErrorIs(err, post.ErrVersionMismatch)
+
+		_, err = s.Storage.ByID(ctx, "1")
+		s.NoError(err)
+	})
+
+	s.Run("success", func() {
+		err := s.Storage.Remove(ctx, "1", post1.Version)
+		s.NoError(err)
+
+		_, err = s.Storage.ByID(ctx, "1")
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestSoftDelete() {
+	s.Require().NoError(s.Storage.Remove(ctx, post1.ID, 0))
+
+	s.Run("excluded", func() {
+		_, err := s.Storage.ByID(ctx, post1.ID)
+		s.ErrorIs(err, post.ErrNotFound)
+
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 10})
+		s.NoError(err)
+		s.Len(posts, 0)
+
+		posts, err = s.Storage.ByFilter(ctx, post.Filter{Query: "era", Limit: 10})
+		s.NoError(err)
+		s.Len(posts, 0)
+
+		err = s.Storage.Update(ctx, post.Post{ID: post1.ID, Title: "title", Content: "content"})
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+
+	s.Run("trash", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Deleted: true, Limit: 10})
+		s.NoError(err)
+		s.Require().Len(posts, 1)
+		s.Equal(post1.ID, posts[0].ID)
+		s.NotNil(posts[0].DeletedAt)
+		s.Equal(post1.Version+1, posts[0].Version)
+	})
+
+	s.Run("restore", func() {
+		err := s.Storage.Restore(ctx, post1.ID)
+		s.NoError(err)
+
+		p, err := s.Storage.ByID(ctx, post1.ID)
+		s.NoError(err)
+		s.Nil(p.DeletedAt)
+
+		err = s.Storage.Restore(ctx, post1.ID)
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+
+	s.Run("restore_unexisting", func() {
+		err := s.Storage.Restore(ctx, "unexisting")
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+}
+
+func (s *Suite) TestPurge() {
+	s.Run("version_mismatch", func() {
+		err := s.Storage.Purge(ctx, post1.ID, 42)
+		s.ErrorIs(err, post.ErrVersionMismatch)
+	})
+
+	s.Run("soft_deleted", func() {
+		s.Require().NoError(s.Storage.Remove(ctx, post1.ID, 0))
+
+		err := s.Storage.Purge(ctx, post1.ID, 0)
+		s.NoError(err)
+
+		err = s.Storage.Restore(ctx, post1.ID)
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+
+	s.Run("unexisting", func() {
+		err := s.Storage.Purge(ctx, "unexisting", 0)
+		s.NoError(err)
+	})
+}
+
+func (s *Suite) TestPurgeDeleted() {
+	live := post.NewPost("live", "live content")
+	deleted := post.NewPost("deleted", "deleted content")
+
+	s.NoError(s.Storage.Insert(ctx, live))
+	s.NoError(s.Storage.Insert(ctx, deleted))
+	s.NoError(s.Storage.Remove(ctx, deleted.ID, 0))
+	s.NoError(s.Storage.Remove(ctx, post1.ID, 0))
+
+	purged, err := s.Storage.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	s.NoError(err)
+	s.Zero(purged)
+
+	purged, err = s.Storage.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	s.NoError(err)
+	s.Equal(int64(2), purged)
+
+	_, err = s.Storage.ByID(ctx, live.ID)
+	s.NoError(err)
+
+	posts, err := s.Storage.ByFilter(ctx, post.Filter{Deleted: true, Limit: 10})
+	s.NoError(err)
+	s.Len(posts, 0)
+}
+
+func (s *Suite) TestRevisions() {
+	authorCtx := post.WithActor(ctx, post.Actor{ID: "editor"})
+
+	updated := post1
+	updated.Title = "updated"
+	updated.Tags = []string{"covid", "health"}
+	updated.Category = "world"
+	s.NoError(s.Storage.Update(authorCtx, updated))
+
+	title := "patched"
+	s.NoError(s.Storage.Patch(ctx, post1.ID, post.Patch{Title: &title}, 0))
+
+	revisions, err := s.Storage.Revisions(ctx, post1.ID)
+	s.NoError(err)
+	s.Require().Len(revisions, 3)
+
+	for i, revision := range revisions {
+		s.Equal(int64(i+1), revision.Number)
+		s.Equal(post1.ID, revision.PostID)
+		s.Equal(post1.Content, revision.Content)
+	}
+
+	s.Equal(post1.Title, revisions[0].Title)
+	s.Equal("", revisions[0].Author)
+	s.Equal("updated", revisions[1].Title)
+	s.Equal("editor", revisions[1].Author)
+	s.Require().NotNil(revisions[1].Tags)
+	s.Equal([]string{"covid", "health"}, *revisions[1].Tags)
+	s.Require().NotNil(revisions[1].Category)
+	s.Equal("world", *revisions[1].Category)
+	s.Equal("patched", revisions[2].Title)
+	s.Require().NotNil(revisions[2].Tags)
+	s.Equal([]string{"covid", "health"}, *revisions[2].Tags, "tags are recorded unless patched")
+	s.Require().NotNil(revisions[0].Tags, "empty tags are recorded as well")
+	s.Empty(*revisions[0].Tags)
+	s.Require().NotNil(revisions[0].Category)
+	s.Empty(*revisions[0].Category)
+
+	revision, err := s.Storage.Revision(ctx, post1.ID, 2)
+	s.NoError(err)
+	s.Equal(revisions[1], revision)
+
+	_, err = s.Storage.Revision(ctx, post1.ID, 4)
+	s.ErrorIs(err, post.ErrNotFound)
+
+	s.NoError(s.Storage.Purge(ctx, post1.ID, 0))
+
+	revisions, err = s.Storage.Revisions(ctx, post1.ID)
+	s.NoError(err)
+	s.Len(revisions, 0)
+}
+
+func (s *Suite) TestByFilterStatuses() {
+	draft := post.NewPost("draft", "draft content")
+	s.NoError(s.Storage.Insert(ctx, draft))
+
+	posts, err := s.Storage.ByFilter(ctx, post.Filter{Limit: 10})
+	s.NoError(err)
+	s.Len(posts, 2)
+
+	posts, err = s.Storage.ByFilter(ctx, post.Filter{Statuses: []post.Status{post.StatusDraft}, Limit: 10})
+	s.NoError(err)
+	s.Equal([]post.Post{draft}, posts)
+
+	posts, err = s.Storage.ByFilter(ctx, post.Filter{
+		Statuses: []post.Status{post.StatusPublished, post.StatusArchived},
+		Limit:    10,
+	})
+	s.NoError(err)
+	s.Equal([]post.Post{post1}, posts)
+}
+
+func (s *Suite) TestSetStatus() {
+	publishAt := time.Now().UTC().Add(time.Hour).Round(time.Millisecond)
+
+	err := s.Storage.SetStatus(ctx, post1.ID, post.StatusScheduled, &publishAt, 2)
+	s.ErrorIs(err, post.ErrVersionMismatch)
+
+	err = s.Storage.SetStatus(ctx, "unexisting_id", post.StatusScheduled, &publishAt, 0)
+	s.ErrorIs(err, post.ErrNotFound)
+
+	err = s.Storage.SetStatus(ctx, post1.ID, post.StatusScheduled, &publishAt, 1)
+	s.NoError(err)
+
+	p, err := s.Storage.ByID(ctx, post1.ID)
+	s.NoError(err)
+	s.Equal(post.StatusScheduled, p.Status)
+	s.Equal(&publishAt, p.PublishAt)
+	s.Equal(int64(2), p.Version)
+}
+
+func (s *Suite) TestPublishDue() {
+	due := post.NewPost("due", "due content")
+	due.Status = post.StatusScheduled
+	dueAt := time.Now().UTC().Add(-time.Minute).Round(time.Millisecond)
+	due.PublishAt = &dueAt
+
+	future := post.NewPost("future", "future content")
+	future.Status = post.StatusScheduled
+	futureAt := time.Now().UTC().Add(time.Hour).Round(time.Millisecond)
+	future.PublishAt = &futureAt
+
+	s.NoError(s.Storage.Insert(ctx, due))
+	s.NoError(s.Storage.Insert(ctx, future))
+
+	published, err := s.Storage.PublishDue(ctx, time.Now().UTC())
+	s.NoError(err)
+	s.Equal(int64(1), published)
+
+	p, err := s.Storage.ByID(ctx, due.ID)
+	s.NoError(err)
+	s.Equal(post.StatusPublished, p.Status)
+	s.Equal(&dueAt, p.PublishAt)
+	s.Equal(int64(2), p.Version)
+
+	p, err = s.Storage.ByID(ctx, future.ID)
+	s.NoError(err)
+	s.Equal(post.StatusScheduled, p.Status)
+}
+
+func (s *Suite) TestTags() {
+	tagged := post.NewPost("tagged", "tagged content")
+	tagged.Tags = []string{"covid", "health"}
+	tagged.Category = "world"
+	tagged.Status = post.StatusPublished
+
+	s.NoError(s.Storage.Insert(ctx, tagged))
+
+	p, err := s.Storage.ByID(ctx, tagged.ID)
+	s.NoError(err)
+	s.Equal(tagged, p)
+
+	s.Run("filter", func() {
+		posts, err := s.Storage.ByFilter(ctx, post.Filter{Tags: []string{"covid", "sport"}, Limit: 10})
+		s.NoError(err)
+		s.Equal([]post.Post{tagged}, posts)
+
+		posts, err = s.Storage.ByFilter(ctx, post.Filter{Tags: []string{"covid", "sport"}, AllTags: true, Limit: 10})
+		s.NoError(err)
+		s.Len(posts, 0)
+
+		posts, err = s.Storage.ByFilter(ctx, post.Filter{Tags: []string{"covid", "health"}, AllTags: true, Limit: 10})
+		s.NoError(err)
+		s.Equal([]post.Post{tagged}, posts)
+
+		posts, err = s.Storage.ByFilter(ctx, post.Filter{Category: "world", Limit: 10})
+		s.NoError(err)
+		s.Equal([]post.Post{tagged}, posts)
+	})
+
+	s.Run("counts", func() {
+		updated := post1
+		updated.Tags = []string{"health"}
+		updated.Category = "world"
+		s.NoError(s.Storage.Update(ctx, updated))
+
+		tags, err := s.Storage.Tags(ctx)
+		s.NoError(err)
+		s.Equal([]post.Count{{Name: "health", Posts: 2}, {Name: "covid", Posts: 1}}, tags)
+
+		categories, err := s.Storage.Categories(ctx)
+		s.NoError(err)
+		s.Equal([]post.Count{{Name: "world", Posts: 2}}, categories)
+	})
+
+	s.Run("patch", func() {
+		tags := []string{"sport"}
+		category := ""
+		s.NoError(s.Storage.Patch(ctx, tagged.ID, post.Patch{Tags: &tags, Category: &category}, 0))
+
+		p, err := s.Storage.ByID(ctx, tagged.ID)
+		s.NoError(err)
+		s.Equal(tags, p.Tags)
+		s.Empty(p.Category)
+
+		tags = nil
+		s.NoError(s.Storage.Patch(ctx, tagged.ID, post.Patch{Tags: &tags}, 0))
+
+		p, err = s.Storage.ByID(ctx, tagged.ID)
+		s.NoError(err)
+		s.Nil(p.Tags)
+	})
+}
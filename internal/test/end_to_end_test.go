@@ -1,14 +1,28 @@
 package test
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/feed"
 	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/health"
 	"github.com/sladonia/news-svc/internal/post"
+	"github.com/vmihailenco/msgpack/v5"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
 )
 
 func (s *Suite) TestGetPostByID() {
@@ -47,7 +61,7 @@ func (s *Suite) TestCreatePost() {
 
 	r := strings.NewReader(requestBody)
 
-	res, err := http.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+	res, err := s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
 	s.NoError(err)
 	s.Equal(201, res.StatusCode)
 
@@ -58,10 +72,11 @@ func (s *Suite) TestCreatePost() {
 	s.NoError(err)
 	s.Equal("title1", p.Title)
 	s.Equal("content1", p.Content)
+	s.Equal(`"1"`, res.Header.Get("ETag"))
 
 	createdID := p.ID
 
-	fromStorage, err := s.storage.ByID(createdID)
+	fromStorage, err := s.storage.ByID(context.Background(), createdID)
 
 	s.NoError(err)
 	s.Equal(p, fromStorage)
@@ -70,7 +85,7 @@ func (s *Suite) TestCreatePost() {
 
 	r = strings.NewReader(requestBody)
 
-	res, err = http.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+	res, err = s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
 	s.NoError(err)
 	s.Equal(201, res.StatusCode)
 
@@ -91,11 +106,11 @@ func (s *Suite) TestReplacePost() {
 	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/1", s.srv.URL), r)
 	s.NoError(err)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := s.client.Do(req)
 	s.NoError(err)
 	s.Equal(200, res.StatusCode)
 
-	fromStorage, err := s.storage.ByID("1")
+	fromStorage, err := s.storage.ByID(context.Background(), "1")
 
 	s.NoError(err)
 	s.Equal("title1", fromStorage.Title)
@@ -107,11 +122,11 @@ func (s *Suite) TestReplacePost() {
 	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/posts/unexisting_id", s.srv.URL), r)
 	s.NoError(err)
 
-	res, err = http.DefaultClient.Do(req)
+	res, err = s.client.Do(req)
 	s.NoError(err)
 	s.Equal(200, res.StatusCode)
 
-	fromStorage, err = s.storage.ByID("unexisting_id")
+	fromStorage, err = s.storage.ByID(context.Background(), "unexisting_id")
 
 	s.NoError(err)
 	s.Equal("title1", fromStorage.Title)
@@ -123,11 +138,12 @@ func (s *Suite) TestDeletePost() {
 		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
 		s.NoError(err)
 
-		res, err := http.DefaultClient.Do(req)
+		res, err := s.client.Do(req)
 		s.NoError(err)
 		s.Equal(204, res.StatusCode)
+		s.Empty(res.Header.Get("Content-Type"))
 
-		_, err = s.storage.ByID("1")
+		_, err = s.storage.ByID(context.Background(), "1")
 		s.ErrorIs(err, post.ErrNotFound)
 	})
 
@@ -135,7 +151,7 @@ func (s *Suite) TestDeletePost() {
 		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/unexisting_id", s.srv.URL), nil)
 		s.NoError(err)
 
-		res, err := http.DefaultClient.Do(req)
+		res, err := s.client.Do(req)
 		s.NoError(err)
 		s.Equal(204, res.StatusCode)
 	})
@@ -147,11 +163,12 @@ func (s *Suite) TestGetPosts() {
 		s.NoError(err)
 		s.Equal(200, res.StatusCode)
 
-		var posts []post.Post
+		var response findPostsResponse
 
-		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&posts)
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
 		s.NoError(err)
-		s.Len(posts, 1)
+		s.Len(response.Items, 1)
+		s.Empty(response.NextCursor)
 	})
 
 	s.Run("no_data", func() {
@@ -163,10 +180,1547 @@ func (s *Suite) TestGetPosts() {
 		s.NoError(err)
 		s.Equal(200, res.StatusCode)
 
-		var posts []post.Post
+		var response findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.NotNil(response.Items)
+		s.Len(response.Items, 0)
+	})
+
+	s.Run("cursor", func() {
+		older := post.NewPost("older", "older content")
+		older.CreatedAt = post1.CreatedAt.Add(-time.Minute)
+		older.Status = post.StatusPublished
+
+		err := s.storage.Insert(context.Background(), older)
+		s.NoError(err)
+
+		res, err := http.Get(fmt.Sprintf("%s/posts?limit=1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var first findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&first)
+		s.NoError(err)
+		s.Equal([]post.Post{post1}, first.Items)
+		s.NotEmpty(first.NextCursor)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts?limit=1&cursor=%s", s.srv.URL, first.NextCursor))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var second findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&second)
+		s.NoError(err)
+		s.Equal([]post.Post{older}, second.Items)
+	})
+
+	s.Run("search", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts?q=era", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 1)
+		s.Equal(post1.ID, response.Items[0].ID)
+		s.Contains(response.Items[0].Snippet, "<b>era</b>")
+	})
+
+	s.Run("search_with_cursor", func() {
+		cursor := post.NewCursor(post1).Encode()
+
+		res, err := http.Get(fmt.Sprintf("%s/posts?q=era&cursor=%s", s.srv.URL, cursor))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("invalid_cursor", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts?cursor=invalid", s.srv.URL))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+}
+
+type findPostsResponse struct {
+	Items      []post.Post `json:"items"`
+	Total      *int64      `json:"total"`
+	Limit      uint        `json:"limit"`
+	Offset     uint        `json:"offset"`
+	NextCursor string      `json:"next_cursor"`
+	Links      struct {
+		Self  string `json:"self"`
+		First string `json:"first"`
+		Prev  string `json:"prev"`
+		Next  string `json:"next"`
+	} `json:"links"`
+}
+
+func (s *Suite) TestConditionalRequests() {
+	s.Run("etag", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal(`"1"`, res.Header.Get("ETag"))
+	})
+
+	s.Run("not_modified", func() {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-None-Match", `W/"1"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(304, res.StatusCode)
+	})
+
+	s.Run("replace_precondition_failed", func() {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/1", s.srv.URL), strings.NewReader(`{"title": "t", "content": "c"}`))
+		s.NoError(err)
+		req.Header.Set("If-Match", `"42"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(412, res.StatusCode)
+	})
+
+	s.Run("replace_unexisting_precondition_failed", func() {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/unexisting_id", s.srv.URL), strings.NewReader(`{"title": "t", "content": "c"}`))
+		s.NoError(err)
+		req.Header.Set("If-Match", "*")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(412, res.StatusCode)
+
+		_, err = s.storage.ByID(context.Background(), "unexisting_id")
+		s.ErrorIs(err, post.ErrNotFound)
+	})
+
+	s.Run("replace_matching", func() {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/1", s.srv.URL), strings.NewReader(`{"title": "t", "content": "c"}`))
+		s.NoError(err)
+		req.Header.Set("If-Match", `"1"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		fromStorage, err := s.storage.ByID(context.Background(), "1")
+		s.NoError(err)
+		s.Equal(int64(2), fromStorage.Version)
+	})
+
+	s.Run("delete_precondition_failed", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-Match", `"1"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(412, res.StatusCode)
+	})
+
+	s.Run("delete_matching", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-Match", `"2"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(204, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestPatchPost() {
+	patch := func(contentType, body string, headers ...string) *http.Response {
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/posts/1", s.srv.URL), strings.NewReader(body))
+		s.NoError(err)
+		req.Header.Set("Content-Type", contentType)
+
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	s.Run("merge_patch", func() {
+		res := patch("application/merge-patch+json", `{"title": "merged title"}`)
+		s.Equal(200, res.StatusCode)
+		s.Equal(`"2"`, res.Header.Get("ETag"))
+
+		var p post.Post
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal("merged title", p.Title)
+		s.Equal(post1.Content, p.Content)
+	})
+
+	s.Run("json_patch", func() {
+		res := patch("application/json-patch+json", `[
+			{"op": "test", "path": "/title", "value": "merged title"},
+			{"op": "replace", "path": "/content", "value": "patched content"}
+		]`, "If-Match", `"2"`)
+		s.Equal(200, res.StatusCode)
+
+		fromStorage, err := s.storage.ByID(context.Background(), "1")
+		s.NoError(err)
+		s.Equal("merged title", fromStorage.Title)
+		s.Equal("patched content", fromStorage.Content)
+		s.Equal(int64(3), fromStorage.Version)
+	})
+
+	s.Run("json_patch_test_failed", func() {
+		res := patch("application/json-patch+json", `[
+			{"op": "test", "path": "/title", "value": "other title"},
+			{"op": "replace", "path": "/content", "value": "other content"}
+		]`)
+		s.Equal(409, res.StatusCode)
+	})
+
+	s.Run("json_patch_tags", func() {
+		res := patch("application/json-patch+json", `[
+			{"op": "test", "path": "/tags", "value": []},
+			{"op": "add", "path": "/tags/-", "value": "go"},
+			{"op": "add", "path": "/tags/-", "value": "news"},
+			{"op": "add", "path": "/tags/0", "value": "ai"},
+			{"op": "test", "path": "/tags", "value": ["ai", "go", "news"]}
+		]`)
+		s.Equal(200, res.StatusCode)
+
+		res = patch("application/json-patch+json", `[
+			{"op": "test", "path": "/tags", "value": ["ai", "go", "news"]},
+			{"op": "test", "path": "/tags/1", "value": "go"},
+			{"op": "remove", "path": "/tags/0"},
+			{"op": "replace", "path": "/tags/1", "value": "tech"}
+		]`)
+		s.Equal(200, res.StatusCode)
+
+		fromStorage, err := s.storage.ByID(context.Background(), "1")
+		s.NoError(err)
+		s.Equal([]string{"go", "tech"}, fromStorage.Tags)
+	})
+
+	s.Run("json_patch_invalid_index", func() {
+		for _, path := range []string{"/tags/2", "/tags/01", "/tags/-1", "/title/0"} {
+			res := patch("application/json-patch+json", `[{"op": "replace", "path": "`+path+`", "value": "x"}]`)
+			s.Equal(400, res.StatusCode, path)
+		}
+	})
+
+	s.Run("precondition_failed", func() {
+		res := patch("application/merge-patch+json", `{"title": "title"}`, "If-Match", `"1"`)
+		s.Equal(412, res.StatusCode)
+	})
+
+	s.Run("remove_required_field", func() {
+		res := patch("application/merge-patch+json", `{"content": null}`)
+		s.Equal(400, res.StatusCode)
+
+		var apiError handler.ApiError
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiError)
+		s.NoError(err)
+		s.Equal("validation error: content required", apiError.Error.Message)
+	})
+
+	s.Run("unknown_field", func() {
+		res := patch("application/json-patch+json", `[{"op": "add", "path": "/author", "value": "john"}]`)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("unsupported_media_type", func() {
+		res := patch("application/json", `{"title": "title"}`)
+		s.Equal(415, res.StatusCode)
+		s.NotEmpty(res.Header.Get("Accept-Patch"))
+	})
+
+	s.Run("not_found", func() {
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/posts/unexisting", s.srv.URL), strings.NewReader(`{}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestTrash() {
+	s.Run("soft_delete", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(204, res.StatusCode)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts/1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+	})
+
+	s.Run("list", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/trash", s.srv.URL))
+		s.NoError(err)
+		s.Equal(403, res.StatusCode, "trash is listed by editors only")
+
+		res, err = s.client.Get(fmt.Sprintf("%s/posts/trash", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 1)
+		s.Equal(post1.ID, response.Items[0].ID)
+		s.NotNil(response.Items[0].DeletedAt)
+	})
+
+	s.Run("restore", func() {
+		res, err := s.client.Post(fmt.Sprintf("%s/posts/1/restore", s.srv.URL), "application/json", nil)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal(`"3"`, res.Header.Get("ETag"))
+
+		res, err = s.client.Post(fmt.Sprintf("%s/posts/1/restore", s.srv.URL), "application/json", nil)
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+
+		_, err = s.storage.ByID(context.Background(), "1")
+		s.NoError(err)
+	})
+
+	s.Run("permanent", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1?permanent=true", s.srv.URL), nil)
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(204, res.StatusCode)
+
+		res, err = s.client.Post(fmt.Sprintf("%s/posts/1/restore", s.srv.URL), "application/json", nil)
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestRevisions() {
+	s.Run("update", func() {
+		body := strings.NewReader(`{"title":"updated","content":"new era beginning!","tags":["covid"],"category":"world"}`)
+
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/1", s.srv.URL), body)
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+	})
+
+	s.Run("list", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1/revisions", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response struct {
+			Items []post.Revision `json:"items"`
+		}
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 2)
+		s.Equal(post1.Title, response.Items[0].Title)
+		s.Equal("updated", response.Items[1].Title)
+		s.Equal("admin", response.Items[1].Author)
+	})
+
+	s.Run("by_number", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1/revisions/2", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var revision post.Revision
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&revision)
+		s.NoError(err)
+		s.Equal(int64(2), revision.Number)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts/1/revisions/5", s.srv.URL))
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+	})
+
+	s.Run("diff", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1/revisions/diff?from=1&to=2", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response struct {
+			Changes []post.FieldChange `json:"changes"`
+		}
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal([]post.FieldChange{
+			{Field: post.FieldTitle, From: post1.Title, To: "updated"},
+			{Field: post.FieldTags, From: "", To: "covid"},
+			{Field: post.FieldCategory, From: "", To: "world"},
+		}, response.Changes)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts/1/revisions/diff?from=1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("revert", func() {
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/posts/1/revisions/1/revert", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-Match", `"1"`)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(412, res.StatusCode)
+
+		req.Header.Set("If-Match", `"2"`)
+
+		res, err = s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal(`"3"`, res.Header.Get("ETag"))
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal(post1.Title, p.Title)
+		s.Empty(p.Tags, "tags are reverted")
+		s.Empty(p.Category, "category is reverted")
+
+		revisions, err := s.storage.Revisions(context.Background(), "1")
+		s.NoError(err)
+		s.Len(revisions, 3)
+	})
+}
+
+func (s *Suite) TestLifecycle() {
+	var draft post.Post
+
+	s.Run("create_draft", func() {
+		r := strings.NewReader(`{"title":"draft","content":"draft content"}`)
+
+		res, err := s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+		s.NoError(err)
+		s.Equal(201, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&draft)
+		s.NoError(err)
+		s.Equal(post.StatusDraft, draft.Status)
+		s.Nil(draft.PublishAt)
+	})
+
+	s.Run("create_published", func() {
+		r := strings.NewReader(`{"title":"published","content":"published content","status":"published"}`)
+
+		res, err := s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+		s.NoError(err)
+		s.Equal(201, res.StatusCode)
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal(post.StatusPublished, p.Status)
+		s.NotNil(p.PublishAt)
+
+		r = strings.NewReader(`{"title":"archived","content":"archived content","status":"archived"}`)
+
+		res, err = s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("find_published_only", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Len(response.Items, 2)
+
+		for _, p := range response.Items {
+			s.Equal(post.StatusPublished, p.Status)
+		}
+
+		res, err = http.Get(fmt.Sprintf("%s/posts?status=draft,scheduled", s.srv.URL))
+		s.NoError(err)
+		s.Equal(403, res.StatusCode, "unpublished posts are listed by editors and their authors")
+
+		res, err = s.client.Get(fmt.Sprintf("%s/posts?status=draft,scheduled", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 1)
+		s.Equal(draft.ID, response.Items[0].ID)
 
-		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&posts)
+		res, err = http.Get(fmt.Sprintf("%s/posts?status=unknown", s.srv.URL))
 		s.NoError(err)
-		s.Len(posts, 0)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("schedule", func() {
+		r := strings.NewReader(`{"status":"scheduled"}`)
+
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/%s/status", s.srv.URL, draft.ID), r)
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+
+		publishAt := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+		r = strings.NewReader(fmt.Sprintf(`{"status":"scheduled","publish_at":"%s"}`, publishAt))
+
+		req, err = http.NewRequest("PUT", fmt.Sprintf("%s/posts/%s/status", s.srv.URL, draft.ID), r)
+		s.NoError(err)
+		req.Header.Set("If-Match", `"1"`)
+
+		res, err = s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal(`"2"`, res.Header.Get("ETag"))
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal(post.StatusScheduled, p.Status)
+		s.Equal(publishAt, p.PublishAt.Format(time.RFC3339))
+	})
+
+	s.Run("publish_due", func() {
+		published, err := s.service.PublishScheduled(context.Background())
+		s.NoError(err)
+		s.Zero(published)
+	})
+
+	s.Run("invalid_transition", func() {
+		r := strings.NewReader(`{"status":"archived"}`)
+
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/1/status", s.srv.URL), r)
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		r = strings.NewReader(`{"status":"scheduled","publish_at":"2100-01-01T00:00:00Z"}`)
+
+		req, err = http.NewRequest("PUT", fmt.Sprintf("%s/posts/1/status", s.srv.URL), r)
+		s.NoError(err)
+
+		res, err = s.client.Do(req)
+		s.NoError(err)
+		s.Equal(409, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestTagsAndCategories() {
+	s.Run("create", func() {
+		r := strings.NewReader(`{
+	"title": "tagged",
+	"content": "tagged content",
+	"tags": ["Covid", "health", "covid"],
+	"category": "World",
+	"status": "published"
+}`)
+
+		res, err := s.client.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+		s.NoError(err)
+		s.Equal(201, res.StatusCode)
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal([]string{"covid", "health"}, p.Tags)
+		s.Equal("world", p.Category)
+	})
+
+	s.Run("patch", func() {
+		r := strings.NewReader(`{"tags": ["health"]}`)
+
+		req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/posts/1", s.srv.URL), r)
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal([]string{"health"}, p.Tags)
+	})
+
+	s.Run("filter", func() {
+		var response findPostsResponse
+
+		res, err := http.Get(fmt.Sprintf("%s/posts?tags=covid,health", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Len(response.Items, 2)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts?tags=covid,health&tags_match=all", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 1)
+		s.Equal("tagged", response.Items[0].Title)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts?category=world", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Len(response.Items, 1)
+
+		res, err = http.Get(fmt.Sprintf("%s/posts?tags=covid&tags_match=some", s.srv.URL))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("counts", func() {
+		var response struct {
+			Items []post.Count `json:"items"`
+		}
+
+		res, err := http.Get(fmt.Sprintf("%s/tags", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal([]post.Count{{Name: "health", Posts: 2}, {Name: "covid", Posts: 1}}, response.Items)
+
+		res, err = http.Get(fmt.Sprintf("%s/categories", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal([]post.Count{{Name: "world", Posts: 1}}, response.Items)
+	})
+}
+
+func (s *Suite) TestAuthors() {
+	var bob author.Author
+
+	doAs := func(key, method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, s.srv.URL+path, strings.NewReader(body))
+		s.NoError(err)
+
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	s.Run("create", func() {
+		res := doAs(bobKey, "POST", "/authors", `{"name":"bob","email":"bob@example.com"}`)
+		s.Equal(201, res.StatusCode)
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&bob)
+		s.NoError(err)
+		s.Equal("bob", bob.ID)
+		s.Equal("bob", bob.Name)
+
+		res = doAs(bobKey, "POST", "/authors", `{"name":"bob","email":"not an email"}`)
+		s.Equal(400, res.StatusCode)
+
+		res = doAs(bobKey, "POST", "/authors", `{"name":"bob","email":"bob@example.com"}`)
+		s.Equal(409, res.StatusCode)
+
+		res = doAs("", "POST", "/authors", `{"name":"anonymous","email":"anonymous@example.com"}`)
+		s.Equal(401, res.StatusCode)
+	})
+
+	s.Run("get", func() {
+		res := doAs(bobKey, "GET", "/authors/"+bob.ID, "")
+		s.Equal(200, res.StatusCode)
+
+		var a author.Author
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&a)
+		s.NoError(err)
+		s.Equal(bob, a)
+
+		res = doAs("", "GET", "/authors/"+bob.ID, "")
+		s.Equal(200, res.StatusCode)
+
+		var anonymous author.Author
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&anonymous)
+		s.NoError(err)
+		s.Empty(anonymous.Email)
+
+		res = doAs(aliceKey, "GET", "/authors", "")
+		s.Equal(200, res.StatusCode)
+
+		var response struct {
+			Items []author.Author `json:"items"`
+		}
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+
+		hidden := bob
+		hidden.Email = ""
+		s.Equal([]author.Author{hidden}, response.Items)
+
+		res = doAs(editorKey, "GET", "/authors", "")
+		s.Equal(200, res.StatusCode)
+
+		response.Items = nil
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal([]author.Author{bob}, response.Items)
+	})
+
+	s.Run("update", func() {
+		res := doAs(aliceKey, "PUT", "/authors/"+bob.ID, `{"name":"alice","bio":"impostor"}`)
+		s.Equal(403, res.StatusCode)
+
+		res = doAs(bobKey, "PUT", "/authors/"+bob.ID, `{"name":"robert","bio":"reporter"}`)
+		s.Equal(200, res.StatusCode)
+
+		var a author.Author
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&a)
+		s.NoError(err)
+		s.Equal("robert", a.Name)
+		s.Equal("reporter", a.Bio)
+
+		res = doAs(editorKey, "PUT", "/authors/"+bob.ID, `{"name":"robert","bio":"senior reporter"}`)
+		s.Equal(200, res.StatusCode)
+	})
+
+	s.Run("delete", func() {
+		s.Equal(403, doAs(aliceKey, "DELETE", "/authors/"+bob.ID, "").StatusCode)
+		s.Equal(204, doAs(bobKey, "DELETE", "/authors/"+bob.ID, "").StatusCode)
+		s.Equal(404, doAs("", "GET", "/authors/"+bob.ID, "").StatusCode)
+	})
+}
+
+func (s *Suite) TestOwnership() {
+	var p post.Post
+
+	s.Run("create", func() {
+		r := strings.NewReader(`{"title":"bob's","content":"bob's content"}`)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/posts", s.srv.URL), r)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", bobKey)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(201, res.StatusCode)
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal("bob", p.AuthorID)
+	})
+
+	getAs := func(key, path string) *http.Response {
+		req, err := http.NewRequest("GET", s.srv.URL+path, nil)
+		s.NoError(err)
+
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	s.Run("filter", func() {
+		res := getAs(bobKey, "/posts?author=bob&status=draft")
+		s.Equal(200, res.StatusCode)
+
+		var response findPostsResponse
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal([]post.Post{p}, response.Items)
+
+		s.Equal(403, getAs("", "/posts?author=bob&status=draft").StatusCode)
+		s.Equal(403, getAs(aliceKey, "/posts?author=bob&status=draft").StatusCode)
+		s.Equal(200, getAs(editorKey, "/posts?author=bob&status=draft").StatusCode)
+	})
+
+	s.Run("read_draft", func() {
+		s.Equal(200, getAs(bobKey, "/posts/"+p.ID).StatusCode)
+		s.Equal(200, getAs(editorKey, "/posts/"+p.ID).StatusCode)
+		s.Equal(404, getAs("", "/posts/"+p.ID).StatusCode)
+		s.Equal(404, getAs(aliceKey, "/posts/"+p.ID).StatusCode)
+
+		s.Equal(200, getAs(bobKey, "/posts/"+p.ID+"/revisions").StatusCode)
+		s.Equal(404, getAs("", "/posts/"+p.ID+"/revisions").StatusCode)
+		s.Equal(404, getAs(aliceKey, "/posts/"+p.ID+"/revisions/1").StatusCode)
+	})
+
+	s.Run("forbidden", func() {
+		r := strings.NewReader(`{"title":"alice's","content":"alice's content"}`)
+
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/%s", s.srv.URL, p.ID), r)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", aliceKey)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(403, res.StatusCode)
+
+		req, err = http.NewRequest("DELETE", fmt.Sprintf("%s/posts/%s", s.srv.URL, p.ID), nil)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", aliceKey)
+
+		res, err = s.client.Do(req)
+		s.NoError(err)
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("owner", func() {
+		r := strings.NewReader(`{"title":"bob's updated","content":"bob's content"}`)
+
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/posts/%s", s.srv.URL, p.ID), r)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", bobKey)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+	})
+
+	s.Run("editor", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/%s", s.srv.URL, p.ID), nil)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", editorKey)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(204, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestAuth() {
+	s.Run("anonymous_read", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+	})
+
+	s.Run("anonymous_write", func() {
+		r := strings.NewReader(`{"title":"title","content":"content"}`)
+
+		res, err := http.Post(fmt.Sprintf("%s/posts", s.srv.URL), "application/json", r)
+		s.NoError(err)
+		s.Equal(401, res.StatusCode)
+		s.Equal("Bearer", res.Header.Get("WWW-Authenticate"))
+	})
+
+	s.Run("invalid_key", func() {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", "unknown")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(401, res.StatusCode)
+	})
+
+	s.Run("no_write_scope", func() {
+		r := strings.NewReader(`{"title":"title","content":"content"}`)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/posts", s.srv.URL), r)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", readerKey)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("jwt", func() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  "carol",
+			"role": "writer",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(jwtSecret))
+		s.NoError(err)
+
+		r := strings.NewReader(`{"title":"carol's","content":"carol's content"}`)
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/posts", s.srv.URL), r)
+		s.NoError(err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(201, res.StatusCode)
+
+		var p post.Post
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+		s.Equal("carol", p.AuthorID)
+	})
+
+	s.Run("invalid_jwt", func() {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("Authorization", "Bearer invalid")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(401, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestRoles() {
+	var p post.Post
+
+	do := func(key, method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, s.srv.URL+path, strings.NewReader(body))
+		s.NoError(err)
+		req.Header.Set("X-API-Key", key)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	s.Run("writer_creates_draft", func() {
+		res := do(bobKey, "POST", "/posts", `{"title":"bob's","content":"bob's content"}`)
+		s.Equal(201, res.StatusCode)
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&p)
+		s.NoError(err)
+	})
+
+	s.Run("writer_may_not_create_published", func() {
+		res := do(bobKey, "POST", "/posts", `{"title":"bob's","content":"bob's content","status":"published"}`)
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("writer_may_not_publish", func() {
+		res := do(bobKey, "PUT", "/posts/"+p.ID+"/status", `{"status":"published"}`)
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("writer_may_not_delete", func() {
+		res := do(bobKey, "DELETE", "/posts/"+p.ID, "")
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("editor_publishes", func() {
+		res := do(editorKey, "PUT", "/posts/"+p.ID+"/status", `{"status":"published"}`)
+		s.Equal(200, res.StatusCode)
+	})
+
+	s.Run("writer_may_not_update_published", func() {
+		res := do(bobKey, "PUT", "/posts/"+p.ID, `{"title":"bob's updated","content":"bob's content"}`)
+		s.Equal(403, res.StatusCode)
+	})
+
+	s.Run("editor_may_not_purge", func() {
+		res := do(editorKey, "DELETE", "/posts/"+p.ID, "")
+		s.Equal(204, res.StatusCode)
+
+		res = do(editorKey, "DELETE", "/posts/"+p.ID+"?permanent=true", "")
+		s.Equal(403, res.StatusCode)
+
+		var apiErr handler.ApiError
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiErr)
+		s.NoError(err)
+		s.Equal(handler.LevelUser, apiErr.Error.Level)
+	})
+
+	s.Run("admin_purges", func() {
+		res := do(adminKey, "DELETE", "/posts/"+p.ID+"?permanent=true", "")
+		s.Equal(204, res.StatusCode)
+	})
+}
+
+func (s *Suite) TestMetrics() {
+	res, err := http.Get(fmt.Sprintf("%s/posts/1", s.srv.URL))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+
+	res, err = http.Get(fmt.Sprintf("%s/metrics", s.srv.URL))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+	s.Contains(res.Header.Get("Content-Type"), "text/plain")
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+
+	s.Contains(string(body), `news_http_requests_total{method="GET",route="postByID",status="200"}`)
+	s.Contains(string(body), `news_http_request_duration_seconds_count{method="GET",route="postByID"}`)
+	s.Contains(string(body), `news_http_requests_in_flight{route="metrics"} 1`)
+	s.Contains(string(body), `news_storage_query_duration_seconds_count{operation="by_id",storage="post"}`)
+}
+
+func (s *Suite) TestTracing() {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+	s.NoError(err)
+	req.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", traceID, spanID))
+
+	res, err := s.client.Do(req)
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+
+	for _, span := range s.spans.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	s.Require().Contains(spans, "postByID")
+	s.Require().Contains(spans, "post.Service/GetPost")
+	s.Require().Contains(spans, "post.by_id")
+
+	s.Equal(spanID, spans["postByID"].Parent().SpanID().String())
+	s.Equal(spans["postByID"].SpanContext().SpanID(), spans["post.Service/GetPost"].Parent().SpanID())
+	s.Equal(spans["post.Service/GetPost"].SpanContext().SpanID(), spans["post.by_id"].Parent().SpanID())
+}
+
+func (s *Suite) TestAccessLog() {
+	s.Run("generated_request_id", func() {
+		res, err := http.Get(fmt.Sprintf("%s/posts/1", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.NotEmpty(res.Header.Get("X-Request-ID"))
+	})
+
+	s.Run("propagated_request_id", func() {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/posts/missing", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("X-Request-ID", "request-1")
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Equal(404, res.StatusCode)
+		s.Equal("request-1", res.Header.Get("X-Request-ID"))
+
+		entries := s.logs.FilterField(zap.String("request_id", "request-1")).All()
+		s.Require().NotEmpty(entries)
+
+		handled := entries[len(entries)-1]
+		s.Equal("request handled", handled.Message)
+
+		fields := handled.ContextMap()
+		s.Equal("postByID", fields["route"])
+		s.Equal(int64(404), fields["status"])
+		s.Greater(fields["bytes"], int64(0))
+		s.Contains(fields, "duration")
+		s.Contains(fields, "remote_addr")
+
+		s.Equal(1, s.logs.FilterMessage("failed to get post").FilterField(zap.String("request_id", "request-1")).Len())
+	})
+
+	s.Run("invalid_request_id", func() {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/posts/1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("X-Request-ID", strings.Repeat("a", 129))
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+		s.Len(res.Header.Get("X-Request-ID"), 20)
+	})
+}
+
+func (s *Suite) TestRecovery() {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/panic", s.srv.URL), nil)
+	s.NoError(err)
+	req.Header.Set("X-Request-ID", "panicking-request")
+
+	res, err := s.client.Do(req)
+	s.NoError(err)
+	s.Equal(500, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+
+	var apiErr handler.ApiError
+
+	err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiErr)
+	s.NoError(err)
+	s.Equal(handler.LevelSystem, apiErr.Error.Level)
+
+	entries := s.logs.FilterMessage("handler panicked").FilterField(zap.String("request_id", "panicking-request")).All()
+	s.Require().Len(entries, 1)
+	s.Equal("handler failure", entries[0].ContextMap()["panic"])
+	s.Contains(entries[0].ContextMap()["stack"], "runtime/debug.Stack")
+
+	res, err = http.Get(fmt.Sprintf("%s/metrics", s.srv.URL))
+	s.NoError(err)
+
+	body, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Contains(string(body), `news_http_panics_total{route="panic"}`)
+	s.Contains(string(body), `news_http_requests_total{method="GET",route="panic",status="500"}`)
+}
+
+func (s *Suite) TestHealth() {
+	res, err := http.Get(fmt.Sprintf("%s/healthz", s.srv.URL))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+
+	res, err = http.Get(fmt.Sprintf("%s/readyz", s.srv.URL))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+
+	var report health.Report
+
+	err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&report)
+	s.NoError(err)
+	s.Equal(health.StatusReady, report.Status)
+	s.Require().Len(report.Checks, 1)
+	s.Equal("storage", report.Checks[0].Name)
+	s.Equal(health.StatusOK, report.Checks[0].Status)
+}
+
+func (s *Suite) TestRateLimit() {
+	get := func(key string) *http.Response {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/limited", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", key)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	res := get(bobKey)
+	s.Equal(204, res.StatusCode)
+	s.Equal("2", res.Header.Get("RateLimit-Limit"))
+	s.Equal("1", res.Header.Get("RateLimit-Remaining"))
+
+	res = get(bobKey)
+	s.Equal(204, res.StatusCode)
+	s.Equal("0", res.Header.Get("RateLimit-Remaining"))
+
+	res = get(bobKey)
+	s.Equal(429, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+	s.NotEmpty(res.Header.Get("Retry-After"))
+
+	var apiErr handler.ApiError
+
+	err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiErr)
+	s.NoError(err)
+	s.Equal(handler.LevelUser, apiErr.Error.Level)
+
+	res = get(aliceKey)
+	s.Equal(204, res.StatusCode, "clients have the buckets of their own")
+
+	res = get("invalid")
+	s.Equal(401, res.StatusCode)
+
+	res = get("invalid")
+	s.Equal(429, res.StatusCode, "the address is limited before auth")
+	s.NotEmpty(res.Header.Get("Retry-After"))
+
+	res = get(aliceKey)
+	s.Equal(429, res.StatusCode, "the address limit applies to the authenticated clients as well")
+
+	for i := 0; i <= 5; i++ {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/limited", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("X-API-Key", "invalid")
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d, 198.51.100.7", i))
+
+		res, err = http.DefaultClient.Do(req)
+		s.NoError(err)
+
+		if i < 5 {
+			s.Equal(401, res.StatusCode)
+		} else {
+			s.Equal(429, res.StatusCode, "the address is the one appended by the proxy")
+		}
+	}
+
+	res, err = s.client.Get(fmt.Sprintf("%s/posts/%s", s.srv.URL, post1.ID))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+	s.Empty(res.Header.Get("RateLimit-Limit"), "other routes aren't limited")
+}
+
+func (s *Suite) TestFeeds() {
+	draft := post.NewPost("draft", "not ready yet")
+	s.NoError(s.storage.Insert(context.Background(), draft))
+
+	s.Run("rss", func() {
+		res, err := http.Get(fmt.Sprintf("%s/feed.rss", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/rss+xml; charset=utf-8", res.Header.Get("Content-Type"))
+		s.Equal(post1.UpdatedAt.Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+
+		var rss struct {
+			Channel struct {
+				Title     string `xml:"title"`
+				Generator string `xml:"generator"`
+				Items     []struct {
+					Title string `xml:"title"`
+					Link  string `xml:"link"`
+					GUID  string `xml:"guid"`
+				} `xml:"item"`
+			} `xml:"channel"`
+		}
+
+		err = xml.NewDecoder(res.Body).Decode(&rss)
+		s.NoError(err)
+		s.Equal("News", rss.Channel.Title)
+		s.Equal("news-sv", rss.Channel.Generator)
+		s.Require().Len(rss.Channel.Items, 1, "drafts aren't in the feed")
+		s.Equal(post1.Title, rss.Channel.Items[0].Title)
+		s.Equal("http://news.test/posts/1", rss.Channel.Items[0].Link)
+		s.Equal(post1.ID, rss.Channel.Items[0].GUID)
+	})
+
+	s.Run("atom", func() {
+		res, err := http.Get(fmt.Sprintf("%s/feed.atom", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/atom+xml; charset=utf-8", res.Header.Get("Content-Type"))
+
+		var atom struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+			Entries []struct {
+				ID      string `xml:"id"`
+				Title   string `xml:"title"`
+				Content string `xml:"content"`
+			} `xml:"entry"`
+		}
+
+		err = xml.NewDecoder(res.Body).Decode(&atom)
+		s.NoError(err)
+		s.Equal("http://news.test/feed.atom", atom.ID)
+		s.Equal(post1.UpdatedAt.Format(time.RFC3339), atom.Updated)
+		s.Require().Len(atom.Entries, 1)
+		s.Equal("http://news.test/posts/1", atom.Entries[0].ID)
+		s.Equal(post1.Content, atom.Entries[0].Content)
+	})
+
+	s.Run("json", func() {
+		res, err := http.Get(fmt.Sprintf("%s/feed.json", s.srv.URL))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/feed+json; charset=utf-8", res.Header.Get("Content-Type"))
+
+		var feed struct {
+			Version string `json:"version"`
+			FeedURL string `json:"feed_url"`
+			Items   []struct {
+				ID          string `json:"id"`
+				ContentText string `json:"content_text"`
+			} `json:"items"`
+		}
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&feed)
+		s.NoError(err)
+		s.Equal("https://jsonfeed.org/version/1.1", feed.Version)
+		s.Equal("http://news.test/feed.json", feed.FeedURL)
+		s.Require().Len(feed.Items, 1)
+		s.Equal(post1.ID, feed.Items[0].ID)
+		s.Equal(post1.Content, feed.Items[0].ContentText)
+	})
+
+	s.Run("conditional", func() {
+		res, err := http.Get(fmt.Sprintf("%s/feed.json", s.srv.URL))
+		s.NoError(err)
+
+		etag := res.Header.Get("ETag")
+		s.True(strings.HasPrefix(etag, `W/"`))
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/feed.json", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-None-Match", etag)
+
+		res, err = http.DefaultClient.Do(req)
+		s.NoError(err)
+		s.Equal(304, res.StatusCode)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("%s/feed.json", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-Modified-Since", res.Header.Get("Last-Modified"))
+
+		res, err = http.DefaultClient.Do(req)
+		s.NoError(err)
+		s.Equal(304, res.StatusCode)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("%s/feed.json?limit=1", s.srv.URL), nil)
+		s.NoError(err)
+		req.Header.Set("If-None-Match", etag)
+
+		res, err = http.DefaultClient.Do(req)
+		s.NoError(err)
+		s.Equal(200, res.StatusCode, "other query has other entity tag")
+	})
+
+	s.Run("filters", func() {
+		from := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+		res, err := http.Get(fmt.Sprintf("%s/feed.json?from=%s", s.srv.URL, from))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Empty(res.Header.Get("Last-Modified"))
+
+		var feed struct {
+			Items []interface{} `json:"items"`
+		}
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&feed)
+		s.NoError(err)
+		s.Empty(feed.Items)
+
+		res, err = http.Get(fmt.Sprintf("%s/feed.rss?limit=many", s.srv.URL))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+
+		res, err = http.Get(fmt.Sprintf("%s/feed.atom?to=yesterday", s.srv.URL))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("max_limit", func() {
+		older := post.NewPost("older", "older content")
+		older.Status = post.StatusPublished
+		older.CreatedAt = post1.CreatedAt.Add(-time.Minute)
+		s.NoError(s.storage.Insert(context.Background(), older))
+
+		r := mux.NewRouter()
+		feed.NewHandler(s.log, s.service, feed.Meta{Link: "http://news.test"}, 50, 1).Register(r)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/feed.json?limit=10", nil))
+		s.Equal(200, rec.Code)
+
+		var response struct {
+			Items []interface{} `json:"items"`
+		}
+
+		err := jsoniter.ConfigFastest.NewDecoder(rec.Body).Decode(&response)
+		s.NoError(err)
+		s.Len(response.Items, 1, "limit is clamped to the maximum")
+	})
+}
+
+func (s *Suite) TestListFormats() {
+	older := post.NewPost("older", "older content")
+	older.Status = post.StatusPublished
+	older.CreatedAt = post1.CreatedAt.Add(-time.Minute)
+	s.NoError(s.storage.Insert(context.Background(), older))
+
+	get := func(path, accept string) *http.Response {
+		req, err := http.NewRequest("GET", s.srv.URL+path, nil)
+		s.NoError(err)
+
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+
+		return res
+	}
+
+	s.Run("envelope", func() {
+		res := get("/posts?limit=1&count=true", "")
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/json", res.Header.Get("Content-Type"))
+		s.Equal("2", res.Header.Get("X-Total-Count"))
+
+		var response findPostsResponse
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Require().Len(response.Items, 1)
+		s.Require().NotNil(response.Total)
+		s.Equal(int64(2), *response.Total)
+		s.Equal(uint(1), response.Limit)
+		s.Equal("/posts?limit=1&count=true", response.Links.Self)
+		s.Equal("/posts?count=true&limit=1", response.Links.First)
+		s.Empty(response.Links.Prev)
+		s.Equal("/posts?count=true&cursor="+response.NextCursor+"&limit=1", response.Links.Next)
+		s.Contains(res.Header.Get("Link"), "<"+response.Links.Next+`>; rel="next"`)
+		s.Contains(res.Header.Get("Link"), `</posts?count=true&limit=1>; rel="first"`)
+
+		res = get(response.Links.Next, "")
+		s.Equal(200, res.StatusCode)
+
+		var next findPostsResponse
+
+		err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&next)
+		s.NoError(err)
+		s.Require().Len(next.Items, 1)
+		s.Equal(older.ID, next.Items[0].ID)
+		s.Equal(int64(2), *next.Total, "total doesn't depend on the page")
+
+		res = get(next.Links.Next, "")
+		s.Equal(200, res.StatusCode)
+		s.NotContains(res.Header.Get("Link"), `rel="next"`, "the last page isn't full")
+	})
+
+	s.Run("offset", func() {
+		res := get("/posts?limit=1&offset=1", "")
+		s.Equal(200, res.StatusCode)
+		s.Empty(res.Header.Get("X-Total-Count"))
+
+		var response findPostsResponse
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Nil(response.Total)
+		s.Equal(uint(1), response.Offset)
+		s.Equal("/posts?limit=1&offset=0", response.Links.Prev)
+	})
+
+	s.Run("negative_page", func() {
+		s.Equal(400, get("/posts?limit=-1", "").StatusCode)
+
+		// the handler rejects them without the OpenAPI validation as well
+		r := mux.NewRouter()
+		s.handler.Register(r)
+
+		for _, path := range []string{"/posts?limit=-1", "/posts?offset=-1", "/authors?limit=-1"} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			s.Equal(400, rec.Code, path)
+		}
+	})
+
+	s.Run("huge_page", func() {
+		for _, path := range []string{"/posts?limit=1000000", "/authors?limit=1000000"} {
+			res := get(path, "")
+			s.Equal(200, res.StatusCode, path)
+
+			var response struct {
+				Limit uint `json:"limit"`
+			}
+
+			err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+			s.NoError(err)
+			s.Equal(uint(100), response.Limit, "limit is clamped to the maximum")
+		}
+	})
+
+	s.Run("ndjson", func() {
+		res := get("/posts", "application/x-ndjson")
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/x-ndjson", res.Header.Get("Content-Type"))
+
+		decoder := jsoniter.ConfigFastest.NewDecoder(res.Body)
+
+		var ids []string
+
+		for decoder.More() {
+			var p post.Post
+			s.NoError(decoder.Decode(&p))
+			ids = append(ids, p.ID)
+		}
+
+		s.Equal([]string{post1.ID, older.ID}, ids)
+	})
+
+	s.Run("csv", func() {
+		res := get("/posts", "text/csv;q=0.9, application/json;q=0.5")
+		s.Equal(200, res.StatusCode)
+		s.Equal("text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+
+		records, err := csv.NewReader(res.Body).ReadAll()
+		s.NoError(err)
+		s.Require().Len(records, 3)
+		s.Equal("ID", records[0][0])
+		s.Equal([]string{post1.ID, post1.Title, post1.Content}, records[1][:3])
+	})
+
+	s.Run("msgpack", func() {
+		res := get("/posts?count=true", "application/msgpack")
+		s.Equal(200, res.StatusCode)
+		s.Equal("application/msgpack", res.Header.Get("Content-Type"))
+
+		var response struct {
+			Items []struct {
+				ID string
+			} `msgpack:"items"`
+			Total int64 `msgpack:"total"`
+		}
+
+		err := msgpack.NewDecoder(res.Body).Decode(&response)
+		s.NoError(err)
+		s.Equal(int64(2), response.Total)
+		s.Require().Len(response.Items, 2)
+		s.Equal(post1.ID, response.Items[0].ID)
+	})
+
+	s.Run("authors", func() {
+		s.NoError(s.authors.Insert(context.Background(), author.NewAuthor("bob", "bob", "", "")))
+
+		res := get("/authors?count=true", "text/*")
+		s.Equal(200, res.StatusCode)
+		s.Equal("text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+		s.Equal("1", res.Header.Get("X-Total-Count"))
+	})
+
+	s.Run("not_acceptable", func() {
+		res := get("/posts", "application/xml, application/json;q=0")
+		s.Equal(406, res.StatusCode)
+		s.Equal("application/json", res.Header.Get("Content-Type"))
+	})
+
+	s.Run("invalid_count", func() {
+		res := get("/posts?count=maybe", "")
+		s.Equal(400, res.StatusCode)
 	})
 }
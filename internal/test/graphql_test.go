@@ -0,0 +1,433 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/author"
+)
+
+type graphqlPost struct {
+	ID        string
+	Title     string
+	Tags      []string
+	Status    string
+	AuthorID  string
+	Version   int64
+	CreatedAt string
+	Author    *struct {
+		Name string
+	}
+}
+
+type graphqlConnection struct {
+	TotalCount int
+	Edges      []struct {
+		Cursor string
+		Node   graphqlPost
+	}
+	PageInfo struct {
+		HasNextPage     bool
+		HasPreviousPage bool
+		EndCursor       *string
+	}
+}
+
+type graphqlError struct {
+	Message    string
+	Extensions struct {
+		Code string
+	}
+}
+
+// graphql posts the query on behalf of the key holder, empty key makes an anonymous request.
+// Data is decoded into the data argument.
+func (s *Suite) graphql(key, query string, variables map[string]interface{}, data interface{}) []graphqlError {
+	body, err := jsoniter.ConfigFastest.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	s.Require().NoError(err)
+
+	req, err := http.NewRequest("POST", s.srv.URL+"/graphql", strings.NewReader(string(body)))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.DefaultClient
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+		client = s.client
+	}
+
+	res, err := client.Do(req)
+	s.Require().NoError(err)
+	s.Require().Equal(200, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+
+	var response struct {
+		Data   jsoniter.RawMessage
+		Errors []graphqlError
+	}
+
+	err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response)
+	s.Require().NoError(err)
+
+	if data != nil && len(response.Data) > 0 {
+		err = jsoniter.ConfigFastest.Unmarshal(response.Data, data)
+		s.Require().NoError(err)
+	}
+
+	return response.Errors
+}
+
+func (s *Suite) TestGraphQL() {
+	s.NoError(s.authors.Insert(context.Background(), author.Author{ID: "bob", Name: "Bob"}))
+
+	const postFields = `id title tags status authorId version createdAt author { name }`
+
+	var created graphqlPost
+
+	s.Run("post", func() {
+		var data struct {
+			Post    *graphqlPost
+			Missing *graphqlPost
+		}
+
+		errs := s.graphql("", `{
+			post(id: "1") { `+postFields+` }
+			missing: post(id: "missing") { id }
+		}`, nil, &data)
+		s.Empty(errs)
+		s.Require().NotNil(data.Post)
+		s.Equal(post1.Title, data.Post.Title)
+		s.Equal("PUBLISHED", data.Post.Status)
+		s.Equal([]string{}, data.Post.Tags)
+		s.Equal(post1.CreatedAt.Format("2006-01-02T15:04:05.999Z07:00"), data.Post.CreatedAt)
+		s.Nil(data.Post.Author, "anonymous post has no author")
+		s.Nil(data.Missing)
+	})
+
+	s.Run("create", func() {
+		mutation := `mutation($input: CreatePostInput!) { createPost(input: $input) { ` + postFields + ` } }`
+		input := map[string]interface{}{"title": "graphql", "content": "created over graphql", "tags": []string{"Tech"}}
+
+		errs := s.graphql("", mutation, map[string]interface{}{"input": input}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("UNAUTHENTICATED", errs[0].Extensions.Code)
+
+		errs = s.graphql(readerKey, mutation, map[string]interface{}{"input": input}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("FORBIDDEN", errs[0].Extensions.Code)
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{
+			"input": map[string]interface{}{"title": "", "content": "no title"},
+		}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("BAD_USER_INPUT", errs[0].Extensions.Code)
+		s.Equal("validation error: title required", errs[0].Message)
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{
+			"input": map[string]interface{}{"title": "graphql", "content": "published", "status": "PUBLISHED"},
+		}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("FORBIDDEN", errs[0].Extensions.Code, "writers create drafts only")
+
+		var data struct {
+			CreatePost graphqlPost
+		}
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{"input": input}, &data)
+		s.Empty(errs)
+
+		created = data.CreatePost
+		s.Equal("bob", created.AuthorID)
+		s.Equal([]string{"tech"}, created.Tags)
+		s.Equal("DRAFT", created.Status)
+		s.Require().NotNil(created.Author)
+		s.Equal("Bob", created.Author.Name)
+	})
+
+	s.Run("patch", func() {
+		mutation := `mutation($id: ID!, $input: PostPatchInput!, $version: Int) {
+			patchPost(id: $id, input: $input, version: $version) { title version }
+		}`
+
+		errs := s.graphql(bobKey, mutation, map[string]interface{}{"id": created.ID, "input": map[string]interface{}{}}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("BAD_USER_INPUT", errs[0].Extensions.Code)
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{
+			"id":    created.ID,
+			"input": map[string]interface{}{"title": ""},
+		}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("validation error: title required", errs[0].Message)
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{
+			"id":      created.ID,
+			"input":   map[string]interface{}{"title": "patched"},
+			"version": 42,
+		}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("VERSION_MISMATCH", errs[0].Extensions.Code)
+
+		var data struct {
+			PatchPost graphqlPost
+		}
+
+		errs = s.graphql(bobKey, mutation, map[string]interface{}{
+			"id":      created.ID,
+			"input":   map[string]interface{}{"title": "patched"},
+			"version": created.Version,
+		}, &data)
+		s.Empty(errs)
+		s.Equal("patched", data.PatchPost.Title)
+		s.Equal(created.Version+1, data.PatchPost.Version)
+	})
+
+	s.Run("status", func() {
+		mutation := fmt.Sprintf(`mutation { setPostStatus(id: %q, status: PUBLISHED) { status } }`, created.ID)
+
+		errs := s.graphql(bobKey, mutation, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("FORBIDDEN", errs[0].Extensions.Code)
+
+		var data struct {
+			SetPostStatus graphqlPost
+		}
+
+		errs = s.graphql(editorKey, mutation, nil, &data)
+		s.Empty(errs)
+		s.Equal("PUBLISHED", data.SetPostStatus.Status)
+	})
+
+	s.Run("delete", func() {
+		mutation := fmt.Sprintf(`mutation { deletePost(id: %q) }`, created.ID)
+
+		errs := s.graphql(bobKey, mutation, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("FORBIDDEN", errs[0].Extensions.Code)
+
+		var data struct {
+			DeletePost bool
+		}
+
+		errs = s.graphql(editorKey, mutation, nil, &data)
+		s.Empty(errs)
+		s.True(data.DeletePost)
+
+		var restored struct {
+			RestorePost graphqlPost
+		}
+
+		errs = s.graphql(editorKey, fmt.Sprintf(`mutation { restorePost(id: %q) { id } }`, created.ID), nil, &restored)
+		s.Empty(errs)
+		s.Equal(created.ID, restored.RestorePost.ID)
+	})
+
+	s.Run("get", func() {
+		res, err := http.Get(s.srv.URL + "/graphql?query=" + url.QueryEscape(`{ post(id: "1") { title } }`))
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+
+		var response struct {
+			Data struct {
+				Post graphqlPost
+			}
+		}
+
+		s.NoError(jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&response))
+		s.Equal(post1.Title, response.Data.Post.Title)
+
+		res, err = http.Get(s.srv.URL + "/graphql?query=" + url.QueryEscape(`mutation { deletePost(id: "1") }`))
+		s.NoError(err)
+		s.Equal(http.StatusMethodNotAllowed, res.StatusCode)
+	})
+
+	s.Run("invalid", func() {
+		errs := s.graphql("", `{ post(id: "1") { unknown } }`, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Contains(errs[0].Message, `Cannot query field "unknown"`)
+
+		res, err := http.Post(s.srv.URL+"/graphql", "application/json", strings.NewReader(`{}`))
+		s.NoError(err)
+		s.Equal(400, res.StatusCode)
+	})
+
+	s.Run("graphiql", func() {
+		res, err := http.Get(s.srv.URL + "/graphiql")
+		s.NoError(err)
+		s.Equal(200, res.StatusCode)
+		s.Equal("text/html; charset=utf-8", res.Header.Get("Content-Type"))
+	})
+}
+
+func (s *Suite) TestGraphQLConnection() {
+	s.NoError(s.authors.Insert(context.Background(), author.Author{ID: "editor", Name: "Editor"}))
+
+	for _, content := range []string{"rainy weather", "sunny weather", "elections", "sports"} {
+		errs := s.graphql(editorKey, `mutation($input: CreatePostInput!) { createPost(input: $input) { id } }`,
+			map[string]interface{}{
+				"input": map[string]interface{}{"title": "news", "content": content, "status": "PUBLISHED"},
+			}, nil)
+		s.Require().Empty(errs)
+	}
+
+	const query = `query($first: Int, $after: String, $query: String) {
+		posts(first: $first, after: $after, query: $query) {
+			totalCount
+			edges { cursor node { id author { name } } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+		}
+	}`
+
+	s.Run("pages", func() {
+		var (
+			ids   []string
+			after interface{}
+			pages int
+		)
+
+		for {
+			var data struct {
+				Posts graphqlConnection
+			}
+
+			errs := s.graphql("", query, map[string]interface{}{"first": 2, "after": after}, &data)
+			s.Require().Empty(errs)
+			s.Equal(5, data.Posts.TotalCount)
+			s.Equal(pages > 0, data.Posts.PageInfo.HasPreviousPage)
+
+			for _, e := range data.Posts.Edges {
+				ids = append(ids, e.Node.ID)
+			}
+
+			pages++
+
+			if !data.Posts.PageInfo.HasNextPage {
+				break
+			}
+
+			s.Require().NotNil(data.Posts.PageInfo.EndCursor)
+			s.Equal(data.Posts.Edges[len(data.Posts.Edges)-1].Cursor, *data.Posts.PageInfo.EndCursor)
+			after = *data.Posts.PageInfo.EndCursor
+		}
+
+		s.Equal(3, pages)
+		s.Len(ids, 5)
+		s.Equal(post1.ID, ids[4], "the oldest post goes last")
+	})
+
+	s.Run("search", func() {
+		var data struct {
+			Posts graphqlConnection
+		}
+
+		errs := s.graphql("", query, map[string]interface{}{"first": 1, "query": "weather"}, &data)
+		s.Require().Empty(errs)
+		s.Equal(2, data.Posts.TotalCount)
+		s.Require().Len(data.Posts.Edges, 1)
+		s.True(data.Posts.PageInfo.HasNextPage)
+
+		first := data.Posts.Edges[0].Node.ID
+
+		errs = s.graphql("", query, map[string]interface{}{
+			"first": 1,
+			"query": "weather",
+			"after": *data.Posts.PageInfo.EndCursor,
+		}, &data)
+		s.Require().Empty(errs)
+		s.Require().Len(data.Posts.Edges, 1)
+		s.NotEqual(first, data.Posts.Edges[0].Node.ID)
+		s.False(data.Posts.PageInfo.HasNextPage)
+	})
+
+	s.Run("invalid_cursor", func() {
+		errs := s.graphql("", query, map[string]interface{}{"first": 1, "after": "invalid"}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("BAD_USER_INPUT", errs[0].Extensions.Code)
+	})
+
+	s.Run("author_posts", func() {
+		var data struct {
+			Author struct {
+				Name  string
+				Posts graphqlConnection
+			}
+		}
+
+		errs := s.graphql("", `{
+			author(id: "editor") {
+				name
+				posts(first: 2) { totalCount edges { node { author { name } } } }
+			}
+		}`, nil, &data)
+		s.Require().Empty(errs)
+		s.Equal(4, data.Author.Posts.TotalCount)
+		s.Require().Len(data.Author.Posts.Edges, 2)
+		s.Equal("Editor", data.Author.Posts.Edges[0].Node.Author.Name)
+	})
+}
+
+func (s *Suite) TestGraphQLLimits() {
+	s.Run("depth", func() {
+		errs := s.graphql("", `{
+			post(id: "1") { author { posts(first: 1) { edges { node { author { name } } } } } }
+		}`, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("QUERY_TOO_COMPLEX", errs[0].Extensions.Code)
+		s.Equal("query depth 7 exceeds the limit of 6", errs[0].Message)
+	})
+
+	s.Run("complexity", func() {
+		errs := s.graphql("", `{ posts { edges { node { id } } } }`, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("QUERY_TOO_COMPLEX", errs[0].Extensions.Code, "default page size counts")
+
+		errs = s.graphql("", `{ posts(first: 10) { edges { node { id } } } }`, nil, nil)
+		s.Empty(errs)
+
+		errs = s.graphql("", `query($n: Int) { posts(first: $n) { edges { node { id } } } }`,
+			map[string]interface{}{"n": 1000}, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("QUERY_TOO_COMPLEX", errs[0].Extensions.Code)
+
+		errs = s.graphql("", `query($n: Int = 100000) { posts(first: $n) { edges { node { id } } } }`, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("QUERY_TOO_COMPLEX", errs[0].Extensions.Code, "default value of the variable counts")
+
+		var data struct {
+			Posts graphqlConnection
+		}
+
+		errs = s.graphql("", `{ posts(first: 100000) { totalCount } }`, nil, &data)
+		s.Empty(errs, "first is clamped to the max page")
+		s.Equal(1, data.Posts.TotalCount)
+	})
+
+	s.Run("fragments", func() {
+		errs := s.graphql("", `
+			query { posts(first: 100) { ...page } }
+			fragment page on PostConnection { edges { node { id title } } }
+		`, nil, nil)
+		s.Require().Len(errs, 1)
+		s.Equal("QUERY_TOO_COMPLEX", errs[0].Extensions.Code)
+	})
+
+	s.Run("introspection", func() {
+		var data struct {
+			Schema struct {
+				Types []struct {
+					Name string
+				}
+			} `json:"__schema"`
+		}
+
+		errs := s.graphql("", `{
+			__schema { types { name fields { name type { name ofType { name ofType { name ofType { name } } } } } } }
+		}`, nil, &data)
+		s.Empty(errs, "introspection isn't limited")
+		s.NotEmpty(data.Schema.Types)
+	})
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"io"
+
+	newsv1 "github.com/sladonia/news-svc/api/news/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Suite) TestGRPC() {
+	client := newsv1.NewNewsServiceClient(s.grpcConn)
+
+	as := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+
+	s.Run("get", func() {
+		p, err := client.GetPost(context.Background(), &newsv1.GetPostRequest{Id: post1.ID})
+		s.NoError(err)
+		s.Equal(post1.Title, p.Title)
+		s.Equal(newsv1.PostStatus_POST_STATUS_PUBLISHED, p.Status)
+		s.True(post1.CreatedAt.Equal(p.CreatedAt.AsTime()))
+
+		_, err = client.GetPost(context.Background(), &newsv1.GetPostRequest{Id: "missing"})
+		s.Equal(codes.NotFound, status.Code(err))
+	})
+
+	s.Run("request_id", func() {
+		withRequestID := func(id string) context.Context {
+			return metadata.AppendToOutgoingContext(context.Background(), "x-request-id", id)
+		}
+
+		_, err := client.GetPost(withRequestID("grpc-request-1"), &newsv1.GetPostRequest{Id: post1.ID})
+		s.NoError(err)
+		s.NotEmpty(s.logs.FilterField(zap.String("request_id", "grpc-request-1")).All())
+
+		const invalid = "grpc-request-\u00e9"
+
+		_, err = client.GetPost(withRequestID(invalid), &newsv1.GetPostRequest{Id: post1.ID})
+		s.NoError(err)
+		s.Empty(s.logs.FilterField(zap.String("request_id", invalid)).All(), "invalid id is replaced")
+	})
+
+	s.Run("create", func() {
+		request := &newsv1.CreatePostRequest{Title: "grpc", Content: "created over grpc", Tags: []string{"Tech"}}
+
+		_, err := client.CreatePost(context.Background(), request)
+		s.Equal(codes.Unauthenticated, status.Code(err))
+
+		_, err = client.CreatePost(as("unknown-key"), request)
+		s.Equal(codes.Unauthenticated, status.Code(err))
+
+		_, err = client.CreatePost(as(readerKey), request)
+		s.Equal(codes.PermissionDenied, status.Code(err))
+
+		_, err = client.CreatePost(as(bobKey), &newsv1.CreatePostRequest{Title: "grpc"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+
+		p, err := client.CreatePost(as(bobKey), request)
+		s.NoError(err)
+		s.Equal("bob", p.AuthorId)
+		s.Equal([]string{"tech"}, p.Tags)
+		s.Equal(newsv1.PostStatus_POST_STATUS_DRAFT, p.Status)
+
+		request.Status = newsv1.PostStatus_POST_STATUS_PUBLISHED
+
+		_, err = client.CreatePost(as(bobKey), request)
+		s.Equal(codes.PermissionDenied, status.Code(err), "writers create drafts only")
+	})
+
+	s.Run("upsert", func() {
+		request := &newsv1.UpsertPostRequest{Id: post1.ID, Title: "replaced", Content: "replaced content", Version: 42}
+
+		_, err := client.UpsertPost(as(editorKey), request)
+		s.Equal(codes.FailedPrecondition, status.Code(err))
+
+		request.Version = post1.Version
+
+		p, err := client.UpsertPost(as(editorKey), request)
+		s.NoError(err)
+		s.Equal("replaced", p.Title)
+		s.Equal(post1.Version+1, p.Version)
+	})
+
+	s.Run("delete", func() {
+		_, err := client.DeletePost(as(bobKey), &newsv1.DeletePostRequest{Id: post1.ID})
+		s.Equal(codes.PermissionDenied, status.Code(err))
+
+		_, err = client.DeletePost(as(editorKey), &newsv1.DeletePostRequest{Id: post1.ID})
+		s.NoError(err)
+
+		_, err = client.GetPost(context.Background(), &newsv1.GetPostRequest{Id: post1.ID})
+		s.Equal(codes.NotFound, status.Code(err))
+	})
+}
+
+func (s *Suite) TestGRPCFindPosts() {
+	client := newsv1.NewNewsServiceClient(s.grpcConn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", editorKey)
+
+	ids := []string{post1.ID}
+
+	for i := 0; i < 4; i++ {
+		p, err := client.CreatePost(ctx, &newsv1.CreatePostRequest{
+			Title:   "streamed",
+			Content: "streamed content",
+			Status:  newsv1.PostStatus_POST_STATUS_PUBLISHED,
+		})
+		s.Require().NoError(err)
+
+		ids = append([]string{p.Id}, ids...)
+	}
+
+	s.Run("find", func() {
+		response, err := client.FindPosts(context.Background(), &newsv1.FindPostsRequest{})
+		s.NoError(err)
+		s.Len(response.Items, 2, "default limit")
+		s.NotEmpty(response.NextCursor)
+
+		response, err = client.FindPosts(context.Background(), &newsv1.FindPostsRequest{Cursor: response.NextCursor, Limit: 10})
+		s.NoError(err)
+		s.Len(response.Items, 3)
+
+		_, err = client.FindPosts(context.Background(), &newsv1.FindPostsRequest{Cursor: "invalid"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	s.Run("stream", func() {
+		stream, err := client.StreamPosts(context.Background(), &newsv1.FindPostsRequest{Limit: 10})
+		s.Require().NoError(err)
+
+		var streamed []string
+
+		for {
+			p, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+
+			s.Require().NoError(err)
+			streamed = append(streamed, p.Id)
+		}
+
+		s.Equal(ids, streamed, "all the pages are streamed")
+	})
+
+	s.Run("stream_limit", func() {
+		stream, err := client.StreamPosts(context.Background(), &newsv1.FindPostsRequest{Limit: 3})
+		s.Require().NoError(err)
+
+		count := 0
+
+		for {
+			_, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+
+			s.Require().NoError(err)
+			count++
+		}
+
+		s.Equal(3, count)
+	})
+
+	s.Run("stream_default_limit", func() {
+		stream, err := client.StreamPosts(context.Background(), &newsv1.FindPostsRequest{})
+		s.Require().NoError(err)
+
+		count := 0
+
+		for {
+			_, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+
+			s.Require().NoError(err)
+			count++
+		}
+
+		s.Equal(2, count, "zero limit is the default one")
+	})
+}
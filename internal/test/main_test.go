@@ -1,7 +1,10 @@
 package test
 
 import (
+	"context"
 	"database/sql"
+	"net"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -9,19 +12,48 @@ import (
 	"github.com/doug-martin/goqu/v9"
 	"github.com/gorilla/mux"
 	"github.com/ory/dockertest/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sladonia/news-svc/internal/auth"
+	"github.com/sladonia/news-svc/internal/author"
+	"github.com/sladonia/news-svc/internal/feed"
+	"github.com/sladonia/news-svc/internal/graphqlhandler"
+	"github.com/sladonia/news-svc/internal/grpchandler"
 	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/handler/middlewares"
+	"github.com/sladonia/news-svc/internal/health"
 	"github.com/sladonia/news-svc/internal/logger"
+	"github.com/sladonia/news-svc/internal/memstorage"
+	"github.com/sladonia/news-svc/internal/policy"
 	"github.com/sladonia/news-svc/internal/post"
 	"github.com/sladonia/news-svc/internal/poststorage"
+	"github.com/sladonia/news-svc/internal/ratelimit"
 	"github.com/sladonia/news-svc/internal/testtool"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 const (
-	postTableName = "post"
-	testDBNAME    = "news_test"
-	migrationsDir = "../../migration"
+	jwtSecret = "test-secret"
+	adminKey  = "admin-key"
+	editorKey = "editor-key"
+	bobKey    = "bob-key"
+	aliceKey  = "alice-key"
+	readerKey = "reader-key"
+
+	postTableName   = "post"
+	authorTableName = "author"
+	testDBNAME      = "news_test"
+	migrationsDir   = "../../migration"
 )
 
 // db fixtures
@@ -32,38 +64,213 @@ var (
 		Content:   "new era beginning!",
 		CreatedAt: time.Now().UTC().Round(time.Millisecond),
 		UpdatedAt: time.Now().UTC().Round(time.Millisecond),
+		Version:   1,
+		Status:    post.StatusPublished,
 	}
 )
 
+// Suite holds end to end tests. It is run against every post.Storage implementation.
 type Suite struct {
 	suite.Suite
 
-	log               *zap.Logger
-	srv               *httptest.Server
-	db                *goqu.Database
-	storage           post.Storage
-	service           post.Service
-	handler           *handler.Handler
-	dockerPool        *dockertest.Pool
-	postgresContainer *dockertest.Resource
+	log      *zap.Logger
+	srv      *httptest.Server
+	client   *http.Client
+	grpcSrv  *grpc.Server
+	grpcConn *grpc.ClientConn
+	spans    *tracetest.SpanRecorder
+	logs     *observer.ObservedLogs
+	storage  post.Storage
+	authors  author.Storage
+	service  post.Service
+	handler  *handler.Handler
+	truncate func() error
 }
 
-func (s *Suite) SetupSuite() {
+func (s *Suite) setupServer(storage post.Storage, authors author.Storage) {
 	log, err := logger.NewZapLogger("debug")
 	if err != nil {
 		panic(err)
 	}
+
+	var core zapcore.Core
+
+	core, s.logs = observer.New(zap.InfoLevel)
+	log = zap.New(zapcore.NewTee(log.Core(), core))
 	s.log = log
 
-	pool, err := dockertest.NewPool("")
+	s.spans = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	registry := prometheus.NewRegistry()
+	storageMetrics := poststorage.NewMetrics(registry)
+
+	s.storage = storage
+	s.authors = authors
+	s.service = post.NewTracedService(post.NewService(poststorage.NewInstrumented(s.storage, storageMetrics)))
+	s.handler = handler.NewHandler(
+		log,
+		100,
+		100,
+		policy.NewService(s.service, policy.DefaultPolicy()),
+		author.NewService(poststorage.NewInstrumentedAuthorStorage(s.authors, storageMetrics)),
+		"news-sv",
+	)
+
+	r := mux.NewRouter()
+	r.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Name("metrics").Methods("GET")
+	middlewares.NewTracing("news-sv").Register(r)
+	middlewares.NewHandlerLogger(log).Register(r)
+	middlewares.NewMetrics(registry).Register(r)
+	middlewares.NewRecovery(log, registry).Register(r)
+	middlewares.NewJsonResponse().Register(r)
+
+	rateLimitStore := ratelimit.NewMemoryStore()
+	middlewares.NewRateLimit(log, rateLimitStore, ratelimit.Limit{}).
+		ByIP().
+		TrustForwardedFor().
+		Limit(ratelimit.Limit{Rate: 0.001, Burst: 5}, "limited").
+		Register(r)
+	middlewares.NewAuth(log, newAuthenticator()).Require("", "graphql").Register(r)
+	middlewares.NewRateLimit(log, rateLimitStore, ratelimit.Limit{}).
+		Limit(ratelimit.Limit{Rate: 0.001, Burst: 2}, "limited").
+		Register(r)
+	// every request of the suite verifies the handlers against the document
+	middlewares.NewOpenAPIValidation(log, s.handler.OpenAPI()).Register(r)
+	s.handler.Register(r)
+	health.NewHandler(log, newHealthChecker(storage)).Register(r)
+	feed.NewHandler(log, s.service, feed.Meta{
+		Title:       "News",
+		Link:        "http://news.test",
+		Description: "Latest news",
+		ServiceName: "news-sv",
+	}, 50, 100).Register(r)
+	graphqlhandler.NewHandler(
+		log,
+		policy.NewService(s.service, policy.DefaultPolicy()),
+		author.NewService(s.authors),
+		100,
+		graphqlhandler.Limits{MaxDepth: 6, MaxComplexity: 300, MaxPage: 100},
+	).RequireAuth().ServeGraphiQL().Register(r)
+	r.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failure")
+	}).Name("panic").Methods("GET")
+	r.HandleFunc("/limited", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Name("limited").Methods("GET")
+
+	s.srv = httptest.NewServer(r)
+	s.client = &http.Client{Transport: apiKeyTransport{key: adminKey}}
+
+	s.setupGRPCServer(log)
+}
+
+// setupGRPCServer serves the gRPC API over the in-memory connection. Its default limit is small to make
+// the streamed posts span several pages.
+func (s *Suite) setupGRPCServer(log *zap.Logger) {
+	lis := bufconn.Listen(1 << 20)
+
+	s.grpcSrv = grpc.NewServer(grpchandler.ServerOptions(log, newAuthenticator())...)
+	grpchandler.NewServer(log, policy.NewService(s.service, policy.DefaultPolicy()), 2).Register(s.grpcSrv)
+
+	go func() {
+		_ = s.grpcSrv.Serve(lis)
+	}()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	s.grpcConn = conn
+}
+
+func (s *Suite) closeServers() {
+	s.srv.Close()
+	s.grpcConn.Close()
+	s.grpcSrv.Stop()
+}
+
+func newHealthChecker(storage post.Storage) *health.Checker {
+	return health.NewChecker(time.Second).Add("storage", func(ctx context.Context) (interface{}, error) {
+		_, err := storage.ByFilter(ctx, post.Filter{Limit: 1})
+
+		return nil, err
+	})
+}
+
+func newAuthenticator() *auth.Authenticator {
+	apiKeys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Key: adminKey, Subject: "admin", Role: auth.RoleAdmin},
+		{Key: editorKey, Subject: "editor", Role: auth.RoleEditor},
+		{Key: bobKey, Subject: "bob", Role: auth.RoleWriter},
+		{Key: aliceKey, Subject: "alice", Role: auth.RoleWriter},
+		{Key: readerKey, Subject: "reader", Role: auth.RoleReader},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return auth.NewAuthenticator(auth.NewJWTVerifier(auth.JWTConfig{Secret: []byte(jwtSecret)}), apiKeys)
+}
+
+// apiKeyTransport authenticates requests with the key unless they carry their own credentials
+type apiKeyTransport struct {
+	key string
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("X-API-Key") == "" && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-API-Key", t.key)
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *Suite) SetupTest() {
+	err := s.insertFixtures()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Suite) TearDownTest() {
+	err := s.truncate()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *Suite) insertFixtures() error {
+	return s.storage.Insert(context.Background(), post1)
+}
+
+type PostgresSuite struct {
+	Suite
+
+	db                *goqu.Database
+	dockerPool        *dockertest.Pool
+	postgresContainer *dockertest.Resource
+}
+
+func (s *PostgresSuite) SetupSuite() {
+	pool, err := testtool.NewPool()
 	if err != nil {
-		log.Panic("failed to init pool", zap.Error(err))
+		s.T().Skipf("docker is not available: %s", err)
 	}
 	s.dockerPool = pool
 
 	postgresContainer, dbDSN, err := testtool.NewPostgresContainer(pool, testDBNAME)
 	if err != nil {
-		log.Panic("failed to create postgres container", zap.Error(err))
+		panic(err)
 	}
 	s.postgresContainer = postgresContainer
 
@@ -73,24 +280,18 @@ func (s *Suite) SetupSuite() {
 	err = pool.Retry(func() error {
 		postgresClient, err = sql.Open("postgres", dbDSN)
 		if err != nil {
-			log.Panic("failed to connect to db", zap.Error(err))
+			panic(err)
 		}
 
 		return postgresClient.Ping()
 	})
 	if err != nil {
-		log.Panic("database up timeout", zap.Error(err))
+		panic(err)
 	}
 
 	s.db = goqu.New("postgres", postgresClient)
-	s.storage = poststorage.New(s.db, postTableName)
-	s.service = post.NewService(s.storage)
-	s.handler = handler.NewHandler(log, 100, s.service, "news-sv")
-
-	r := mux.NewRouter()
-	s.handler.Register(r)
-
-	s.srv = httptest.NewServer(r)
+	s.truncate = s.deleteAllData
+	s.setupServer(poststorage.New(s.db, postTableName), poststorage.NewAuthorStorage(s.db))
 
 	loc, err := time.LoadLocation("UTC")
 	if err != nil {
@@ -98,15 +299,14 @@ func (s *Suite) SetupSuite() {
 	}
 	goqu.SetTimeLocation(loc)
 
-	err = testtool.ApplyDBMigrations(s.db, migrationsDir)
+	err = testtool.ApplyDBMigrations(postgresClient, migrationsDir)
 	if err != nil {
 		panic(err)
 	}
-
 }
 
-func (s *Suite) TearDownSuite() {
-	s.srv.Close()
+func (s *PostgresSuite) TearDownSuite() {
+	s.closeServers()
 
 	err := s.dockerPool.Purge(s.postgresContainer)
 	if err != nil {
@@ -114,33 +314,36 @@ func (s *Suite) TearDownSuite() {
 	}
 }
 
-func TestRunSuite(t *testing.T) {
-	suite.Run(t, new(Suite))
-}
-
-func (s *Suite) SetupTest() {
-	err := s.insertFixtures()
+func (s *PostgresSuite) deleteAllData() error {
+	_, err := s.db.Delete(postTableName).Executor().Exec()
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	_, err = s.db.Delete(authorTableName).Executor().Exec()
+
+	return err
 }
 
-func (s *Suite) TearDownTest() {
-	err := s.deleteAllData()
-	if err != nil {
-		panic(err)
-	}
+// MemorySuite starts a server with a fresh in-memory storage for every test.
+type MemorySuite struct {
+	Suite
 }
 
-func (s *Suite) insertFixtures() error {
-	post1SQL := poststorage.NewPostSQL(post1)
-	_, err := s.db.Insert(postTableName).Rows(post1SQL).Executor().Exec()
+func (s *MemorySuite) SetupTest() {
+	storage := memstorage.New()
+	s.setupServer(storage, memstorage.NewAuthorStorage(storage))
+	s.Suite.SetupTest()
+}
 
-	return err
+func (s *MemorySuite) TearDownTest() {
+	s.closeServers()
 }
 
-func (s *Suite) deleteAllData() error {
-	_, err := s.db.Delete(postTableName).Executor().Exec()
+func TestRunPostgresSuite(t *testing.T) {
+	suite.Run(t, new(PostgresSuite))
+}
 
-	return err
+func TestRunMemorySuite(t *testing.T) {
+	suite.Run(t, new(MemorySuite))
 }
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sladonia/news-svc/internal/handler"
+	"github.com/sladonia/news-svc/internal/handler/middlewares"
+	"github.com/sladonia/news-svc/internal/openapi"
+)
+
+// routeVariablePattern strips the patterns of the route variables, which OpenAPI paths don't have
+var routeVariablePattern = regexp.MustCompile(`\{(\w+):[^}]+\}`)
+
+func (s *Suite) TestOpenAPI() {
+	res, err := http.Get(fmt.Sprintf("%s/openapi.json", s.srv.URL))
+	s.NoError(err)
+	s.Equal(200, res.StatusCode)
+	s.Equal("application/json", res.Header.Get("Content-Type"))
+
+	var doc openapi.Document
+
+	err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&doc)
+	s.NoError(err)
+	s.Equal("3.1.0", doc.OpenAPI)
+	s.Equal("news-sv", doc.Info.Title)
+
+	// every route of the handler is documented and every operation is routed
+	expected := s.handler.OpenAPI()
+	documented := make(map[string]bool)
+
+	for _, id := range expected.Operations() {
+		documented[id] = true
+	}
+
+	r := mux.NewRouter()
+	s.handler.Register(r)
+
+	err = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		name := route.GetName()
+
+		method, path, _, ok := expected.Operation(name)
+		if !s.Truef(ok, "route %s is not documented", name) {
+			return nil
+		}
+
+		delete(documented, name)
+
+		template, err := route.GetPathTemplate()
+		s.NoError(err)
+		s.Equal(routeVariablePattern.ReplaceAllString(template, "{$1}"), path, name)
+
+		// routes without methods match any, the document describes GET
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"GET"}
+		}
+
+		s.Equal([]string{method}, methods, name)
+
+		s.Contains(doc.Paths, path)
+		s.Contains(doc.Paths[path], strings.ToLower(method))
+
+		return nil
+	})
+	s.NoError(err)
+	s.Empty(documented, "operations without the routes")
+}
+
+func (s *Suite) TestOpenAPIValidation() {
+	do := func(method, path, body string) (*http.Response, handler.ApiError) {
+		req, err := http.NewRequest(method, fmt.Sprintf("%s%s", s.srv.URL, path), strings.NewReader(body))
+		s.NoError(err)
+
+		res, err := s.client.Do(req)
+		s.NoError(err)
+
+		var apiErr handler.ApiError
+
+		if res.StatusCode >= 400 {
+			err = jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiErr)
+			s.NoError(err)
+		}
+
+		return res, apiErr
+	}
+
+	s.Run("body", func() {
+		tags := `"` + strings.Repeat(`tag", "`, 20) + `tag"`
+
+		res, apiErr := do("POST", "/posts", `{"title": "", "tags": [`+tags+`, ""]}`)
+		s.Equal(400, res.StatusCode)
+		s.Equal("validation error: content required tags max tags[21] required title required", apiErr.Error.Message)
+		s.Equal(handler.LevelUser, apiErr.Error.Level)
+	})
+
+	s.Run("body_type", func() {
+		res, apiErr := do("PUT", "/posts/1/status", `{"status": "published", "publish_at": 1}`)
+		s.Equal(400, res.StatusCode)
+		s.Equal("validation error: publish_at type", apiErr.Error.Message)
+	})
+
+	s.Run("invalid_json", func() {
+		res, apiErr := do("POST", "/authors", `{"name":`)
+		s.Equal(400, res.StatusCode)
+		s.Equal("failed to unmarshal json", apiErr.Error.Message)
+	})
+
+	s.Run("query", func() {
+		res, apiErr := do("GET", "/posts?limit=ten&status=published,hidden&from=yesterday", "")
+		s.Equal(400, res.StatusCode)
+		s.Equal("validation error: limit type from date-time status[1] oneof", apiErr.Error.Message)
+	})
+
+	s.Run("required_query", func() {
+		res, apiErr := do("GET", "/posts/1/revisions/diff?from=1", "")
+		s.Equal(400, res.StatusCode)
+		s.Equal("validation error: to required", apiErr.Error.Message)
+	})
+
+	s.Run("valid", func() {
+		res, _ := do("POST", "/posts", `{"title": "title", "content": "content", "tags": ["go"], "publish_at": null}`)
+		s.Equal(201, res.StatusCode)
+	})
+
+	s.Run("invalid_response", func() {
+		r := mux.NewRouter()
+		middlewares.NewOpenAPIValidation(s.log, s.handler.OpenAPI()).Register(r)
+		r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"service": "news-sv"}`))
+		}).Name("identity")
+
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+		s.Equal(500, res.Code)
+
+		var apiErr handler.ApiError
+
+		err := jsoniter.ConfigFastest.NewDecoder(res.Body).Decode(&apiErr)
+		s.NoError(err)
+		s.Equal("response validation error: validation error: service_name required", apiErr.Error.Message)
+		s.Equal(handler.LevelSystem, apiErr.Error.Level)
+		s.Len(s.logs.FilterMessage("response does not match openapi document").All(), 1)
+	})
+}
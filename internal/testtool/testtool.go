@@ -1,14 +1,15 @@
 package testtool
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"sort"
 
-	"github.com/doug-martin/goqu/v9"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
+	"github.com/sladonia/news-svc/internal/migrator"
+	"go.uber.org/zap"
 )
 
 const (
@@ -16,6 +17,22 @@ const (
 	user     = "user"
 )
 
+// NewPool connects to the docker daemon and makes sure it is reachable,
+// so suites depending on containers can be skipped on hosts without docker.
+func NewPool() (*dockertest.Pool, error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, err
+	}
+
+	err = pool.Client.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
 func NewPostgresContainer(pool *dockertest.Pool, dbName string) (*dockertest.Resource, string, error) {
 	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
 		Repository: "postgres",
@@ -40,41 +57,12 @@ func NewPostgresContainer(pool *dockertest.Pool, dbName string) (*dockertest.Res
 	return resource, dbDSN, nil
 }
 
-func ApplyDBMigrations(db *goqu.Database, migrationsDIR string) error {
-	fileInfos, err := ioutil.ReadDir(migrationsDIR)
+// ApplyDBMigrations migrates the database up to the latest version found in migrationsDIR
+func ApplyDBMigrations(db *sql.DB, migrationsDIR string) error {
+	migrations, err := migrator.Load(os.DirFS(migrationsDIR))
 	if err != nil {
 		return err
 	}
 
-	sort.SliceStable(fileInfos, func(i, j int) bool {
-		return fileInfos[i].Name() < fileInfos[j].Name()
-	})
-
-	for _, fileInfo := range fileInfos {
-		filePath := fmt.Sprintf("%s/%s", migrationsDIR, fileInfo.Name())
-
-		err = runMigrationFile(db, filePath)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return migrator.New(db, migrations, zap.NewNop()).Up(context.Background())
 }
-
-func runMigrationFile(db *goqu.Database, filePath string) error {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-
-	sqlStr, err := ioutil.ReadAll(f)
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(string(sqlStr))
-
-	return err
-}
-
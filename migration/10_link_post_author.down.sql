@@ -0,0 +1,11 @@
+DROP INDEX author_id_idx;
+CREATE INDEX author_id_idx on post using btree(author_id) WHERE author_id <> '';
+
+ALTER TABLE post DROP CONSTRAINT post_author_id_fkey;
+
+UPDATE post SET author_id = '' WHERE author_id IS NULL;
+ALTER TABLE post ALTER COLUMN author_id SET DEFAULT '';
+ALTER TABLE post ALTER COLUMN author_id SET NOT NULL;
+
+-- ids stay TEXT, subjects longer than 20 characters don't fit the former VARCHAR(20) columns.
+-- The columns are dropped along with the author table by the down migration of version 9.
@@ -0,0 +1,20 @@
+-- author ids are the subjects of the principals, which aren't limited to xids
+ALTER TABLE author ALTER COLUMN id TYPE TEXT;
+ALTER TABLE post ALTER COLUMN author_id TYPE TEXT;
+
+-- anonymous posts have no author
+ALTER TABLE post ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE post ALTER COLUMN author_id DROP DEFAULT;
+UPDATE post SET author_id = NULL WHERE author_id = '';
+
+-- the principals who wrote posts before get the author profiles
+INSERT INTO author (id, name, email, bio, created_at, updated_at)
+SELECT DISTINCT author_id, author_id, '', '', now() AT TIME ZONE 'UTC', now() AT TIME ZONE 'UTC'
+FROM post
+WHERE author_id IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE post ADD CONSTRAINT post_author_id_fkey FOREIGN KEY (author_id) REFERENCES author (id) ON DELETE SET NULL;
+
+DROP INDEX author_id_idx;
+CREATE INDEX author_id_idx on post using btree(author_id) WHERE author_id IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE post_revision DROP COLUMN category;
+ALTER TABLE post_revision DROP COLUMN tags;
@@ -0,0 +1,3 @@
+-- tags and category of the earlier revisions weren't recorded, they are left NULL
+ALTER TABLE post_revision ADD COLUMN tags TEXT[];
+ALTER TABLE post_revision ADD COLUMN category VARCHAR(50);
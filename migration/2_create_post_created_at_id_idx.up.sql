@@ -0,0 +1,3 @@
+DROP INDEX created_at_idx;
+
+CREATE INDEX created_at_id_idx on post using btree(created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP INDEX search_vector_idx;
+
+ALTER TABLE post DROP COLUMN search_vector;
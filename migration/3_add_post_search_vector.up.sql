@@ -0,0 +1,7 @@
+ALTER TABLE post
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', content), 'B')
+    ) STORED;
+
+CREATE INDEX search_vector_idx on post using gin(search_vector);
@@ -0,0 +1 @@
+ALTER TABLE post ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX deleted_at_idx;
+
+ALTER TABLE post DROP COLUMN deleted_at;
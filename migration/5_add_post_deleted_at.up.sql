@@ -0,0 +1,3 @@
+ALTER TABLE post ADD COLUMN deleted_at timestamp NULL;
+
+CREATE INDEX deleted_at_idx on post using btree(deleted_at) WHERE deleted_at IS NOT NULL;
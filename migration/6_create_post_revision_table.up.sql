@@ -0,0 +1,13 @@
+CREATE TABLE post_revision
+(
+    post_id VARCHAR(20) NOT NULL REFERENCES post (id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    author TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at timestamp NOT NULL,
+    PRIMARY KEY (post_id, number)
+);
+
+INSERT INTO post_revision (post_id, number, author, title, content, created_at)
+SELECT id, 1, '', title, content, updated_at FROM post;
@@ -0,0 +1,3 @@
+DROP INDEX scheduled_publish_at_idx;
+ALTER TABLE post DROP COLUMN publish_at;
+ALTER TABLE post DROP COLUMN status;
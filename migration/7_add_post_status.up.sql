@@ -0,0 +1,7 @@
+-- posts created before the lifecycle was introduced stay visible
+ALTER TABLE post ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE post ADD COLUMN publish_at timestamp;
+UPDATE post SET publish_at = created_at;
+ALTER TABLE post ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX scheduled_publish_at_idx on post using btree(publish_at) WHERE status = 'scheduled';
@@ -0,0 +1,5 @@
+DROP TABLE post_tag;
+DROP TABLE tag;
+
+DROP INDEX category_idx;
+ALTER TABLE post DROP COLUMN category;
@@ -0,0 +1,18 @@
+ALTER TABLE post ADD COLUMN category VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX category_idx on post using btree(category) WHERE category <> '';
+
+CREATE TABLE tag
+(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE post_tag
+(
+    post_id VARCHAR(20) NOT NULL REFERENCES post (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE INDEX post_tag_tag_id_idx on post_tag using btree(tag_id);
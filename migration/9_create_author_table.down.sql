@@ -0,0 +1,4 @@
+DROP INDEX author_id_idx;
+ALTER TABLE post DROP COLUMN author_id;
+
+DROP TABLE author;
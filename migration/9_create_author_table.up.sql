@@ -0,0 +1,15 @@
+CREATE TABLE author
+(
+    id VARCHAR(20) NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    bio TEXT NOT NULL,
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL
+);
+
+CREATE INDEX author_name_idx on author using btree(name, id);
+
+ALTER TABLE post ADD COLUMN author_id VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX author_id_idx on post using btree(author_id) WHERE author_id <> '';
@@ -0,0 +1,7 @@
+// Package migration embeds the sql migrations, so binaries don't depend on the files being shipped next to them.
+package migration
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS